@@ -0,0 +1,38 @@
+package confluent
+
+import (
+	"fmt"
+)
+
+type InvalidMagicByteError struct {
+	MagicByte byte
+}
+
+func (e *InvalidMagicByteError) Error() string {
+	return fmt.Sprintf("Invalid magic byte %#x, expected %#x", e.MagicByte, MagicByte)
+}
+
+type UnknownSchemaIDError struct {
+	ID int32
+}
+
+func (e *UnknownSchemaIDError) Error() string {
+	return fmt.Sprintf("No schema registered with ID %v", e.ID)
+}
+
+type UnknownSchemaError struct {
+	Schema string
+}
+
+func (e *UnknownSchemaError) Error() string {
+	return fmt.Sprintf("No ID registered for schema %v", e.Schema)
+}
+
+type UnregisteredTypeError struct {
+	ID     int32
+	Schema string
+}
+
+func (e *UnregisteredTypeError) Error() string {
+	return fmt.Sprintf("No record type registered for schema ID %v: %v", e.ID, e.Schema)
+}
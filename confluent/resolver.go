@@ -0,0 +1,66 @@
+package confluent
+
+import (
+	"sync"
+)
+
+/*
+  SchemaResolver maps schemas to and from the IDs written in the Confluent wire format.
+  Implementations are usually backed by a schema registry.
+*/
+type SchemaResolver interface {
+	// SchemaID returns the ID under which the given schema is registered
+	SchemaID(schema string) (int32, error)
+	// Schema returns the schema registered with the given ID
+	Schema(id int32) (string, error)
+}
+
+/*
+  MemoryResolver is a SchemaResolver backed by an in-memory map, for tests and for
+  deployments where the set of schemas is known ahead of time.
+*/
+type MemoryResolver struct {
+	lock    sync.RWMutex
+	schemas map[int32]string
+	ids     map[string]int32
+}
+
+func NewMemoryResolver() *MemoryResolver {
+	return &MemoryResolver{
+		schemas: make(map[int32]string),
+		ids:     make(map[string]int32),
+	}
+}
+
+/*
+  Add a schema to the resolver with the given ID, replacing any schema previously registered with that ID.
+*/
+func (m *MemoryResolver) Register(id int32, schema string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if old, ok := m.schemas[id]; ok {
+		delete(m.ids, old)
+	}
+	m.schemas[id] = schema
+	m.ids[schema] = id
+}
+
+func (m *MemoryResolver) SchemaID(schema string) (int32, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	id, ok := m.ids[schema]
+	if !ok {
+		return 0, &UnknownSchemaError{Schema: schema}
+	}
+	return id, nil
+}
+
+func (m *MemoryResolver) Schema(id int32) (string, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	schema, ok := m.schemas[id]
+	if !ok {
+		return "", &UnknownSchemaIDError{ID: id}
+	}
+	return schema, nil
+}
@@ -0,0 +1,192 @@
+// Package confluent reads and writes gogen-avro records framed in the Confluent wire format:
+// a zero magic byte, the 4-byte big-endian ID of the writer's schema and then the binary Avro datum.
+package confluent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"github.com/alanctgardner/gogen-avro/container"
+	"github.com/alanctgardner/gogen-avro/types"
+)
+
+// The first byte of every message in the Confluent wire format
+const MagicByte byte = 0
+
+// The length of the magic byte and schema ID which prefix every message
+const HeaderSize = 5
+
+/*
+  DeserializeFunc reads a single record from the reader. Wrap the Deserialize<RecordType>
+  function generated for each record to get a DeserializeFunc.
+*/
+type DeserializeFunc func(r io.Reader) (container.AvroRecord, error)
+
+/*
+  Serializer writes records prefixed with the ID the SchemaResolver returns for their schema.
+*/
+type Serializer struct {
+	resolver SchemaResolver
+}
+
+func NewSerializer(resolver SchemaResolver) *Serializer {
+	return &Serializer{resolver: resolver}
+}
+
+/*
+  Write the header and the encoded record to the writer.
+*/
+func (s *Serializer) Serialize(record container.AvroRecord, w io.Writer) error {
+	id, err := s.resolver.SchemaID(record.Schema())
+	if err != nil {
+		return err
+	}
+	err = writeHeader(id, w)
+	if err != nil {
+		return err
+	}
+	return record.Serialize(w)
+}
+
+/*
+  Return the header and the encoded record as a new byte slice.
+*/
+func (s *Serializer) Marshal(record container.AvroRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	err := s.Serialize(record, &buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+/*
+  Deserializer reads framed records, picking the generated type to decode from the schema ID in the header.
+  Record types are matched to schema IDs by the fingerprint of their Parsing Canonical Form, so the
+  schema returned by the SchemaResolver doesn't need to be byte-for-byte identical to the generated one.
+*/
+type Deserializer struct {
+	resolver SchemaResolver
+	lock     sync.RWMutex
+	types    map[uint64]DeserializeFunc
+	ids      map[int32]DeserializeFunc
+}
+
+func NewDeserializer(resolver SchemaResolver) *Deserializer {
+	return &Deserializer{
+		resolver: resolver,
+		types:    make(map[uint64]DeserializeFunc),
+		ids:      make(map[int32]DeserializeFunc),
+	}
+}
+
+/*
+  Register the function used to decode records written with the given schema.
+  Returns an error if the schema can't be parsed.
+*/
+func (d *Deserializer) Register(schema string, fn DeserializeFunc) error {
+	fingerprint, err := schemaFingerprint(schema)
+	if err != nil {
+		return err
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.types[fingerprint] = fn
+	return nil
+}
+
+/*
+  Read the header and a single record from the reader. Returns an UnknownSchemaIDError if the
+  SchemaResolver doesn't know the ID, or an UnregisteredTypeError if no record type was
+  registered for the schema with that ID.
+*/
+func (d *Deserializer) Deserialize(r io.Reader) (container.AvroRecord, error) {
+	id, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	fn, err := d.deserializerForID(id)
+	if err != nil {
+		return nil, err
+	}
+	return fn(r)
+}
+
+/*
+  Decode a single framed record from the byte slice.
+*/
+func (d *Deserializer) Unmarshal(b []byte) (container.AvroRecord, error) {
+	return d.Deserialize(bytes.NewReader(b))
+}
+
+func (d *Deserializer) deserializerForID(id int32) (DeserializeFunc, error) {
+	d.lock.RLock()
+	fn, ok := d.ids[id]
+	d.lock.RUnlock()
+	if ok {
+		return fn, nil
+	}
+
+	schema, err := d.resolver.Schema(id)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint, err := schemaFingerprint(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	fn, ok = d.types[fingerprint]
+	if !ok {
+		return nil, &UnregisteredTypeError{ID: id, Schema: schema}
+	}
+	d.ids[id] = fn
+	return fn, nil
+}
+
+/*
+  Read the magic byte and schema ID which prefix a message in the Confluent wire format.
+*/
+func readHeader(r io.Reader) (int32, error) {
+	var header [HeaderSize]byte
+	_, err := io.ReadFull(r, header[:])
+	if err != nil {
+		return 0, err
+	}
+	if header[0] != MagicByte {
+		return 0, &InvalidMagicByteError{MagicByte: header[0]}
+	}
+	return int32(binary.BigEndian.Uint32(header[1:])), nil
+}
+
+func writeHeader(id int32, w io.Writer) error {
+	var header [HeaderSize]byte
+	header[0] = MagicByte
+	binary.BigEndian.PutUint32(header[1:], uint32(id))
+	_, err := w.Write(header[:])
+	return err
+}
+
+/*
+  Parse the schema and return the CRC-64-AVRO fingerprint of its Parsing Canonical Form.
+*/
+func schemaFingerprint(schema string) (uint64, error) {
+	namespace := types.NewNamespace()
+	field, err := namespace.FieldDefinitionForSchema([]byte(schema))
+	if err != nil {
+		return 0, err
+	}
+	err = field.ResolveReferences(namespace)
+	if err != nil {
+		return 0, err
+	}
+	canonical, err := types.CanonicalForm(field)
+	if err != nil {
+		return 0, err
+	}
+	return types.CRC64Fingerprint([]byte(canonical)), nil
+}
@@ -2,9 +2,13 @@ package generator
 
 import (
 	"fmt"
+	"go/ast"
 	"go/format"
+	"go/parser"
+	"go/token"
 	"io/ioutil"
 	"os"
+	"path"
 	"sort"
 	"strings"
 )
@@ -20,16 +24,19 @@ type File struct {
 	imports    map[string]interface{}
 	constants  map[string]interface{}
 	rawContent string
+	// The names imports are imported as, if they're imported under another name
+	importNames map[string]string
 }
 
 func NewFile(name string) *File {
 	return &File{
-		name:       name,
-		functions:  make(map[FunctionName]string),
-		structs:    make(map[string]string),
-		imports:    make(map[string]interface{}),
-		constants:  make(map[string]interface{}),
-		rawContent: "",
+		name:        name,
+		functions:   make(map[FunctionName]string),
+		structs:     make(map[string]string),
+		imports:     make(map[string]interface{}),
+		constants:   make(map[string]interface{}),
+		rawContent:  "",
+		importNames: make(map[string]string),
 	}
 }
 
@@ -59,6 +66,35 @@ func (f *File) WriteFile(pkgName, targetFile string) error {
 	return nil
 }
 
+/*
+Add the imports, out of the package's imports by name, which the file refers to. A name refers to an
+import when it's used as the qualifier of a selector, like people.Customer, without being declared in the file.
+*/
+func (f *File) addPackageImports(pkgName string, imports map[string]string) error {
+	if len(imports) == 0 {
+		return nil
+	}
+	src := fmt.Sprintf("package %v\n%v\n%v\n%v\n%s\n", pkgName, f.constantString(), f.structString(), f.functionString(), f.rawContent)
+	parsed, err := parser.ParseFile(token.NewFileSet(), f.name, src, 0)
+	if err != nil {
+		return fmt.Errorf("Error parsing file %v - %v\n\nContents: %v", f.name, err, src)
+	}
+	ast.Inspect(parsed, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil {
+				if importPath, ok := imports[ident.Name]; ok {
+					f.imports[importPath] = 1
+					if ident.Name != path.Base(importPath) {
+						f.importNames[importPath] = ident.Name
+					}
+				}
+			}
+		}
+		return true
+	})
+	return nil
+}
+
 func (f *File) Imports() []string {
 	imports := make([]string, 0)
 	for i, _ := range f.imports {
@@ -100,7 +136,11 @@ func (f *File) importString() string {
 	}
 	imports := "import (\n"
 	for i, _ := range f.imports {
-		imports += fmt.Sprintf("%q\n", i)
+		if name, ok := f.importNames[i]; ok {
+			imports += fmt.Sprintf("%v %q\n", name, i)
+		} else {
+			imports += fmt.Sprintf("%q\n", i)
+		}
 	}
 	imports += ")"
 	return imports
@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"os"
 	"path/filepath"
 	"sort"
 )
@@ -9,22 +10,62 @@ import (
 type Package struct {
 	name  string
 	files map[string]*File
+	// Packages written to subdirectories of the target directory, by their relative path
+	packages map[string]*Package
+	// The import paths of other packages, by the name they're imported as
+	imports map[string]string
 }
 
 func NewPackage(name string) *Package {
-	return &Package{name: name, files: make(map[string]*File)}
+	return &Package{name: name, files: make(map[string]*File), packages: make(map[string]*Package), imports: make(map[string]string)}
+}
+
+func (p *Package) Name() string {
+	return p.name
 }
 
 func (p *Package) WriteFiles(targetDir string) error {
 	for _, f := range p.files {
-		err := f.WriteFile(p.name, filepath.Join(targetDir, f.name))
+		err := f.addPackageImports(p.name, p.imports)
+		if err != nil {
+			return err
+		}
+		err = f.WriteFile(p.name, filepath.Join(targetDir, f.name))
 		if err != nil {
 			return err
 		}
 	}
+	for dir, sub := range p.packages {
+		subDir := filepath.Join(targetDir, dir)
+		if err := os.MkdirAll(subDir, os.ModeDir|0755); err != nil {
+			return err
+		}
+		if err := sub.WriteFiles(subDir); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// Get the package written to dir, a path relative to this package's directory, adding it if it doesn't exist
+func (p *Package) SubPackage(dir, name string) *Package {
+	sub, ok := p.packages[dir]
+	if !ok {
+		sub = NewPackage(name)
+		p.packages[dir] = sub
+	}
+	return sub
+}
+
+func (p *Package) SubPackages() []string {
+	dirs := make([]string, 0)
+	for dir := range p.packages {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
 func (p *Package) Files() []string {
 	files := make([]string, 0)
 	for file, _ := range p.files {
@@ -86,6 +127,11 @@ func (p *Package) AddImport(file, name string) {
 	f.imports[name] = 1
 }
 
+// Import the package at path as name in every file which refers to it, like a qualified type in a struct field
+func (p *Package) AddPackageImport(name, path string) {
+	p.imports[name] = path
+}
+
 func (p *Package) AddConstant(file, name string, value interface{}) {
 	f, ok := p.files[file]
 	if !ok {
@@ -0,0 +1,60 @@
+// Package idl parses Avro IDL (.avdl) files into the JSON definition of the protocol they declare,
+// which can be decoded with types.Namespace.ProtocolDefinitionForSchema.
+package idl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+/*
+  ParseError describes a syntax error in an IDL file.
+*/
+type ParseError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%v:%v:%v: %v", e.File, e.Line, e.Column, e.Message)
+}
+
+/*
+  Read and parse the IDL file at the given path, and return the JSON definition of the protocol.
+*/
+func ParseFile(path string) ([]byte, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, src)
+}
+
+/*
+  Parse the IDL source and return the JSON definition of the protocol. The file name is used in
+  error messages, and imports are resolved relative to the directory containing it.
+*/
+func Parse(fileName string, src []byte) ([]byte, error) {
+	i := &importer{imported: make(map[string]bool)}
+	if abs, err := filepath.Abs(fileName); err == nil {
+		i.imported[abs] = true
+	}
+	p, err := i.parse(fileName, src)
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't escape the angle brackets and ampersands which are common in doc comments
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(p.definition()); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(buf.Bytes()), nil
+}
@@ -0,0 +1,214 @@
+package idl
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+/*
+  A token of Avro IDL. Doc comments aren't tokens themselves - the text of the
+  doc comment immediately before a token is attached to it.
+*/
+type token struct {
+	kind tokenKind
+	text string
+	doc  string
+	line int
+	col  int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of file"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+type lexer struct {
+	file string
+	src  []rune
+	pos  int
+	line int
+	col  int
+}
+
+func newLexer(file string, src []byte) *lexer {
+	return &lexer{file: file, src: []rune(string(src)), line: 1, col: 1}
+}
+
+/*
+  Split the whole source into tokens, ending with a tokenEOF.
+*/
+func (l *lexer) tokens() ([]token, error) {
+	var tokens []token
+	for {
+		t, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+		if t.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+func (l *lexer) errorf(line, col int, format string, args ...interface{}) error {
+	return &ParseError{File: l.file, Line: line, Column: col, Message: fmt.Sprintf(format, args...)}
+}
+
+func (l *lexer) peek(offset int) rune {
+	if l.pos+offset >= len(l.src) {
+		return 0
+	}
+	return l.src[l.pos+offset]
+}
+
+func (l *lexer) advance() rune {
+	r := l.src[l.pos]
+	l.pos++
+	if r == '\n' {
+		l.line++
+		l.col = 1
+	} else {
+		l.col++
+	}
+	return r
+}
+
+func (l *lexer) next() (token, error) {
+	doc := ""
+	for l.pos < len(l.src) {
+		r := l.peek(0)
+		switch {
+		case unicode.IsSpace(r):
+			l.advance()
+		case r == '/' && l.peek(1) == '/':
+			for l.pos < len(l.src) && l.peek(0) != '\n' {
+				l.advance()
+			}
+		case r == '/' && l.peek(1) == '*':
+			line, col := l.line, l.col
+			isDoc := l.peek(2) == '*' && l.peek(3) != '/'
+			l.advance()
+			l.advance()
+			start := l.pos
+			for !(l.peek(0) == '*' && l.peek(1) == '/') {
+				if l.pos >= len(l.src) {
+					return token{}, l.errorf(line, col, "Unterminated comment")
+				}
+				l.advance()
+			}
+			if isDoc {
+				doc = docText(string(l.src[start+1 : l.pos]))
+			}
+			l.advance()
+			l.advance()
+		default:
+			t, err := l.scan()
+			t.doc = doc
+			return t, err
+		}
+	}
+	return token{kind: tokenEOF, doc: doc, line: l.line, col: l.col}, nil
+}
+
+func (l *lexer) scan() (token, error) {
+	line, col := l.line, l.col
+	r := l.peek(0)
+	switch {
+	case r == '"':
+		return l.scanString()
+	case r == '`':
+		l.advance()
+		start := l.pos
+		for l.peek(0) != '`' {
+			if l.pos >= len(l.src) || l.peek(0) == '\n' {
+				return token{}, l.errorf(line, col, "Unterminated quoted identifier")
+			}
+			l.advance()
+		}
+		text := string(l.src[start:l.pos])
+		l.advance()
+		return token{kind: tokenIdent, text: text, line: line, col: col}, nil
+	case r == '-' || unicode.IsDigit(r):
+		start := l.pos
+		l.advance()
+		for isNumberRune(l.peek(0)) {
+			l.advance()
+		}
+		return token{kind: tokenNumber, text: string(l.src[start:l.pos]), line: line, col: col}, nil
+	case isIdentStart(r):
+		start := l.pos
+		for isIdentRune(l.peek(0)) {
+			l.advance()
+		}
+		return token{kind: tokenIdent, text: string(l.src[start:l.pos]), line: line, col: col}, nil
+	case strings.ContainsRune("{}()[]<>,;=:?@", r):
+		l.advance()
+		return token{kind: tokenSymbol, text: string(r), line: line, col: col}, nil
+	}
+	return token{}, l.errorf(line, col, "Unexpected character %q", r)
+}
+
+/*
+  Scan a JSON string literal. The token text keeps the quotes and escapes, so it can be decoded with encoding/json.
+*/
+func (l *lexer) scanString() (token, error) {
+	line, col := l.line, l.col
+	start := l.pos
+	l.advance()
+	for l.peek(0) != '"' {
+		if l.pos >= len(l.src) || l.peek(0) == '\n' {
+			return token{}, l.errorf(line, col, "Unterminated string")
+		}
+		if l.peek(0) == '\\' {
+			l.advance()
+			if l.pos >= len(l.src) || l.peek(0) == '\n' {
+				return token{}, l.errorf(line, col, "Unterminated string")
+			}
+		}
+		l.advance()
+	}
+	l.advance()
+	return token{kind: tokenString, text: string(l.src[start:l.pos]), line: line, col: col}, nil
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+/*
+  Identifiers may be qualified with a namespace, so dots are allowed after the first rune.
+*/
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isNumberRune(r rune) bool {
+	return unicode.IsDigit(r) || strings.ContainsRune(".eE+-", r)
+}
+
+/*
+  Strip the leading asterisks and indentation from each line of a doc comment.
+*/
+func docText(comment string) string {
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "*")
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,755 @@
+package idl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+)
+
+// IDL keywords for logical types, and the annotated type each one stands for
+var logicalTypes = map[string]map[string]interface{}{
+	"date":               {"type": "int", "logicalType": "date"},
+	"time_ms":            {"type": "int", "logicalType": "time-millis"},
+	"timestamp_ms":       {"type": "long", "logicalType": "timestamp-millis"},
+	"local_timestamp_ms": {"type": "long", "logicalType": "local-timestamp-millis"},
+	"uuid":               {"type": "string", "logicalType": "uuid"},
+}
+
+var primitiveTypes = map[string]bool{
+	"null":    true,
+	"boolean": true,
+	"int":     true,
+	"long":    true,
+	"float":   true,
+	"double":  true,
+	"bytes":   true,
+	"string":  true,
+}
+
+/*
+  The importer tracks the files which have already been imported, so each file is only
+  imported once even if several files import it.
+*/
+type importer struct {
+	imported map[string]bool
+}
+
+func (i *importer) parse(fileName string, src []byte) (*protocol, error) {
+	tokens, err := newLexer(fileName, src).tokens()
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{
+		importer: i,
+		file:     fileName,
+		dir:      filepath.Dir(fileName),
+		tokens:   tokens,
+	}
+	return p.parseProtocol()
+}
+
+/*
+  Return false if the file has already been imported, and mark it as imported otherwise.
+*/
+func (i *importer) markImported(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	if i.imported[abs] {
+		return false
+	}
+	i.imported[abs] = true
+	return true
+}
+
+/*
+  A recursive-descent parser for a single IDL file.
+*/
+type parser struct {
+	*importer
+	file   string
+	dir    string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return &ParseError{File: p.file, Line: t.line, Column: t.col, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) isSymbol(s string) bool {
+	t := p.peek()
+	return t.kind == tokenSymbol && t.text == s
+}
+
+func (p *parser) isKeyword(k string) bool {
+	t := p.peek()
+	return t.kind == tokenIdent && t.text == k
+}
+
+func (p *parser) expectSymbol(s string) error {
+	t := p.next()
+	if t.kind != tokenSymbol || t.text != s {
+		return p.errorf(t, "Expected %q, found %v", s, t)
+	}
+	return nil
+}
+
+func (p *parser) expectKeyword(k string) error {
+	t := p.next()
+	if t.kind != tokenIdent || t.text != k {
+		return p.errorf(t, "Expected %q, found %v", k, t)
+	}
+	return nil
+}
+
+func (p *parser) expectIdent() (string, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		return "", p.errorf(t, "Expected an identifier, found %v", t)
+	}
+	return t.text, nil
+}
+
+func (p *parser) expectString() (string, error) {
+	t := p.next()
+	if t.kind != tokenString {
+		return "", p.errorf(t, "Expected a string, found %v", t)
+	}
+	var s string
+	if err := json.Unmarshal([]byte(t.text), &s); err != nil {
+		return "", p.errorf(t, "Invalid string %v: %v", t.text, err)
+	}
+	return s, nil
+}
+
+func (p *parser) expectInt() (int, error) {
+	t := p.next()
+	if t.kind != tokenNumber {
+		return 0, p.errorf(t, "Expected an integer, found %v", t)
+	}
+	i, err := strconv.Atoi(t.text)
+	if err != nil {
+		return 0, p.errorf(t, "Expected an integer, found %v", t)
+	}
+	return i, nil
+}
+
+/*
+  protocol := annotation* "protocol" name "{" declaration* "}"
+*/
+func (p *parser) parseProtocol() (*protocol, error) {
+	proto := newProtocol()
+	proto.doc = p.peek().doc
+	annotations, err := p.parseAnnotations()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("protocol"); err != nil {
+		return nil, err
+	}
+	if proto.name, err = p.expectIdent(); err != nil {
+		return nil, err
+	}
+	if proto.namespace, err = namespaceAnnotation(annotations); err != nil {
+		return nil, p.errorf(p.peek(), "%v", err)
+	}
+	for k, v := range annotations {
+		proto.props[k] = v
+	}
+
+	if err := p.expectSymbol("{"); err != nil {
+		return nil, err
+	}
+	for !p.isSymbol("}") {
+		if p.peek().kind == tokenEOF {
+			return nil, p.errorf(p.peek(), "Expected \"}\", found %v", p.peek())
+		}
+		if err := p.parseDeclaration(proto); err != nil {
+			return nil, err
+		}
+	}
+	p.next()
+	if t := p.next(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "Unexpected %v after protocol", t)
+	}
+	return proto, nil
+}
+
+func (p *parser) parseDeclaration(proto *protocol) error {
+	if p.isKeyword("import") {
+		return p.parseImport(proto)
+	}
+
+	doc := p.peek().doc
+	annotations, err := p.parseAnnotations()
+	if err != nil {
+		return err
+	}
+
+	var schema map[string]interface{}
+	switch {
+	case p.isKeyword("record"), p.isKeyword("error"):
+		schema, err = p.parseRecord()
+	case p.isKeyword("enum"):
+		schema, err = p.parseEnum()
+	case p.isKeyword("fixed"):
+		schema, err = p.parseFixed()
+	default:
+		return p.parseMessage(proto, doc, annotations)
+	}
+	if err != nil {
+		return err
+	}
+
+	for k, v := range annotations {
+		schema[k] = v
+	}
+	if doc != "" {
+		schema["doc"] = doc
+	}
+	proto.types = append(proto.types, schema)
+	return nil
+}
+
+/*
+  import := "import" ("idl" | "protocol" | "schema") path ";"
+*/
+func (p *parser) parseImport(proto *protocol) error {
+	p.next()
+	kind := p.next()
+	if kind.kind != tokenIdent || (kind.text != "idl" && kind.text != "protocol" && kind.text != "schema") {
+		return p.errorf(kind, "Expected \"idl\", \"protocol\" or \"schema\", found %v", kind)
+	}
+	pathToken := p.peek()
+	path, err := p.expectString()
+	if err != nil {
+		return err
+	}
+	if err := p.expectSymbol(";"); err != nil {
+		return err
+	}
+
+	path = filepath.Join(p.dir, path)
+	if !p.markImported(path) {
+		return nil
+	}
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return p.errorf(pathToken, "Failed to import %v: %v", path, err)
+	}
+
+	switch kind.text {
+	case "idl":
+		imported, err := p.importer.parse(path, src)
+		if err != nil {
+			return err
+		}
+		proto.merge(imported.types, imported.namespace, imported.messages)
+	case "protocol":
+		var imported struct {
+			Namespace string                 `json:"namespace"`
+			Types     []interface{}          `json:"types"`
+			Messages  map[string]interface{} `json:"messages"`
+		}
+		if err := json.Unmarshal(src, &imported); err != nil {
+			return p.errorf(pathToken, "Failed to import %v: %v", path, err)
+		}
+		proto.merge(imported.Types, imported.Namespace, imported.Messages)
+	case "schema":
+		var imported interface{}
+		if err := json.Unmarshal(src, &imported); err != nil {
+			return p.errorf(pathToken, "Failed to import %v: %v", path, err)
+		}
+		proto.merge([]interface{}{imported}, "", nil)
+	}
+	return nil
+}
+
+/*
+  record := ("record" | "error") name "{" field* "}"
+*/
+func (p *parser) parseRecord() (map[string]interface{}, error) {
+	kind := p.next().text
+	name, err := p.expectIdent()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectSymbol("{"); err != nil {
+		return nil, err
+	}
+	fields := make([]interface{}, 0)
+	for !p.isSymbol("}") {
+		declared, err := p.parseFieldDeclaration()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, declared...)
+	}
+	p.next()
+	return map[string]interface{}{"type": kind, "name": name, "fields": fields}, nil
+}
+
+/*
+  fieldDeclaration := type variable ("," variable)* ";"
+*/
+func (p *parser) parseFieldDeclaration() ([]interface{}, error) {
+	doc := p.peek().doc
+	t, nullable, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	var fields []interface{}
+	for {
+		field, err := p.parseVariable(doc, t, nullable)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+		if !p.isSymbol(",") {
+			break
+		}
+		p.next()
+	}
+	return fields, p.expectSymbol(";")
+}
+
+/*
+  variable := annotation* name ("=" value)?
+*/
+func (p *parser) parseVariable(doc string, t interface{}, nullable bool) (map[string]interface{}, error) {
+	if d := p.peek().doc; d != "" {
+		doc = d
+	}
+	annotations, err := p.parseAnnotations()
+	if err != nil {
+		return nil, err
+	}
+	name, err := p.expectIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	variable := make(map[string]interface{})
+	for k, v := range annotations {
+		variable[k] = v
+	}
+	variable["name"] = name
+
+	var def interface{}
+	hasDef := false
+	if p.isSymbol("=") {
+		p.next()
+		if def, err = p.parseValue(); err != nil {
+			return nil, err
+		}
+		hasDef = true
+		variable["default"] = def
+	}
+	variable["type"] = nullableType(t, nullable, def, hasDef)
+	if doc != "" {
+		variable["doc"] = doc
+	}
+	return variable, nil
+}
+
+/*
+  The union for a nullable type. Defaults must match the first branch of a union, so null
+  goes last if the default is anything other than null.
+*/
+func nullableType(t interface{}, nullable bool, def interface{}, hasDef bool) interface{} {
+	if !nullable {
+		return t
+	}
+	if hasDef && def != nil {
+		return []interface{}{t, "null"}
+	}
+	return []interface{}{"null", t}
+}
+
+/*
+  enum := "enum" name "{" symbol ("," symbol)* "}" ("=" symbol)? ";"?
+*/
+func (p *parser) parseEnum() (map[string]interface{}, error) {
+	p.next()
+	name, err := p.expectIdent()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectSymbol("{"); err != nil {
+		return nil, err
+	}
+	symbols := make([]interface{}, 0)
+	for !p.isSymbol("}") {
+		symbol, err := p.expectIdent()
+		if err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, symbol)
+		if !p.isSymbol(",") {
+			break
+		}
+		p.next()
+	}
+	if err := p.expectSymbol("}"); err != nil {
+		return nil, err
+	}
+
+	enum := map[string]interface{}{"type": "enum", "name": name, "symbols": symbols}
+	if p.isSymbol("=") {
+		p.next()
+		def, err := p.expectIdent()
+		if err != nil {
+			return nil, err
+		}
+		enum["default"] = def
+		if err := p.expectSymbol(";"); err != nil {
+			return nil, err
+		}
+	} else if p.isSymbol(";") {
+		p.next()
+	}
+	return enum, nil
+}
+
+/*
+  fixed := "fixed" name "(" size ")" ";"
+*/
+func (p *parser) parseFixed() (map[string]interface{}, error) {
+	p.next()
+	name, err := p.expectIdent()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	size, err := p.expectInt()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+	if err := p.expectSymbol(";"); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"type": "fixed", "name": name, "size": size}, nil
+}
+
+/*
+  message := ("void" | type) name "(" (type variable ("," type variable)*)? ")"
+    ("throws" name ("," name)*)? "oneway"? ";"
+*/
+func (p *parser) parseMessage(proto *protocol, doc string, annotations map[string]interface{}) error {
+	var response interface{} = "null"
+	if p.isKeyword("void") {
+		p.next()
+	} else {
+		t, nullable, err := p.parseType()
+		if err != nil {
+			return err
+		}
+		response = nullableType(t, nullable, nil, false)
+	}
+
+	nameToken := p.peek()
+	name, err := p.expectIdent()
+	if err != nil {
+		return err
+	}
+	if _, ok := proto.messages[name]; ok {
+		return p.errorf(nameToken, "Duplicate message %v", name)
+	}
+
+	if err := p.expectSymbol("("); err != nil {
+		return err
+	}
+	request := make([]interface{}, 0)
+	for !p.isSymbol(")") {
+		paramDoc := p.peek().doc
+		t, nullable, err := p.parseType()
+		if err != nil {
+			return err
+		}
+		param, err := p.parseVariable(paramDoc, t, nullable)
+		if err != nil {
+			return err
+		}
+		request = append(request, param)
+		if !p.isSymbol(",") {
+			break
+		}
+		p.next()
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return err
+	}
+
+	message := make(map[string]interface{})
+	for k, v := range annotations {
+		message[k] = v
+	}
+	message["request"] = request
+	message["response"] = response
+	if doc != "" {
+		message["doc"] = doc
+	}
+
+	if p.isKeyword("throws") {
+		p.next()
+		var errors []interface{}
+		for {
+			e, err := p.expectIdent()
+			if err != nil {
+				return err
+			}
+			errors = append(errors, e)
+			if !p.isSymbol(",") {
+				break
+			}
+			p.next()
+		}
+		message["errors"] = errors
+	}
+	if p.isKeyword("oneway") {
+		p.next()
+		message["one-way"] = true
+	}
+	if err := p.expectSymbol(";"); err != nil {
+		return err
+	}
+	proto.messages[name] = message
+	return nil
+}
+
+/*
+  type := annotation* (primitive | logical | "decimal" "(" precision "," scale ")" | "array" "<" type ">"
+    | "map" "<" type ">" | "union" "{" type ("," type)* "}" | name) "?"?
+
+  Returns the type and whether it was marked nullable with the "?" shorthand.
+*/
+func (p *parser) parseType() (interface{}, bool, error) {
+	annotations, err := p.parseAnnotations()
+	if err != nil {
+		return nil, false, err
+	}
+	t := p.next()
+	if t.kind != tokenIdent {
+		return nil, false, p.errorf(t, "Expected a type, found %v", t)
+	}
+
+	var schema interface{}
+	switch t.text {
+	case "array", "map":
+		if err := p.expectSymbol("<"); err != nil {
+			return nil, false, err
+		}
+		inner, nullable, err := p.parseType()
+		if err != nil {
+			return nil, false, err
+		}
+		if err := p.expectSymbol(">"); err != nil {
+			return nil, false, err
+		}
+		key := "items"
+		if t.text == "map" {
+			key = "values"
+		}
+		schema = map[string]interface{}{"type": t.text, key: nullableType(inner, nullable, nil, false)}
+	case "union":
+		if err := p.expectSymbol("{"); err != nil {
+			return nil, false, err
+		}
+		branches := make([]interface{}, 0)
+		for !p.isSymbol("}") {
+			branch, nullable, err := p.parseType()
+			if err != nil {
+				return nil, false, err
+			}
+			if nullable {
+				return nil, false, p.errorf(t, "Union branches can't be nullable")
+			}
+			branches = append(branches, branch)
+			if !p.isSymbol(",") {
+				break
+			}
+			p.next()
+		}
+		if err := p.expectSymbol("}"); err != nil {
+			return nil, false, err
+		}
+		schema = branches
+	case "decimal":
+		if err := p.expectSymbol("("); err != nil {
+			return nil, false, err
+		}
+		precision, err := p.expectInt()
+		if err != nil {
+			return nil, false, err
+		}
+		if err := p.expectSymbol(","); err != nil {
+			return nil, false, err
+		}
+		scale, err := p.expectInt()
+		if err != nil {
+			return nil, false, err
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, false, err
+		}
+		schema = map[string]interface{}{"type": "bytes", "logicalType": "decimal", "precision": precision, "scale": scale}
+	default:
+		if logical, ok := logicalTypes[t.text]; ok {
+			copied := make(map[string]interface{})
+			for k, v := range logical {
+				copied[k] = v
+			}
+			schema = copied
+		} else {
+			schema = t.text
+		}
+	}
+
+	if len(annotations) > 0 {
+		if name, ok := schema.(string); ok && primitiveTypes[name] {
+			schema = map[string]interface{}{"type": name}
+		}
+		annotated, ok := schema.(map[string]interface{})
+		if !ok {
+			return nil, false, p.errorf(t, "Annotations aren't allowed on type %v", t.text)
+		}
+		for k, v := range annotations {
+			annotated[k] = v
+		}
+	}
+
+	nullable := false
+	if p.isSymbol("?") {
+		p.next()
+		nullable = true
+	}
+	return schema, nullable, nil
+}
+
+/*
+  annotation := "@" name "(" value ")"
+*/
+func (p *parser) parseAnnotations() (map[string]interface{}, error) {
+	annotations := make(map[string]interface{})
+	for p.isSymbol("@") {
+		p.next()
+		name, err := p.expectIdent()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		annotations[name] = value
+	}
+	return annotations, nil
+}
+
+/*
+  Parse a JSON value, used for defaults and annotation values.
+*/
+func (p *parser) parseValue() (interface{}, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenString:
+		return p.expectString()
+	case t.kind == tokenNumber:
+		p.next()
+		var n interface{}
+		if err := json.Unmarshal([]byte(t.text), &n); err != nil {
+			return nil, p.errorf(t, "Invalid number %v", t.text)
+		}
+		return n, nil
+	case t.kind == tokenIdent && t.text == "true":
+		p.next()
+		return true, nil
+	case t.kind == tokenIdent && t.text == "false":
+		p.next()
+		return false, nil
+	case t.kind == tokenIdent && t.text == "null":
+		p.next()
+		return nil, nil
+	case p.isSymbol("["):
+		p.next()
+		values := make([]interface{}, 0)
+		for !p.isSymbol("]") {
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+			if !p.isSymbol(",") {
+				break
+			}
+			p.next()
+		}
+		return values, p.expectSymbol("]")
+	case p.isSymbol("{"):
+		p.next()
+		object := make(map[string]interface{})
+		for !p.isSymbol("}") {
+			k, err := p.expectString()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectSymbol(":"); err != nil {
+				return nil, err
+			}
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			object[k] = v
+			if !p.isSymbol(",") {
+				break
+			}
+			p.next()
+		}
+		return object, p.expectSymbol("}")
+	}
+	return nil, p.errorf(t, "Expected a value, found %v", t)
+}
+
+/*
+  Remove the namespace annotation from the annotations and return its value.
+*/
+func namespaceAnnotation(annotations map[string]interface{}) (string, error) {
+	value, ok := annotations["namespace"]
+	if !ok {
+		return "", nil
+	}
+	delete(annotations, "namespace")
+	namespace, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("@namespace must be a string")
+	}
+	return namespace, nil
+}
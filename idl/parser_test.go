@@ -0,0 +1,216 @@
+package idl
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parse(t *testing.T, src string) map[string]interface{} {
+	protocol, err := Parse("test.avdl", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var definition map[string]interface{}
+	if err := json.Unmarshal(protocol, &definition); err != nil {
+		t.Fatal(err)
+	}
+	return definition
+}
+
+func decode(t *testing.T, s string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestParseRecord(t *testing.T) {
+	definition := parse(t, `
+		/** A test protocol */
+		@namespace("com.example")
+		protocol Test {
+			/**
+			 * A person
+			 */
+			@aliases(["com.example.Human"])
+			record Person {
+				/** Their name */
+				string name;
+				int age = 0, @order("ignore") height;
+				array<string?> nicknames = [];
+				@logicalType("timestamp-millis") long born;
+			}
+		}`)
+
+	assert.Equal(t, "Test", definition["protocol"])
+	assert.Equal(t, "com.example", definition["namespace"])
+	assert.Equal(t, "A test protocol", definition["doc"])
+	assert.Equal(t, decode(t, `[{
+		"type": "record", "name": "Person", "doc": "A person", "aliases": ["com.example.Human"],
+		"fields": [
+			{"name": "name", "type": "string", "doc": "Their name"},
+			{"name": "age", "type": "int", "default": 0},
+			{"name": "height", "type": "int", "order": "ignore"},
+			{"name": "nicknames", "type": {"type": "array", "items": ["null", "string"]}, "default": []},
+			{"name": "born", "type": {"type": "long", "logicalType": "timestamp-millis"}}
+		]
+	}]`), definition["types"])
+}
+
+func TestParseNullableShorthand(t *testing.T) {
+	definition := parse(t, `
+		protocol Test {
+			record Nullable {
+				string? none;
+				string? nullDefault = null;
+				string? stringDefault = "x";
+			}
+		}`)
+
+	fields := definition["types"].([]interface{})[0].(map[string]interface{})["fields"]
+	assert.Equal(t, decode(t, `[
+		{"name": "none", "type": ["null", "string"]},
+		{"name": "nullDefault", "type": ["null", "string"], "default": null},
+		{"name": "stringDefault", "type": ["string", "null"], "default": "x"}
+	]`), fields)
+}
+
+func TestParseNamedTypes(t *testing.T) {
+	definition := parse(t, `
+		protocol Test {
+			enum Suit { SPADES, HEARTS, DIAMONDS, CLUBS } = SPADES;
+			fixed Hash(16);
+			@namespace("com.example.other") error Failure { string message; }
+			record Logical {
+				date day;
+				time_ms time;
+				timestamp_ms timestamp;
+				local_timestamp_ms local;
+				uuid id;
+				decimal(9, 2) amount;
+				union { null, Hash } hash = null;
+				map<Suit> suits;
+			}
+		}`)
+
+	assert.Equal(t, decode(t, `[
+		{"type": "enum", "name": "Suit", "symbols": ["SPADES", "HEARTS", "DIAMONDS", "CLUBS"], "default": "SPADES"},
+		{"type": "fixed", "name": "Hash", "size": 16},
+		{"type": "error", "name": "Failure", "namespace": "com.example.other", "fields": [{"name": "message", "type": "string"}]},
+		{"type": "record", "name": "Logical", "fields": [
+			{"name": "day", "type": {"type": "int", "logicalType": "date"}},
+			{"name": "time", "type": {"type": "int", "logicalType": "time-millis"}},
+			{"name": "timestamp", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+			{"name": "local", "type": {"type": "long", "logicalType": "local-timestamp-millis"}},
+			{"name": "id", "type": {"type": "string", "logicalType": "uuid"}},
+			{"name": "amount", "type": {"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2}},
+			{"name": "hash", "type": ["null", "Hash"], "default": null},
+			{"name": "suits", "type": {"type": "map", "values": "Suit"}}
+		]}
+	]`), definition["types"])
+}
+
+func TestParseMessages(t *testing.T) {
+	definition := parse(t, `
+		protocol Test {
+			error Failure { string message; }
+			/** Say hello */
+			string hello(string greeting, int times = 1) throws Failure;
+			string? maybe();
+			void reset(string `+"`record`"+`);
+			void ping() oneway;
+		}`)
+
+	assert.Equal(t, decode(t, `{
+		"hello": {
+			"doc": "Say hello",
+			"request": [{"name": "greeting", "type": "string"}, {"name": "times", "type": "int", "default": 1}],
+			"response": "string",
+			"errors": ["Failure"]
+		},
+		"maybe": {"request": [], "response": ["null", "string"]},
+		"reset": {"request": [{"name": "record", "type": "string"}], "response": "null"},
+		"ping": {"request": [], "response": "null", "one-way": true}
+	}`), definition["messages"])
+}
+
+func TestParseImports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"common.avdl": `@namespace("com.example.common") protocol Common { record Id { string value; } }`,
+		"hash.avsc":   `{"type": "fixed", "name": "Hash", "size": 16}`,
+		"echo.avpr":   `{"protocol": "Echo", "namespace": "com.example.echo", "types": [{"type": "record", "name": "Ping", "fields": []}], "messages": {"echo": {"request": [{"name": "ping", "type": "Ping"}], "response": "Ping"}}}`,
+		"main.avdl": `@namespace("com.example") protocol Main {
+			import idl "common.avdl";
+			import schema "hash.avsc";
+			import protocol "echo.avpr";
+			import idl "common.avdl";
+		}`,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	protocol, err := ParseFile(filepath.Join(dir, "main.avdl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var definition map[string]interface{}
+	if err := json.Unmarshal(protocol, &definition); err != nil {
+		t.Fatal(err)
+	}
+
+	// Imported types keep the namespace they were declared in, and files are only imported once
+	assert.Equal(t, decode(t, `[
+		{"type": "record", "name": "Id", "namespace": "com.example.common", "fields": [{"name": "value", "type": "string"}]},
+		{"type": "fixed", "name": "Hash", "namespace": "", "size": 16},
+		{"type": "record", "name": "Ping", "namespace": "com.example.echo", "fields": []}
+	]`), definition["types"])
+	assert.Equal(t, decode(t, `{
+		"echo": {"request": [{"name": "ping", "type": "com.example.echo.Ping"}], "response": "com.example.echo.Ping"}
+	}`), definition["messages"])
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]*ParseError{
+		"protocol Test {\n  record R { string }\n}":               {File: "test.avdl", Line: 2, Column: 21, Message: `Expected an identifier, found "}"`},
+		"protocol Test {\n  string? hello()\n}":                   {File: "test.avdl", Line: 3, Column: 1, Message: `Expected ";", found "}"`},
+		"protocol Test {\n  /* unterminated\n}":                   {File: "test.avdl", Line: 2, Column: 3, Message: "Unterminated comment"},
+		"protocol Test {\n  record R { @foo(\"x\") Other o; }\n}": {File: "test.avdl", Line: 2, Column: 24, Message: "Annotations aren't allowed on type Other"},
+		"record R {}": {File: "test.avdl", Line: 1, Column: 1, Message: `Expected "protocol", found "record"`},
+	}
+	for src, expected := range cases {
+		_, err := Parse("test.avdl", []byte(src))
+		assert.Equal(t, expected, err, src)
+	}
+}
+
+func TestLexUnterminatedStrings(t *testing.T) {
+	cases := map[string]*ParseError{
+		`"abc`:        {File: "test.avdl", Line: 1, Column: 1, Message: "Unterminated string"},
+		"\"abc\n\"":   {File: "test.avdl", Line: 1, Column: 1, Message: "Unterminated string"},
+		`"abc\`:       {File: "test.avdl", Line: 1, Column: 1, Message: "Unterminated string"},
+		"\"abc\\\n\"": {File: "test.avdl", Line: 1, Column: 1, Message: "Unterminated string"},
+	}
+	for src, expected := range cases {
+		_, err := newLexer("test.avdl", []byte(src)).tokens()
+		assert.Equal(t, expected, err, src)
+	}
+
+	// A backslash at the end of the file stops the parser with the same error
+	_, err := Parse("test.avdl", []byte(`protocol P { "abc\`))
+	assert.Equal(t, &ParseError{File: "test.avdl", Line: 1, Column: 14, Message: "Unterminated string"}, err)
+}
@@ -0,0 +1,122 @@
+package idl
+
+import (
+	"strings"
+)
+
+/*
+  A protocol as it's built up by the parser, before it's converted to its JSON definition.
+*/
+type protocol struct {
+	name      string
+	namespace string
+	doc       string
+	props     map[string]interface{}
+	types     []interface{}
+	messages  map[string]interface{}
+}
+
+func newProtocol() *protocol {
+	return &protocol{
+		props:    make(map[string]interface{}),
+		messages: make(map[string]interface{}),
+	}
+}
+
+func (p *protocol) definition() map[string]interface{} {
+	definition := make(map[string]interface{})
+	for k, v := range p.props {
+		definition[k] = v
+	}
+	definition["protocol"] = p.name
+	if p.namespace != "" {
+		definition["namespace"] = p.namespace
+	}
+	if p.doc != "" {
+		definition["doc"] = p.doc
+	}
+	definition["types"] = p.types
+	definition["messages"] = p.messages
+	return definition
+}
+
+/*
+  Add the types and messages of an imported protocol. Named types without an explicit
+  namespace are qualified with the namespace of the protocol they were declared in.
+*/
+func (p *protocol) merge(types []interface{}, namespace string, messages map[string]interface{}) {
+	for _, t := range types {
+		p.types = append(p.types, qualify(t, namespace))
+	}
+	for name, m := range messages {
+		p.messages[name] = qualifyMessage(m, namespace)
+	}
+}
+
+/*
+  Qualify the type names used by an imported message, which would otherwise be resolved
+  in the namespace of the importing protocol.
+*/
+func qualifyMessage(m interface{}, namespace string) interface{} {
+	message, ok := m.(map[string]interface{})
+	if !ok || namespace == "" {
+		return m
+	}
+	if params, ok := message["request"].([]interface{}); ok {
+		for _, param := range params {
+			if field, ok := param.(map[string]interface{}); ok {
+				field["type"] = qualifyReferences(field["type"], namespace)
+			}
+		}
+	}
+	if response, ok := message["response"]; ok {
+		message["response"] = qualifyReferences(response, namespace)
+	}
+	if errors, ok := message["errors"]; ok {
+		message["errors"] = qualifyReferences(errors, namespace)
+	}
+	return message
+}
+
+func qualifyReferences(t interface{}, namespace string) interface{} {
+	switch schema := t.(type) {
+	case string:
+		if primitiveTypes[schema] || strings.Contains(schema, ".") {
+			return schema
+		}
+		return namespace + "." + schema
+	case []interface{}:
+		for i, branch := range schema {
+			schema[i] = qualifyReferences(branch, namespace)
+		}
+	case map[string]interface{}:
+		switch schema["type"] {
+		case "array":
+			schema["items"] = qualifyReferences(schema["items"], namespace)
+		case "map":
+			schema["values"] = qualifyReferences(schema["values"], namespace)
+		case "record", "error", "enum", "fixed":
+			return qualify(schema, namespace)
+		}
+	}
+	return t
+}
+
+func qualify(t interface{}, namespace string) interface{} {
+	schema, ok := t.(map[string]interface{})
+	if !ok {
+		return t
+	}
+	switch schema["type"] {
+	case "record", "error", "enum", "fixed":
+	default:
+		return t
+	}
+	if _, ok := schema["namespace"]; ok {
+		return t
+	}
+	if name, ok := schema["name"].(string); ok && !strings.Contains(name, ".") {
+		schema["namespace"] = namespace
+	}
+	return schema
+}
@@ -9,14 +9,21 @@ import (
 	"strings"
 
 	"github.com/alanctgardner/gogen-avro/generator"
+	"github.com/alanctgardner/gogen-avro/idl"
 	"github.com/alanctgardner/gogen-avro/types"
 )
 
 func main() {
 	packageName := flag.String("package", "avro", "Name of generated package")
+	unionMode := flag.String("union-mode", "struct", "Representation of unions, either struct or interface")
+	optionalPointers := flag.Bool("optional-pointers", false, "Generate unions of null and one other type as a pointer, with nil for null")
+	validateOnSerialize := flag.Bool("validate-on-serialize", false, "Validate records in the generated Serialize methods before writing them")
+	sortedMaps := flag.Bool("sorted-maps", false, "Serialize map entries in sorted key order, so equal records always have the same encoding")
+	writerSchema := flag.String("writer-schema", "", "Schema file the data was written with, to generate readers which project it onto the records in the schema files")
+	namespacePackages := flag.String("namespace-packages", "", "Import path of the target directory, to generate a package for each namespace in its subdirectories")
 	flag.Parse()
 	if flag.NArg() < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: gogen-avro [--package=<package name>] <target directory> <schema files>\n")
+		fmt.Fprintf(os.Stderr, "Usage: gogen-avro [--package=<package name>] [--union-mode=struct|interface] [--optional-pointers] [--validate-on-serialize] [--sorted-maps] [--writer-schema=<schema file>] [--namespace-packages=<import path>] <target directory> <schema or protocol files>\n")
 		os.Exit(1)
 	}
 	targetDir := flag.Arg(0)
@@ -25,6 +32,19 @@ func main() {
 	var err error
 	pkg := generator.NewPackage(*packageName)
 	namespace := types.NewNamespace()
+	namespace.UnionMode, err = types.ParseUnionMode(*unionMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	namespace.OptionalPointers = *optionalPointers
+	namespace.ValidateOnSerialize = *validateOnSerialize
+	namespace.SortedMaps = *sortedMaps
+	namespace.NamespacePackages = *namespacePackages
+	if *namespacePackages != "" && *writerSchema != "" {
+		fmt.Fprintf(os.Stderr, "The --writer-schema and --namespace-packages options can't be used together\n")
+		os.Exit(1)
+	}
 
 	var files []string
 	for _, input := range inputs {
@@ -49,7 +69,18 @@ func main() {
 			os.Exit(2)
 		}
 
-		_, err = namespace.FieldDefinitionForSchema(schema)
+		switch filepath.Ext(fileName) {
+		case ".avdl":
+			var protocol []byte
+			protocol, err = idl.Parse(fileName, schema)
+			if err == nil {
+				_, err = namespace.ProtocolDefinitionForSchema(protocol)
+			}
+		case ".avpr":
+			_, err = namespace.ProtocolDefinitionForSchema(schema)
+		default:
+			_, err = namespace.FieldDefinitionForSchema(schema)
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error decoding schema for file %q - %v\n", fileName, err)
 			os.Exit(3)
@@ -63,11 +94,17 @@ func main() {
 		os.Exit(4)
 	}
 
-	// Add header comment to all generated files.
-	for _, f := range pkg.Files() {
-		pkg.AddHeader(f, codegenCommentMinimal())
+	if *writerSchema != "" {
+		err = addProjectionsToPackage(namespace, *writerSchema, pkg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating projections from writer schema %q - %v\n", *writerSchema, err)
+			os.Exit(4)
+		}
 	}
 
+	// Add header comment to all generated files.
+	addHeaders(pkg)
+
 	if err := os.MkdirAll(targetDir, os.ModeDir | 0755); err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating output directory %s: %s", targetDir, err)
 		os.Exit(4)
@@ -81,6 +118,13 @@ func main() {
 }
 
 func addFieldsToPackage(namespace *types.Namespace, pkg *generator.Package) error {
+	if err := namespace.CheckNames(); err != nil {
+		return err
+	}
+	if namespace.NamespacePackages != "" {
+		return namespace.AddNamespacePackages(pkg)
+	}
+
 	for _, schema := range namespace.Schemas {
 		err := schema.Root.ResolveReferences(namespace)
 		if err != nil {
@@ -91,9 +135,41 @@ func addFieldsToPackage(namespace *types.Namespace, pkg *generator.Package) erro
 		schema.Root.AddSerializer(pkg)
 		schema.Root.AddDeserializer(pkg)
 	}
+
+	for _, protocol := range namespace.Protocols {
+		err := protocol.ResolveReferences(namespace)
+		if err != nil {
+			return err
+		}
+
+		protocol.AddStruct(pkg)
+		protocol.AddSerializer(pkg)
+		protocol.AddDeserializer(pkg)
+	}
 	return nil
 }
 
+func addHeaders(pkg *generator.Package) {
+	for _, f := range pkg.Files() {
+		pkg.AddHeader(f, codegenCommentMinimal())
+	}
+	for _, dir := range pkg.SubPackages() {
+		addHeaders(pkg.SubPackage(dir, ""))
+	}
+}
+
+func addProjectionsToPackage(namespace *types.Namespace, writerSchema string, pkg *generator.Package) error {
+	schema, err := ioutil.ReadFile(writerSchema)
+	if err != nil {
+		return err
+	}
+	writer := types.NewNamespace()
+	if _, err := writer.FieldDefinitionForSchema(schema); err != nil {
+		return err
+	}
+	return namespace.AddProjections(writer, pkg)
+}
+
 // codegenComment generates a comment informing readers they are looking at
 // generated code and lists the source avro files used to generate the code
 //
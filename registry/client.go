@@ -0,0 +1,243 @@
+// Package registry is a client for the REST API of the Confluent Schema Registry,
+// and implements the confluent.SchemaResolver interface on top of it.
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/alanctgardner/gogen-avro/confluent"
+	"github.com/alanctgardner/gogen-avro/container"
+)
+
+// The content type for requests and responses of version 1 of the REST API
+const ContentType = "application/vnd.schemaregistry.v1+json"
+
+// The error code returned by the registry when no schema has the requested ID
+const errorCodeSchemaNotFound = 40403
+
+/*
+  SchemaMetadata describes a version of a schema registered under a subject.
+*/
+type SchemaMetadata struct {
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+	ID      int32  `json:"id"`
+	Schema  string `json:"schema"`
+}
+
+/*
+  Client talks to a schema registry over HTTP. Lookups of schemas by ID and of IDs by schema are
+  cached, since the registry never changes the schema for an ID once it's assigned.
+*/
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+
+	lock    sync.RWMutex
+	schemas map[int32]string
+	ids     map[subjectSchema]int32
+}
+
+type subjectSchema struct {
+	subject string
+	schema  string
+}
+
+/*
+  Create a new Client for the registry at the given base URL, using http.DefaultClient.
+*/
+func NewClient(baseURL string) *Client {
+	return NewClientWithHTTPClient(baseURL, http.DefaultClient)
+}
+
+/*
+  Create a new Client for the registry at the given base URL, sending requests with the given http.Client.
+*/
+func NewClientWithHTTPClient(baseURL string, httpClient *http.Client) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+		schemas:    make(map[int32]string),
+		ids:        make(map[subjectSchema]int32),
+	}
+}
+
+/*
+  Register the schema under the subject and return its ID. If the schema is already registered
+  under the subject, the registry returns the existing ID.
+*/
+func (c *Client) Register(subject, schema string) (int32, error) {
+	key := subjectSchema{subject, schema}
+	c.lock.RLock()
+	id, ok := c.ids[key]
+	c.lock.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	var response struct {
+		ID int32 `json:"id"`
+	}
+	err := c.do("POST", "/subjects/"+url.PathEscape(subject)+"/versions", schemaRequest(schema), &response)
+	if err != nil {
+		return 0, err
+	}
+	c.cache(response.ID, schema)
+	c.lock.Lock()
+	c.ids[key] = response.ID
+	c.lock.Unlock()
+	return response.ID, nil
+}
+
+/*
+  Register the schema of a generated record under the subject named by its "subject" attribute.
+*/
+func (c *Client) RegisterRecord(record container.AvroRecord) (int32, error) {
+	schema := record.Schema()
+	subject, err := Subject(schema)
+	if err != nil {
+		return 0, err
+	}
+	return c.Register(subject, schema)
+}
+
+/*
+  Return the schema with the given ID. Returns a *confluent.UnknownSchemaIDError if the registry has no such schema.
+*/
+func (c *Client) GetSchema(id int32) (string, error) {
+	c.lock.RLock()
+	schema, ok := c.schemas[id]
+	c.lock.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	var response struct {
+		Schema string `json:"schema"`
+	}
+	err := c.do("GET", fmt.Sprintf("/schemas/ids/%d", id), nil, &response)
+	if regErr, ok := err.(*Error); ok && regErr.ErrorCode == errorCodeSchemaNotFound {
+		return "", &confluent.UnknownSchemaIDError{ID: id}
+	}
+	if err != nil {
+		return "", err
+	}
+	c.cache(id, response.Schema)
+	return response.Schema, nil
+}
+
+/*
+  Return the latest version of the schema registered under the subject.
+*/
+func (c *Client) GetLatestSchema(subject string) (*SchemaMetadata, error) {
+	var metadata SchemaMetadata
+	err := c.do("GET", "/subjects/"+url.PathEscape(subject)+"/versions/latest", nil, &metadata)
+	if err != nil {
+		return nil, err
+	}
+	c.cache(metadata.ID, metadata.Schema)
+	return &metadata, nil
+}
+
+/*
+  Check whether the schema is compatible with the latest version registered under the subject,
+  according to the compatibility level configured in the registry.
+*/
+func (c *Client) CheckCompatibility(subject, schema string) (bool, error) {
+	var response struct {
+		IsCompatible bool `json:"is_compatible"`
+	}
+	err := c.do("POST", "/compatibility/subjects/"+url.PathEscape(subject)+"/versions/latest", schemaRequest(schema), &response)
+	if err != nil {
+		return false, err
+	}
+	return response.IsCompatible, nil
+}
+
+/*
+  SchemaID implements confluent.SchemaResolver by registering the schema under the subject named by its "subject" attribute.
+*/
+func (c *Client) SchemaID(schema string) (int32, error) {
+	subject, err := Subject(schema)
+	if err != nil {
+		return 0, err
+	}
+	return c.Register(subject, schema)
+}
+
+/*
+  Schema implements confluent.SchemaResolver by looking up the schema by ID.
+*/
+func (c *Client) Schema(id int32) (string, error) {
+	return c.GetSchema(id)
+}
+
+/*
+  Return the value of the "subject" attribute at the top level of the schema.
+*/
+func Subject(schema string) (string, error) {
+	var schemaMap map[string]interface{}
+	if err := json.Unmarshal([]byte(schema), &schemaMap); err != nil {
+		return "", err
+	}
+	subject, ok := schemaMap["subject"].(string)
+	if !ok || subject == "" {
+		return "", fmt.Errorf("Schema has no subject attribute")
+	}
+	return subject, nil
+}
+
+func (c *Client) cache(id int32, schema string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.schemas[id] = schema
+}
+
+func schemaRequest(schema string) interface{} {
+	return map[string]string{"schema": schema}
+}
+
+/*
+  Send a request to the registry and decode the JSON response into the given value.
+*/
+func (c *Client) do(method, path string, request, response interface{}) error {
+	var body io.Reader
+	if request != nil {
+		encoded, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", ContentType)
+	if body != nil {
+		req.Header.Set("Content-Type", ContentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		regErr := &Error{StatusCode: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(regErr); err != nil {
+			regErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return regErr
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
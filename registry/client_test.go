@@ -0,0 +1,229 @@
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/confluent"
+	"github.com/stretchr/testify/assert"
+)
+
+const userSchema = `{"type":"record","name":"User","namespace":"com.example","subject":"com.example.user","fields":[{"name":"name","type":"string"}]}`
+
+const userSchemaV2 = `{"type":"record","name":"User","namespace":"com.example","subject":"com.example.user","fields":[{"name":"name","type":"string"},{"name":"age","type":"int","default":0}]}`
+
+/* A minimal stand-in for the schema registry, which records the requests it receives */
+type fakeRegistry struct {
+	lock     sync.Mutex
+	schemas  []string
+	subjects map[string][]int
+	requests []string
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{subjects: make(map[string][]int)}
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.EscapedPath())
+	w.Header().Set("Content-Type", ContentType)
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == "POST" && len(parts) == 3 && parts[0] == "subjects" && parts[2] == "versions":
+		schema := f.decodeSchema(r)
+		id := f.idFor(schema)
+		if id < 0 {
+			f.schemas = append(f.schemas, schema)
+			id = len(f.schemas)
+		}
+		f.subjects[parts[1]] = append(f.subjects[parts[1]], id)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": id})
+	case r.Method == "GET" && len(parts) == 3 && parts[0] == "schemas" && parts[1] == "ids":
+		id, _ := strconv.Atoi(parts[2])
+		if id < 1 || id > len(f.schemas) {
+			f.writeError(w, http.StatusNotFound, 40403, "Schema not found")
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"schema": f.schemas[id-1]})
+	case r.Method == "GET" && len(parts) == 4 && parts[0] == "subjects" && parts[3] == "latest":
+		versions := f.subjects[parts[1]]
+		if len(versions) == 0 {
+			f.writeError(w, http.StatusNotFound, 40401, "Subject not found")
+			return
+		}
+		id := versions[len(versions)-1]
+		json.NewEncoder(w).Encode(SchemaMetadata{Subject: parts[1], Version: len(versions), ID: int32(id), Schema: f.schemas[id-1]})
+	case r.Method == "POST" && len(parts) == 5 && parts[0] == "compatibility":
+		schema := f.decodeSchema(r)
+		// Treat any schema with the same name as compatible
+		var latest, candidate map[string]interface{}
+		versions := f.subjects[parts[2]]
+		json.Unmarshal([]byte(f.schemas[versions[len(versions)-1]-1]), &latest)
+		json.Unmarshal([]byte(schema), &candidate)
+		json.NewEncoder(w).Encode(map[string]interface{}{"is_compatible": latest["name"] == candidate["name"]})
+	default:
+		f.writeError(w, http.StatusNotFound, 404, "Not found")
+	}
+}
+
+func (f *fakeRegistry) decodeSchema(r *http.Request) string {
+	var request map[string]string
+	json.NewDecoder(r.Body).Decode(&request)
+	return request["schema"]
+}
+
+func (f *fakeRegistry) idFor(schema string) int {
+	for i, s := range f.schemas {
+		if s == schema {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func (f *fakeRegistry) writeError(w http.ResponseWriter, status, code int, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error_code": code, "message": message})
+}
+
+func (f *fakeRegistry) requestCount() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return len(f.requests)
+}
+
+func (f *fakeRegistry) requestLog() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+func TestRegisterUsesSubject(t *testing.T) {
+	registry := newFakeRegistry()
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	subject, err := Subject(userSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := client.Register(subject, userSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int32(1), id)
+	assert.Equal(t, []string{"POST /subjects/com.example.user/versions"}, registry.requestLog())
+
+	// Registering again is served from the cache
+	id, err = client.Register(subject, userSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int32(1), id)
+	assert.Equal(t, 1, registry.requestCount())
+}
+
+func TestGetSchemaIsCached(t *testing.T) {
+	registry := newFakeRegistry()
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	if _, err := NewClient(server.URL).Register("com.example.user", userSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient(server.URL)
+	for i := 0; i < 3; i++ {
+		schema, err := client.GetSchema(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, userSchema, schema)
+	}
+	assert.Equal(t, 2, registry.requestCount())
+}
+
+func TestGetUnknownSchema(t *testing.T) {
+	server := httptest.NewServer(newFakeRegistry())
+	defer server.Close()
+
+	_, err := NewClient(server.URL).GetSchema(42)
+	assert.Equal(t, &confluent.UnknownSchemaIDError{ID: 42}, err)
+}
+
+func TestGetLatestSchema(t *testing.T) {
+	server := httptest.NewServer(newFakeRegistry())
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	if _, err := client.Register("com.example.user", userSchema); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Register("com.example.user", userSchemaV2); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := client.GetLatestSchema("com.example.user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &SchemaMetadata{Subject: "com.example.user", Version: 2, ID: 2, Schema: userSchemaV2}, metadata)
+
+	_, err = client.GetLatestSchema("com.example.missing")
+	regErr, ok := err.(*Error)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, regErr.StatusCode)
+	assert.Equal(t, 40401, regErr.ErrorCode)
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	server := httptest.NewServer(newFakeRegistry())
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	if _, err := client.Register("com.example.user", userSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	compatible, err := client.CheckCompatibility("com.example.user", userSchemaV2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, compatible)
+
+	compatible, err = client.CheckCompatibility("com.example.user", `{"type":"record","name":"Other","fields":[]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, compatible)
+}
+
+func TestClientAsSchemaResolver(t *testing.T) {
+	server := httptest.NewServer(newFakeRegistry())
+	defer server.Close()
+
+	var resolver confluent.SchemaResolver = NewClient(server.URL)
+	id, err := resolver.SchemaID(userSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := resolver.Schema(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, userSchema, schema)
+}
+
+func TestSubjectRequired(t *testing.T) {
+	_, err := Subject(`{"type":"record","name":"User","fields":[]}`)
+	assert.NotNil(t, err)
+}
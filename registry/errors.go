@@ -0,0 +1,18 @@
+package registry
+
+import (
+	"fmt"
+)
+
+/*
+  Error is returned when the registry responds with a non-2xx status code.
+*/
+type Error struct {
+	StatusCode int    `json:"-"`
+	ErrorCode  int    `json:"error_code"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("Schema registry returned status %v (error code %v): %v", e.StatusCode, e.ErrorCode, e.Message)
+}
@@ -0,0 +1,3 @@
+package avro
+
+//go:generate $GOPATH/bin/gogen-avro --package avro . handshake_request.avsc handshake_response.avsc
@@ -0,0 +1,27 @@
+/*
+ * CODE GENERATED AUTOMATICALLY WITH github.com/alanctgardner/gogen-avro
+ * THIS FILE SHOULD NOT BE EDITED BY HAND
+ */
+
+package avro
+
+type HandshakeMatch int32
+
+const (
+	BOTH   HandshakeMatch = 0
+	CLIENT HandshakeMatch = 1
+	NONE   HandshakeMatch = 2
+)
+
+func (e HandshakeMatch) String() string {
+	switch e {
+	case BOTH:
+		return "BOTH"
+	case CLIENT:
+		return "CLIENT"
+	case NONE:
+		return "NONE"
+
+	}
+	return "Unknown"
+}
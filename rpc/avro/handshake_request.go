@@ -0,0 +1,182 @@
+/*
+ * CODE GENERATED AUTOMATICALLY WITH github.com/alanctgardner/gogen-avro
+ * THIS FILE SHOULD NOT BE EDITED BY HAND
+ */
+
+package avro
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/satori/go.uuid"
+	"github.com/securityscorecard/go-stats"
+	"io"
+)
+
+type HandshakeRequest struct {
+	ClientHash     MD5
+	ClientProtocol UnionNullString
+	ServerHash     MD5
+	Meta           UnionNullMapBytes
+}
+
+func DeserializeHandshakeRequest(r io.Reader) (*HandshakeRequest, error) {
+	d := decoderFor(r)
+	start := d.Offset()
+	t, err := readHandshakeRequest(d)
+	if err != nil {
+		return nil, decodeErrorAt(err, "HandshakeRequest", "org.apache.avro.ipc.HandshakeRequest", start, d.Offset())
+	}
+	return t, nil
+}
+
+// DeserializeHandshakeRequestInto decodes a HandshakeRequest from r into dst, reusing its nested records and the storage of its slices and maps
+// instead of allocating new ones. Values which dst held before are overwritten.
+func DeserializeHandshakeRequestInto(r io.Reader, dst *HandshakeRequest) error {
+	d := decoderFor(r)
+	start := d.Offset()
+	if err := readHandshakeRequestInto(d, dst); err != nil {
+		return decodeErrorAt(err, "HandshakeRequest", "org.apache.avro.ipc.HandshakeRequest", start, d.Offset())
+	}
+	return nil
+}
+
+// UnmarshalHandshakeRequest decodes a HandshakeRequest from the start of data, and returns it with the number of bytes it took up.
+// The values of bytes fields are copied out of data.
+func UnmarshalHandshakeRequest(data []byte) (*HandshakeRequest, int, error) {
+	d := newSliceDecoder(data, false)
+	t, err := readHandshakeRequest(d)
+	if err != nil {
+		return nil, 0, decodeErrorAt(err, "HandshakeRequest", "org.apache.avro.ipc.HandshakeRequest", 0, d.Offset())
+	}
+	return t, int(d.Offset()), nil
+}
+
+// UnmarshalHandshakeRequestNoCopy is like UnmarshalHandshakeRequest, but the values of bytes fields are slices of data rather than copies.
+// It's unsafe to modify or reuse data while the record is in use.
+func UnmarshalHandshakeRequestNoCopy(data []byte) (*HandshakeRequest, int, error) {
+	d := newSliceDecoder(data, true)
+	t, err := readHandshakeRequest(d)
+	if err != nil {
+		return nil, 0, decodeErrorAt(err, "HandshakeRequest", "org.apache.avro.ipc.HandshakeRequest", 0, d.Offset())
+	}
+	return t, int(d.Offset()), nil
+}
+
+// CanonicalSchema returns the Parsing Canonical Form of the record's schema, which the fingerprints are computed from
+func (r *HandshakeRequest) CanonicalSchema() string {
+	return "{\"name\":\"org.apache.avro.ipc.HandshakeRequest\",\"type\":\"record\",\"fields\":[{\"name\":\"clientHash\",\"type\":{\"name\":\"org.apache.avro.ipc.MD5\",\"type\":\"fixed\",\"size\":16}},{\"name\":\"clientProtocol\",\"type\":[\"null\",\"string\"]},{\"name\":\"serverHash\",\"type\":\"org.apache.avro.ipc.MD5\"},{\"name\":\"meta\",\"type\":[\"null\",{\"type\":\"map\",\"values\":\"bytes\"}]}]}"
+}
+
+// Clone returns a deep copy of the record, which shares no slices, maps or nested records with it
+func (r *HandshakeRequest) Clone() *HandshakeRequest {
+	return cloneHandshakeRequest(r)
+}
+
+// Diff returns the values which differ between the record and other, with the path of each, such as
+// lines[2].status or notes["gift"]. Arrays are compared by index, and maps by key in sorted order.
+func (r *HandshakeRequest) Diff(other *HandshakeRequest) []FieldDiff {
+	return diffHandshakeRequest(r, other, "", nil)
+}
+
+// EncodedSize returns the length of the binary encoding of the record, without encoding it
+func (r *HandshakeRequest) EncodedSize() int {
+	return sizeHandshakeRequest(r)
+}
+
+// Equals returns whether other holds the same values as the record. Nil and empty slices and maps are equal,
+// since they have the same encoding, and so are NaNs.
+func (r *HandshakeRequest) Equals(other *HandshakeRequest) bool {
+	return equalHandshakeRequest(r, other)
+}
+
+func (r *HandshakeRequest) GenerateID() string {
+	s := fmt.Sprint()
+	return uuid.NewV5(uuid.NamespaceOID, s).String()
+}
+
+// MarshalAppend appends the binary encoding of the record to dst and returns the extended slice.
+// Use EncodedSize to allocate dst with enough space for the record.
+func (r *HandshakeRequest) MarshalAppend(dst []byte) ([]byte, error) {
+	w := sliceWriter(dst)
+	if err := writeHandshakeRequest(r, &w); err != nil {
+		return dst, err
+	}
+	return w, nil
+}
+
+// MarshalAvroJSON encodes the record with the JSON encoding defined by the Avro spec
+func (r *HandshakeRequest) MarshalAvroJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSONHandshakeRequest(r, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalAvroJSON decodes the record from the JSON encoding defined by the Avro spec
+func (r *HandshakeRequest) UnmarshalAvroJSON(data []byte) error {
+	value, err := decodeAvroJSON(data)
+	if err != nil {
+		return err
+	}
+	decoded, err := readJSONHandshakeRequest(value)
+	if err != nil {
+		return err
+	}
+	*r = *decoded
+	return nil
+}
+
+// Reset clears the record, but keeps its nested records and the storage of its slices and maps for DeserializeInto to reuse.
+// Records can be pooled with sync.Pool by resetting them before they're put back.
+func (r *HandshakeRequest) Reset() {
+	*r = HandshakeRequest{}
+}
+
+func (r *HandshakeRequest) Schema() string {
+	return "{\"fields\":[{\"name\":\"clientHash\",\"type\":{\"name\":\"org.apache.avro.ipc.MD5\",\"size\":16,\"type\":\"fixed\"}},{\"name\":\"clientProtocol\",\"type\":[\"null\",\"string\"]},{\"name\":\"serverHash\",\"type\":{\"name\":\"org.apache.avro.ipc.MD5_1\",\"size\":16,\"type\":\"fixed\"}},{\"name\":\"meta\",\"type\":[\"null\",{\"type\":\"map\",\"values\":\"bytes\"}]}],\"name\":\"HandshakeRequest\",\"namespace\":\"org.apache.avro.ipc\",\"type\":\"record\"}"
+}
+
+// SchemaFingerprint returns the CRC-64-AVRO fingerprint of the canonical schema
+func (r *HandshakeRequest) SchemaFingerprint() uint64 {
+	return 0x57577c5a9ed76ab9
+}
+
+// SchemaFingerprintMD5 returns the MD5 fingerprint of the canonical schema
+func (r *HandshakeRequest) SchemaFingerprintMD5() [16]byte {
+	return [16]byte{0x4c, 0x82, 0x2a, 0xf2, 0xe1, 0x7e, 0xec, 0xd9, 0x24, 0x22, 0x82, 0x7e, 0xed, 0xe9, 0x7f, 0x5b}
+}
+
+// SchemaFingerprintSHA256 returns the SHA-256 fingerprint of the canonical schema
+func (r *HandshakeRequest) SchemaFingerprintSHA256() [32]byte {
+	return [32]byte{0x2b, 0x2f, 0x7a, 0x9b, 0x22, 0x99, 0x1f, 0xe0, 0xdf, 0x91, 0x34, 0xcb, 0x6b, 0x5f, 0xf7, 0x35, 0x53, 0x43, 0xe7, 0x97, 0xaa, 0xea, 0x33, 0x7e, 0x01, 0x50, 0xe2, 0x0f, 0x3a, 0x35, 0x80, 0x0e}
+}
+
+func (r *HandshakeRequest) SchemaVersion() int {
+	return 0
+}
+
+func (r *HandshakeRequest) SendStats(statser stats.Statser) {
+	statser.Count("org.apache.avro.ipc.HandshakeRequest", 1, stats.Tags{})
+}
+
+func (r *HandshakeRequest) Serialize(w io.Writer) error {
+	return writeHandshakeRequest(r, w)
+}
+
+// Validate returns the first value in the record which can't be serialized, as a *ValidationError
+func (r *HandshakeRequest) Validate() error {
+	if errs := validateHandshakeRequest(r, "", nil); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// ValidateAll returns every value in the record which can't be serialized, as ValidationErrors
+func (r *HandshakeRequest) ValidateAll() error {
+	if errs := validateHandshakeRequest(r, "", nil); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
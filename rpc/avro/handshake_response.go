@@ -0,0 +1,182 @@
+/*
+ * CODE GENERATED AUTOMATICALLY WITH github.com/alanctgardner/gogen-avro
+ * THIS FILE SHOULD NOT BE EDITED BY HAND
+ */
+
+package avro
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/satori/go.uuid"
+	"github.com/securityscorecard/go-stats"
+	"io"
+)
+
+type HandshakeResponse struct {
+	Match          HandshakeMatch
+	ServerProtocol UnionNullString
+	ServerHash     UnionNullMD5
+	Meta           UnionNullMapBytes
+}
+
+func DeserializeHandshakeResponse(r io.Reader) (*HandshakeResponse, error) {
+	d := decoderFor(r)
+	start := d.Offset()
+	t, err := readHandshakeResponse(d)
+	if err != nil {
+		return nil, decodeErrorAt(err, "HandshakeResponse", "org.apache.avro.ipc.HandshakeResponse", start, d.Offset())
+	}
+	return t, nil
+}
+
+// DeserializeHandshakeResponseInto decodes a HandshakeResponse from r into dst, reusing its nested records and the storage of its slices and maps
+// instead of allocating new ones. Values which dst held before are overwritten.
+func DeserializeHandshakeResponseInto(r io.Reader, dst *HandshakeResponse) error {
+	d := decoderFor(r)
+	start := d.Offset()
+	if err := readHandshakeResponseInto(d, dst); err != nil {
+		return decodeErrorAt(err, "HandshakeResponse", "org.apache.avro.ipc.HandshakeResponse", start, d.Offset())
+	}
+	return nil
+}
+
+// UnmarshalHandshakeResponse decodes a HandshakeResponse from the start of data, and returns it with the number of bytes it took up.
+// The values of bytes fields are copied out of data.
+func UnmarshalHandshakeResponse(data []byte) (*HandshakeResponse, int, error) {
+	d := newSliceDecoder(data, false)
+	t, err := readHandshakeResponse(d)
+	if err != nil {
+		return nil, 0, decodeErrorAt(err, "HandshakeResponse", "org.apache.avro.ipc.HandshakeResponse", 0, d.Offset())
+	}
+	return t, int(d.Offset()), nil
+}
+
+// UnmarshalHandshakeResponseNoCopy is like UnmarshalHandshakeResponse, but the values of bytes fields are slices of data rather than copies.
+// It's unsafe to modify or reuse data while the record is in use.
+func UnmarshalHandshakeResponseNoCopy(data []byte) (*HandshakeResponse, int, error) {
+	d := newSliceDecoder(data, true)
+	t, err := readHandshakeResponse(d)
+	if err != nil {
+		return nil, 0, decodeErrorAt(err, "HandshakeResponse", "org.apache.avro.ipc.HandshakeResponse", 0, d.Offset())
+	}
+	return t, int(d.Offset()), nil
+}
+
+// CanonicalSchema returns the Parsing Canonical Form of the record's schema, which the fingerprints are computed from
+func (r *HandshakeResponse) CanonicalSchema() string {
+	return "{\"name\":\"org.apache.avro.ipc.HandshakeResponse\",\"type\":\"record\",\"fields\":[{\"name\":\"match\",\"type\":{\"name\":\"org.apache.avro.ipc.HandshakeMatch\",\"type\":\"enum\",\"symbols\":[\"BOTH\",\"CLIENT\",\"NONE\"]}},{\"name\":\"serverProtocol\",\"type\":[\"null\",\"string\"]},{\"name\":\"serverHash\",\"type\":[\"null\",{\"name\":\"org.apache.avro.ipc.MD5\",\"type\":\"fixed\",\"size\":16}]},{\"name\":\"meta\",\"type\":[\"null\",{\"type\":\"map\",\"values\":\"bytes\"}]}]}"
+}
+
+// Clone returns a deep copy of the record, which shares no slices, maps or nested records with it
+func (r *HandshakeResponse) Clone() *HandshakeResponse {
+	return cloneHandshakeResponse(r)
+}
+
+// Diff returns the values which differ between the record and other, with the path of each, such as
+// lines[2].status or notes["gift"]. Arrays are compared by index, and maps by key in sorted order.
+func (r *HandshakeResponse) Diff(other *HandshakeResponse) []FieldDiff {
+	return diffHandshakeResponse(r, other, "", nil)
+}
+
+// EncodedSize returns the length of the binary encoding of the record, without encoding it
+func (r *HandshakeResponse) EncodedSize() int {
+	return sizeHandshakeResponse(r)
+}
+
+// Equals returns whether other holds the same values as the record. Nil and empty slices and maps are equal,
+// since they have the same encoding, and so are NaNs.
+func (r *HandshakeResponse) Equals(other *HandshakeResponse) bool {
+	return equalHandshakeResponse(r, other)
+}
+
+func (r *HandshakeResponse) GenerateID() string {
+	s := fmt.Sprint()
+	return uuid.NewV5(uuid.NamespaceOID, s).String()
+}
+
+// MarshalAppend appends the binary encoding of the record to dst and returns the extended slice.
+// Use EncodedSize to allocate dst with enough space for the record.
+func (r *HandshakeResponse) MarshalAppend(dst []byte) ([]byte, error) {
+	w := sliceWriter(dst)
+	if err := writeHandshakeResponse(r, &w); err != nil {
+		return dst, err
+	}
+	return w, nil
+}
+
+// MarshalAvroJSON encodes the record with the JSON encoding defined by the Avro spec
+func (r *HandshakeResponse) MarshalAvroJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSONHandshakeResponse(r, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalAvroJSON decodes the record from the JSON encoding defined by the Avro spec
+func (r *HandshakeResponse) UnmarshalAvroJSON(data []byte) error {
+	value, err := decodeAvroJSON(data)
+	if err != nil {
+		return err
+	}
+	decoded, err := readJSONHandshakeResponse(value)
+	if err != nil {
+		return err
+	}
+	*r = *decoded
+	return nil
+}
+
+// Reset clears the record, but keeps its nested records and the storage of its slices and maps for DeserializeInto to reuse.
+// Records can be pooled with sync.Pool by resetting them before they're put back.
+func (r *HandshakeResponse) Reset() {
+	*r = HandshakeResponse{}
+}
+
+func (r *HandshakeResponse) Schema() string {
+	return "{\"fields\":[{\"name\":\"match\",\"type\":{\"name\":\"org.apache.avro.ipc.HandshakeMatch\",\"symbols\":[\"BOTH\",\"CLIENT\",\"NONE\"],\"type\":\"enum\"}},{\"name\":\"serverProtocol\",\"type\":[\"null\",\"string\"]},{\"name\":\"serverHash\",\"type\":[\"null\",{\"name\":\"org.apache.avro.ipc.MD5\",\"size\":16,\"type\":\"fixed\"}]},{\"name\":\"meta\",\"type\":[\"null\",{\"type\":\"map\",\"values\":\"bytes\"}]}],\"name\":\"HandshakeResponse\",\"namespace\":\"org.apache.avro.ipc\",\"type\":\"record\"}"
+}
+
+// SchemaFingerprint returns the CRC-64-AVRO fingerprint of the canonical schema
+func (r *HandshakeResponse) SchemaFingerprint() uint64 {
+	return 0x0ea54ede01eefe00
+}
+
+// SchemaFingerprintMD5 returns the MD5 fingerprint of the canonical schema
+func (r *HandshakeResponse) SchemaFingerprintMD5() [16]byte {
+	return [16]byte{0xaf, 0xe5, 0x29, 0xd0, 0x11, 0x32, 0xda, 0xab, 0x7f, 0x4e, 0x2a, 0x66, 0x63, 0xe7, 0xa2, 0xf5}
+}
+
+// SchemaFingerprintSHA256 returns the SHA-256 fingerprint of the canonical schema
+func (r *HandshakeResponse) SchemaFingerprintSHA256() [32]byte {
+	return [32]byte{0xa3, 0x03, 0xcb, 0xbf, 0xe1, 0x39, 0x58, 0xf8, 0x80, 0x60, 0x5d, 0x70, 0xc5, 0x21, 0xa4, 0xb7, 0xbe, 0x34, 0xd9, 0x26, 0x5a, 0xc5, 0xa8, 0x48, 0xf2, 0x59, 0x16, 0xa6, 0x7b, 0x11, 0xd8, 0x89}
+}
+
+func (r *HandshakeResponse) SchemaVersion() int {
+	return 0
+}
+
+func (r *HandshakeResponse) SendStats(statser stats.Statser) {
+	statser.Count("org.apache.avro.ipc.HandshakeResponse", 1, stats.Tags{})
+}
+
+func (r *HandshakeResponse) Serialize(w io.Writer) error {
+	return writeHandshakeResponse(r, w)
+}
+
+// Validate returns the first value in the record which can't be serialized, as a *ValidationError
+func (r *HandshakeResponse) Validate() error {
+	if errs := validateHandshakeResponse(r, "", nil); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// ValidateAll returns every value in the record which can't be serialized, as ValidationErrors
+func (r *HandshakeResponse) ValidateAll() error {
+	if errs := validateHandshakeResponse(r, "", nil); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
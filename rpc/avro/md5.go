@@ -0,0 +1,8 @@
+/*
+ * CODE GENERATED AUTOMATICALLY WITH github.com/alanctgardner/gogen-avro
+ * THIS FILE SHOULD NOT BE EDITED BY HAND
+ */
+
+package avro
+
+type MD5 [16]byte
@@ -0,0 +1,1743 @@
+/*
+ * CODE GENERATED AUTOMATICALLY WITH github.com/alanctgardner/gogen-avro
+ * THIS FILE SHOULD NOT BE EDITED BY HAND
+ */
+
+package avro
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type ByteReader interface {
+	ReadByte() (byte, error)
+}
+
+type ByteWriter interface {
+	Grow(int)
+	WriteByte(byte) error
+}
+
+// DecodeError is returned by the generated decoders, with the path of the value which couldn't be read
+// (like Order.items[3].price), the Avro type expected there and the offset in the stream where reading stopped.
+// The Offset is -1 when it isn't known.
+type DecodeError struct {
+	Path   string
+	Type   string
+	Offset int64
+	Err    error
+}
+
+// Decoder reads the Avro binary encoding from a stream through io.ByteReader, and keeps scratch space between
+// values so the generated readers don't allocate for primitives. Pass the same Decoder to Deserialize for every
+// record in a stream: it may buffer past the end of the record it's reading.
+type Decoder struct {
+	r       io.Reader
+	br      ByteReader
+	offset  int64
+	buf     [8]byte
+	scratch []byte
+	// When there's no reader, the Decoder reads from data, and offset is the index of the next byte
+	data []byte
+	// Whether bytes values are slices of data rather than copies
+	alias bool
+	// The number of records being read, nested inside each other
+	depth int
+}
+
+// DepthError is returned by the generated decoders when records are nested deeper than MaxDepth
+type DepthError struct {
+	Limit int
+}
+
+// FieldDiff is a value which differs between two records: its path, and its value in each of them.
+// Old or New is nil if the value is missing from that record, or it holds null.
+type FieldDiff struct {
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+// LengthError is returned by the generated decoders when a length read from the input is negative or exceeds its limit
+type LengthError struct {
+	Type   string
+	Length int64
+	Limit  int64
+}
+
+// The number of records the generated decoders allow to be nested inside each other. It protects against
+// running out of stack on corrupt or hostile input for recursive schemas. A limit of 0 disables the check.
+var MaxDepth = 128
+
+type StringWriter interface {
+	WriteString(string) (int, error)
+}
+
+// ValidationError describes a value which can't be serialized, and the path of the field which holds it
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+// ValidationErrors holds every value in a record which can't be serialized
+type ValidationErrors []*ValidationError
+
+// Limits on the lengths read by the generated decoders, which protect against corrupt or hostile input.
+// A limit of 0 disables the check.
+var (
+	MaxStringLength int64 = 128 << 20
+	MaxBytesLength  int64 = 128 << 20
+	MaxArrayLength  int64 = 16 << 20
+	MaxMapLength    int64 = 16 << 20
+)
+
+// The largest value a Decoder keeps scratch space for
+const maxDecoderScratch = 64 << 10
+
+// sliceWriter appends everything written to it to a byte slice
+type sliceWriter []byte
+
+// NewDecoder returns a Decoder which reads from r, wrapping it in a bufio.Reader unless it implements io.ByteReader
+func NewDecoder(r io.Reader) *Decoder {
+	if _, ok := r.(ByteReader); !ok {
+		r = bufio.NewReader(r)
+	}
+	return newDecoder(r)
+}
+
+func checkLength(typ string, length, limit int64) error {
+	if length < 0 || (limit > 0 && length > limit) {
+		return &LengthError{Type: typ, Length: length, Limit: limit}
+	}
+	return nil
+}
+
+func cloneBytes(r []byte) []byte {
+	if r == nil {
+		return nil
+	}
+	c := make([]byte, len(r))
+	copy(c, r)
+	return c
+}
+
+func cloneHandshakeRequest(r *HandshakeRequest) *HandshakeRequest {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	c.Meta = cloneUnionNullMapBytes(r.Meta)
+	return &c
+}
+
+func cloneHandshakeResponse(r *HandshakeResponse) *HandshakeResponse {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	c.Meta = cloneUnionNullMapBytes(r.Meta)
+	return &c
+}
+
+func cloneMapBytes(r map[string][]byte) map[string][]byte {
+	if r == nil {
+		return nil
+	}
+	c := make(map[string][]byte, len(r))
+	for k, v := range r {
+		c[k] = cloneBytes(v)
+	}
+	return c
+}
+
+func cloneUnionNullMapBytes(r UnionNullMapBytes) UnionNullMapBytes {
+	c := r
+	c.MapBytes = cloneMapBytes(r.MapBytes)
+	return c
+}
+
+func decodeAvroJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// decodeErrorAt wraps an error from reading a record which started at start and stopped at offset. If the input
+// ended before any of the record was read, it returns io.EOF itself, so callers can tell the end of a stream apart.
+func decodeErrorAt(err error, segment, typ string, start, offset int64) error {
+	if offset == start {
+		if e, ok := err.(*DecodeError); (ok && e.Err == io.EOF) || err == io.EOF {
+			return io.EOF
+		}
+	}
+	e := wrapDecodeError(err, segment, typ).(*DecodeError)
+	e.Offset = offset
+	return e
+}
+
+// decoderFor returns r if it's already a Decoder, or an unbuffered Decoder which won't read past the value
+func decoderFor(r io.Reader) *Decoder {
+	if d, ok := r.(*Decoder); ok {
+		return d
+	}
+	return newDecoder(r)
+}
+
+func diffHandshakeRequest(a, b *HandshakeRequest, path string, diffs []FieldDiff) []FieldDiff {
+	if a == nil || b == nil {
+		if a == nil && b == nil {
+			return diffs
+		}
+		diff := FieldDiff{Path: path}
+		if a != nil {
+			diff.Old = a
+		}
+		if b != nil {
+			diff.New = b
+		}
+		return append(diffs, diff)
+	}
+	if !(a.ClientHash == b.ClientHash) {
+		diffs = append(diffs, FieldDiff{Path: validationPath(path, "clientHash"), Old: a.ClientHash, New: b.ClientHash})
+	}
+	diffs = diffUnionNullString(a.ClientProtocol, b.ClientProtocol, validationPath(path, "clientProtocol"), diffs)
+	if !(a.ServerHash == b.ServerHash) {
+		diffs = append(diffs, FieldDiff{Path: validationPath(path, "serverHash"), Old: a.ServerHash, New: b.ServerHash})
+	}
+	diffs = diffUnionNullMapBytes(a.Meta, b.Meta, validationPath(path, "meta"), diffs)
+	return diffs
+}
+
+func diffHandshakeResponse(a, b *HandshakeResponse, path string, diffs []FieldDiff) []FieldDiff {
+	if a == nil || b == nil {
+		if a == nil && b == nil {
+			return diffs
+		}
+		diff := FieldDiff{Path: path}
+		if a != nil {
+			diff.Old = a
+		}
+		if b != nil {
+			diff.New = b
+		}
+		return append(diffs, diff)
+	}
+	if !(a.Match == b.Match) {
+		diffs = append(diffs, FieldDiff{Path: validationPath(path, "match"), Old: a.Match, New: b.Match})
+	}
+	diffs = diffUnionNullString(a.ServerProtocol, b.ServerProtocol, validationPath(path, "serverProtocol"), diffs)
+	diffs = diffUnionNullMD5(a.ServerHash, b.ServerHash, validationPath(path, "serverHash"), diffs)
+	diffs = diffUnionNullMapBytes(a.Meta, b.Meta, validationPath(path, "meta"), diffs)
+	return diffs
+}
+
+func diffMapBytes(a, b map[string][]byte, path string, diffs []FieldDiff) []FieldDiff {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		itemPath := fmt.Sprintf("%v[%q]", path, k)
+		va, inA := a[k]
+		vb, inB := b[k]
+		switch {
+		case !inB:
+			diffs = append(diffs, FieldDiff{Path: itemPath, Old: va})
+		case !inA:
+			diffs = append(diffs, FieldDiff{Path: itemPath, New: vb})
+		default:
+			if !(bytes.Equal(va, vb)) {
+				diffs = append(diffs, FieldDiff{Path: itemPath, Old: va, New: vb})
+			}
+
+		}
+	}
+	return diffs
+}
+
+func diffUnionNullMD5(a, b UnionNullMD5, path string, diffs []FieldDiff) []FieldDiff {
+	if a.UnionType != b.UnionType {
+		return append(diffs, FieldDiff{Path: path, Old: valueOfUnionNullMD5(a), New: valueOfUnionNullMD5(b)})
+	}
+	switch a.UnionType {
+	case UnionNullMD5TypeEnumMD5:
+		if !(a.MD5 == b.MD5) {
+			diffs = append(diffs, FieldDiff{Path: path, Old: a.MD5, New: b.MD5})
+		}
+
+	}
+	return diffs
+}
+
+func diffUnionNullMapBytes(a, b UnionNullMapBytes, path string, diffs []FieldDiff) []FieldDiff {
+	if a.UnionType != b.UnionType {
+		return append(diffs, FieldDiff{Path: path, Old: valueOfUnionNullMapBytes(a), New: valueOfUnionNullMapBytes(b)})
+	}
+	switch a.UnionType {
+	case UnionNullMapBytesTypeEnumMapBytes:
+		diffs = diffMapBytes(a.MapBytes, b.MapBytes, path, diffs)
+
+	}
+	return diffs
+}
+
+func diffUnionNullString(a, b UnionNullString, path string, diffs []FieldDiff) []FieldDiff {
+	if a.UnionType != b.UnionType {
+		return append(diffs, FieldDiff{Path: path, Old: valueOfUnionNullString(a), New: valueOfUnionNullString(b)})
+	}
+	switch a.UnionType {
+	case UnionNullStringTypeEnumString:
+		if !(a.String == b.String) {
+			diffs = append(diffs, FieldDiff{Path: path, Old: a.String, New: b.String})
+		}
+
+	}
+	return diffs
+}
+
+func encodeInt(w io.Writer, byteCount int, encoded uint64) error {
+	var err error
+	var bb []byte
+	bw, ok := w.(ByteWriter)
+	// To avoid reallocations, grow capacity to the largest possible size
+	// for this integer
+	if ok {
+		bw.Grow(byteCount)
+	} else {
+		bb = make([]byte, 0, byteCount)
+	}
+
+	if encoded == 0 {
+		if bw != nil {
+			err = bw.WriteByte(0)
+			if err != nil {
+				return err
+			}
+		} else {
+			bb = append(bb, byte(0))
+		}
+	} else {
+		for encoded > 0 {
+			b := byte(encoded & 127)
+			encoded = encoded >> 7
+			if !(encoded == 0) {
+				b |= 128
+			}
+			if bw != nil {
+				err = bw.WriteByte(b)
+				if err != nil {
+					return err
+				}
+			} else {
+				bb = append(bb, b)
+			}
+		}
+	}
+	if bw == nil {
+		_, err := w.Write(bb)
+		return err
+	}
+	return nil
+
+}
+
+// enterRecord counts a record being read from r, and returns a *DepthError if it's nested deeper than MaxDepth.
+// Every call which succeeds has to be followed by leaveRecord.
+func enterRecord(r io.Reader) error {
+	d, ok := r.(*Decoder)
+	if !ok {
+		return nil
+	}
+	if MaxDepth > 0 && d.depth >= MaxDepth {
+		return &DepthError{Limit: MaxDepth}
+	}
+	d.depth++
+	return nil
+}
+
+func equalHandshakeRequest(a, b *HandshakeRequest) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.ClientHash == b.ClientHash &&
+		equalUnionNullString(a.ClientProtocol, b.ClientProtocol) &&
+		a.ServerHash == b.ServerHash &&
+		equalUnionNullMapBytes(a.Meta, b.Meta)
+}
+
+func equalHandshakeResponse(a, b *HandshakeResponse) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Match == b.Match &&
+		equalUnionNullString(a.ServerProtocol, b.ServerProtocol) &&
+		equalUnionNullMD5(a.ServerHash, b.ServerHash) &&
+		equalUnionNullMapBytes(a.Meta, b.Meta)
+}
+
+func equalMapBytes(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, va := range a {
+		vb, ok := b[k]
+		if !ok || !(bytes.Equal(va, vb)) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalUnionNullMD5(a, b UnionNullMD5) bool {
+	if a.UnionType != b.UnionType {
+		return false
+	}
+	switch a.UnionType {
+	case UnionNullMD5TypeEnumMD5:
+		return a.MD5 == b.MD5
+
+	}
+	return true
+}
+
+func equalUnionNullMapBytes(a, b UnionNullMapBytes) bool {
+	if a.UnionType != b.UnionType {
+		return false
+	}
+	switch a.UnionType {
+	case UnionNullMapBytesTypeEnumMapBytes:
+		return equalMapBytes(a.MapBytes, b.MapBytes)
+
+	}
+	return true
+}
+
+func equalUnionNullString(a, b UnionNullString) bool {
+	if a.UnionType != b.UnionType {
+		return false
+	}
+	switch a.UnionType {
+	case UnionNullStringTypeEnumString:
+		return a.String == b.String
+
+	}
+	return true
+}
+
+func leaveRecord(r io.Reader) {
+	if d, ok := r.(*Decoder); ok {
+		d.depth--
+	}
+}
+
+func newDecoder(r io.Reader) *Decoder {
+	d := &Decoder{r: r}
+	d.br, _ = r.(ByteReader)
+	return d
+}
+
+func newSliceDecoder(data []byte, alias bool) *Decoder {
+	return &Decoder{data: data, alias: alias}
+}
+
+func readBytes(r io.Reader) ([]byte, error) {
+	size, err := readLong(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLength("bytes", size, MaxBytesLength); err != nil {
+		return nil, err
+	}
+	return decoderFor(r).bytes(int(size))
+}
+
+func readHandshakeMatch(r io.Reader) (HandshakeMatch, error) {
+	val, err := readInt(r)
+	return HandshakeMatch(val), err
+}
+
+func readHandshakeRequest(r io.Reader) (*HandshakeRequest, error) {
+	if err := enterRecord(r); err != nil {
+		return nil, err
+	}
+	defer leaveRecord(r)
+	var str = &HandshakeRequest{}
+	var err error
+	str.ClientHash, err = readMD5(r)
+	if err != nil {
+		return nil, wrapDecodeError(err, "clientHash", "org.apache.avro.ipc.MD5")
+	}
+	str.ClientProtocol, err = readUnionNullString(r)
+	if err != nil {
+		return nil, wrapDecodeError(err, "clientProtocol", "union")
+	}
+	str.ServerHash, err = readMD5(r)
+	if err != nil {
+		return nil, wrapDecodeError(err, "serverHash", "org.apache.avro.ipc.MD5")
+	}
+	str.Meta, err = readUnionNullMapBytes(r)
+	if err != nil {
+		return nil, wrapDecodeError(err, "meta", "union")
+	}
+
+	return str, nil
+}
+
+func readHandshakeRequestInto(r io.Reader, dst *HandshakeRequest) error {
+	if err := enterRecord(r); err != nil {
+		return err
+	}
+	defer leaveRecord(r)
+	var err error
+	dst.ClientHash, err = readMD5(r)
+	if err != nil {
+		return wrapDecodeError(err, "clientHash", "org.apache.avro.ipc.MD5")
+	}
+	dst.ClientProtocol, err = readUnionNullString(r)
+	if err != nil {
+		return wrapDecodeError(err, "clientProtocol", "union")
+	}
+	dst.ServerHash, err = readMD5(r)
+	if err != nil {
+		return wrapDecodeError(err, "serverHash", "org.apache.avro.ipc.MD5")
+	}
+	dst.Meta, err = readUnionNullMapBytes(r)
+	if err != nil {
+		return wrapDecodeError(err, "meta", "union")
+	}
+	return nil
+}
+
+func readHandshakeResponse(r io.Reader) (*HandshakeResponse, error) {
+	if err := enterRecord(r); err != nil {
+		return nil, err
+	}
+	defer leaveRecord(r)
+	var str = &HandshakeResponse{}
+	var err error
+	str.Match, err = readHandshakeMatch(r)
+	if err != nil {
+		return nil, wrapDecodeError(err, "match", "org.apache.avro.ipc.HandshakeMatch")
+	}
+	str.ServerProtocol, err = readUnionNullString(r)
+	if err != nil {
+		return nil, wrapDecodeError(err, "serverProtocol", "union")
+	}
+	str.ServerHash, err = readUnionNullMD5(r)
+	if err != nil {
+		return nil, wrapDecodeError(err, "serverHash", "union")
+	}
+	str.Meta, err = readUnionNullMapBytes(r)
+	if err != nil {
+		return nil, wrapDecodeError(err, "meta", "union")
+	}
+
+	return str, nil
+}
+
+func readHandshakeResponseInto(r io.Reader, dst *HandshakeResponse) error {
+	if err := enterRecord(r); err != nil {
+		return err
+	}
+	defer leaveRecord(r)
+	var err error
+	dst.Match, err = readHandshakeMatch(r)
+	if err != nil {
+		return wrapDecodeError(err, "match", "org.apache.avro.ipc.HandshakeMatch")
+	}
+	dst.ServerProtocol, err = readUnionNullString(r)
+	if err != nil {
+		return wrapDecodeError(err, "serverProtocol", "union")
+	}
+	dst.ServerHash, err = readUnionNullMD5(r)
+	if err != nil {
+		return wrapDecodeError(err, "serverHash", "union")
+	}
+	dst.Meta, err = readUnionNullMapBytes(r)
+	if err != nil {
+		return wrapDecodeError(err, "meta", "union")
+	}
+	return nil
+}
+
+func readInt(r io.Reader) (int32, error) {
+	d := decoderFor(r)
+	var v uint32
+	for shift := uint(0); ; shift += 7 {
+		b, err := d.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		// The fifth byte holds the top 4 bits, and must be the last
+		if shift == 28 && b > 15 {
+			return 0, fmt.Errorf("Varint overflows an int")
+		}
+		v |= uint32(b&127) << shift
+		if b&128 == 0 {
+			break
+		}
+	}
+	datum := (int32(v>>1) ^ -int32(v&1))
+	return datum, nil
+}
+
+func readJSONBytes(v interface{}) ([]byte, error) {
+	s, err := readJSONString(v)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 0, len(s))
+	for _, c := range s {
+		if c > 0xff {
+			return nil, fmt.Errorf("Invalid character %q in bytes value", c)
+		}
+		b = append(b, byte(c))
+	}
+	return b, nil
+}
+
+func readJSONHandshakeMatch(v interface{}) (HandshakeMatch, error) {
+	s, err := readJSONString(v)
+	if err != nil {
+		return 0, err
+	}
+	switch s {
+	case "BOTH":
+		return BOTH, nil
+	case "CLIENT":
+		return CLIENT, nil
+	case "NONE":
+		return NONE, nil
+
+	}
+	return 0, fmt.Errorf("Invalid symbol %q for HandshakeMatch", s)
+}
+
+func readJSONHandshakeRequest(v interface{}) (*HandshakeRequest, error) {
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Expected a JSON object for org.apache.avro.ipc.HandshakeRequest, got %T", v)
+	}
+	var str = &HandshakeRequest{}
+	var value interface{}
+	var err error
+	if v, ok := object["clientHash"]; ok {
+		value = v
+	} else {
+		return nil, fmt.Errorf("Missing field %q of org.apache.avro.ipc.HandshakeRequest", "clientHash")
+	}
+	str.ClientHash, err = readJSONMD5(value)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := object["clientProtocol"]; ok {
+		value = v
+	} else {
+		return nil, fmt.Errorf("Missing field %q of org.apache.avro.ipc.HandshakeRequest", "clientProtocol")
+	}
+	str.ClientProtocol, err = readJSONUnionNullString(value)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := object["serverHash"]; ok {
+		value = v
+	} else {
+		return nil, fmt.Errorf("Missing field %q of org.apache.avro.ipc.HandshakeRequest", "serverHash")
+	}
+	str.ServerHash, err = readJSONMD5(value)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := object["meta"]; ok {
+		value = v
+	} else {
+		return nil, fmt.Errorf("Missing field %q of org.apache.avro.ipc.HandshakeRequest", "meta")
+	}
+	str.Meta, err = readJSONUnionNullMapBytes(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return str, nil
+}
+
+func readJSONHandshakeResponse(v interface{}) (*HandshakeResponse, error) {
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Expected a JSON object for org.apache.avro.ipc.HandshakeResponse, got %T", v)
+	}
+	var str = &HandshakeResponse{}
+	var value interface{}
+	var err error
+	if v, ok := object["match"]; ok {
+		value = v
+	} else {
+		return nil, fmt.Errorf("Missing field %q of org.apache.avro.ipc.HandshakeResponse", "match")
+	}
+	str.Match, err = readJSONHandshakeMatch(value)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := object["serverProtocol"]; ok {
+		value = v
+	} else {
+		return nil, fmt.Errorf("Missing field %q of org.apache.avro.ipc.HandshakeResponse", "serverProtocol")
+	}
+	str.ServerProtocol, err = readJSONUnionNullString(value)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := object["serverHash"]; ok {
+		value = v
+	} else {
+		return nil, fmt.Errorf("Missing field %q of org.apache.avro.ipc.HandshakeResponse", "serverHash")
+	}
+	str.ServerHash, err = readJSONUnionNullMD5(value)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := object["meta"]; ok {
+		value = v
+	} else {
+		return nil, fmt.Errorf("Missing field %q of org.apache.avro.ipc.HandshakeResponse", "meta")
+	}
+	str.Meta, err = readJSONUnionNullMapBytes(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return str, nil
+}
+
+func readJSONMD5(v interface{}) (MD5, error) {
+	var fixed MD5
+	b, err := readJSONBytes(v)
+	if err != nil {
+		return fixed, err
+	}
+	if len(b) != 16 {
+		return fixed, fmt.Errorf("Expected 16 bytes for MD5, got %v", len(b))
+	}
+	copy(fixed[:], b)
+	return fixed, nil
+}
+
+func readJSONMapBytes(v interface{}) (map[string][]byte, error) {
+	values, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Expected a JSON object, got %T", v)
+	}
+	m := make(map[string][]byte)
+	for k, value := range values {
+		e, err := readJSONBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = e
+	}
+	return m, nil
+}
+
+func readJSONNull(v interface{}) (interface{}, error) {
+	if v != nil {
+		return nil, fmt.Errorf("Expected JSON null, got %T", v)
+	}
+	return nil, nil
+}
+
+func readJSONString(v interface{}) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("Expected a JSON string, got %T", v)
+	}
+	return s, nil
+}
+
+func readJSONUnionNullMD5(v interface{}) (UnionNullMD5, error) {
+	var unionStr UnionNullMD5
+	if v == nil {
+		return UnionNullMD5{UnionType: UnionNullMD5TypeEnumNull}, nil
+	}
+	object, ok := v.(map[string]interface{})
+	if !ok || len(object) != 1 {
+		return unionStr, fmt.Errorf("Expected a JSON object with one key for UnionNullMD5, got %v", v)
+	}
+	for name, value := range object {
+		switch name {
+		case "org.apache.avro.ipc.MD5":
+			val, err := readJSONMD5(value)
+			if err != nil {
+				return unionStr, err
+			}
+			return UnionNullMD5{MD5: val, UnionType: UnionNullMD5TypeEnumMD5}, nil
+
+		}
+		return unionStr, fmt.Errorf("Invalid branch %q for UnionNullMD5", name)
+	}
+	return unionStr, nil
+}
+
+func readJSONUnionNullMapBytes(v interface{}) (UnionNullMapBytes, error) {
+	var unionStr UnionNullMapBytes
+	if v == nil {
+		return UnionNullMapBytes{UnionType: UnionNullMapBytesTypeEnumNull}, nil
+	}
+	object, ok := v.(map[string]interface{})
+	if !ok || len(object) != 1 {
+		return unionStr, fmt.Errorf("Expected a JSON object with one key for UnionNullMapBytes, got %v", v)
+	}
+	for name, value := range object {
+		switch name {
+		case "map":
+			val, err := readJSONMapBytes(value)
+			if err != nil {
+				return unionStr, err
+			}
+			return UnionNullMapBytes{MapBytes: val, UnionType: UnionNullMapBytesTypeEnumMapBytes}, nil
+
+		}
+		return unionStr, fmt.Errorf("Invalid branch %q for UnionNullMapBytes", name)
+	}
+	return unionStr, nil
+}
+
+func readJSONUnionNullString(v interface{}) (UnionNullString, error) {
+	var unionStr UnionNullString
+	if v == nil {
+		return UnionNullString{UnionType: UnionNullStringTypeEnumNull}, nil
+	}
+	object, ok := v.(map[string]interface{})
+	if !ok || len(object) != 1 {
+		return unionStr, fmt.Errorf("Expected a JSON object with one key for UnionNullString, got %v", v)
+	}
+	for name, value := range object {
+		switch name {
+		case "string":
+			val, err := readJSONString(value)
+			if err != nil {
+				return unionStr, err
+			}
+			return UnionNullString{String: val, UnionType: UnionNullStringTypeEnumString}, nil
+
+		}
+		return unionStr, fmt.Errorf("Invalid branch %q for UnionNullString", name)
+	}
+	return unionStr, nil
+}
+
+func readLong(r io.Reader) (int64, error) {
+	d := decoderFor(r)
+	var v uint64
+	for shift := uint(0); ; shift += 7 {
+		b, err := d.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		// The tenth byte holds the top bit, and must be the last
+		if shift == 63 && b > 1 {
+			return 0, fmt.Errorf("Varint overflows a long")
+		}
+		v |= uint64(b&127) << shift
+		if b&128 == 0 {
+			break
+		}
+	}
+	datum := (int64(v>>1) ^ -int64(v&1))
+	return datum, nil
+}
+
+func readMD5(r io.Reader) (MD5, error) {
+	var bb MD5
+	buf, err := decoderFor(r).next(len(bb))
+	if err != nil {
+		return bb, err
+	}
+	copy(bb[:], buf)
+	return bb, nil
+}
+
+func readMapBytes(r io.Reader) (map[string][]byte, error) {
+	m := make(map[string][]byte)
+	var total int64
+	for {
+		blkSize, err := readLong(r)
+		if err != nil {
+			return nil, err
+		}
+		if blkSize == 0 {
+			break
+		}
+		if blkSize < 0 {
+			blkSize = -blkSize
+			_, err := readLong(r)
+			if err != nil {
+				return nil, err
+			}
+		}
+		total += blkSize
+		if err := checkLength("map", total, MaxMapLength); err != nil {
+			return nil, err
+		}
+		for i := int64(0); i < blkSize; i++ {
+			key, err := readString(r)
+			if err != nil {
+				return nil, err
+			}
+			val, err := readBytes(r)
+			if err != nil {
+				return nil, wrapDecodeError(err, fmt.Sprintf("[%q]", key), "bytes")
+			}
+			m[key] = val
+		}
+	}
+	return m, nil
+}
+
+func readNull(_ io.Reader) (interface{}, error) {
+	return nil, nil
+}
+
+func readString(r io.Reader) (string, error) {
+	len, err := readLong(r)
+	if err != nil {
+		return "", err
+	}
+	if err := checkLength("string", len, MaxStringLength); err != nil {
+		return "", err
+	}
+	bb, err := decoderFor(r).next(int(len))
+	if err != nil {
+		return "", err
+	}
+	return string(bb), nil
+}
+
+func readUnionNullMD5(r io.Reader) (UnionNullMD5, error) {
+	field, err := readLong(r)
+	var unionStr UnionNullMD5
+	if err != nil {
+		return unionStr, err
+	}
+	unionStr.UnionType = UnionNullMD5TypeEnum(field)
+	switch unionStr.UnionType {
+	case UnionNullMD5TypeEnumNull:
+		val, err := readNull(r)
+		if err != nil {
+			return unionStr, err
+		}
+		unionStr.Null = val
+	case UnionNullMD5TypeEnumMD5:
+		val, err := readMD5(r)
+		if err != nil {
+			return unionStr, err
+		}
+		unionStr.MD5 = val
+
+	default:
+		return unionStr, fmt.Errorf("Invalid value for UnionNullMD5")
+	}
+	return unionStr, nil
+}
+
+func readUnionNullMapBytes(r io.Reader) (UnionNullMapBytes, error) {
+	field, err := readLong(r)
+	var unionStr UnionNullMapBytes
+	if err != nil {
+		return unionStr, err
+	}
+	unionStr.UnionType = UnionNullMapBytesTypeEnum(field)
+	switch unionStr.UnionType {
+	case UnionNullMapBytesTypeEnumNull:
+		val, err := readNull(r)
+		if err != nil {
+			return unionStr, err
+		}
+		unionStr.Null = val
+	case UnionNullMapBytesTypeEnumMapBytes:
+		val, err := readMapBytes(r)
+		if err != nil {
+			return unionStr, err
+		}
+		unionStr.MapBytes = val
+
+	default:
+		return unionStr, fmt.Errorf("Invalid value for UnionNullMapBytes")
+	}
+	return unionStr, nil
+}
+
+func readUnionNullString(r io.Reader) (UnionNullString, error) {
+	field, err := readLong(r)
+	var unionStr UnionNullString
+	if err != nil {
+		return unionStr, err
+	}
+	unionStr.UnionType = UnionNullStringTypeEnum(field)
+	switch unionStr.UnionType {
+	case UnionNullStringTypeEnumNull:
+		val, err := readNull(r)
+		if err != nil {
+			return unionStr, err
+		}
+		unionStr.Null = val
+	case UnionNullStringTypeEnumString:
+		val, err := readString(r)
+		if err != nil {
+			return unionStr, err
+		}
+		unionStr.String = val
+
+	default:
+		return unionStr, fmt.Errorf("Invalid value for UnionNullString")
+	}
+	return unionStr, nil
+}
+
+func sizeBytes(r []byte) int {
+	return sizeLong(int64(len(r))) + len(r)
+}
+
+func sizeHandshakeMatch(r HandshakeMatch) int {
+	return sizeInt(int32(r))
+}
+
+func sizeHandshakeRequest(r *HandshakeRequest) int {
+	size := 0
+	size += sizeMD5(r.ClientHash)
+	size += sizeUnionNullString(r.ClientProtocol)
+	size += sizeMD5(r.ServerHash)
+	size += sizeUnionNullMapBytes(r.Meta)
+	return size
+}
+
+func sizeHandshakeResponse(r *HandshakeResponse) int {
+	size := 0
+	size += sizeHandshakeMatch(r.Match)
+	size += sizeUnionNullString(r.ServerProtocol)
+	size += sizeUnionNullMD5(r.ServerHash)
+	size += sizeUnionNullMapBytes(r.Meta)
+	return size
+}
+
+func sizeInt(r int32) int {
+	return sizeLong(int64(r))
+}
+
+func sizeLong(r int64) int {
+	encoded := uint64((r << 1) ^ (r >> 63))
+	size := 1
+	for encoded >= 0x80 {
+		encoded >>= 7
+		size++
+	}
+	return size
+}
+
+func sizeMD5(r MD5) int {
+	return 16
+}
+
+func sizeMapBytes(r map[string][]byte) int {
+	if len(r) == 0 {
+		return 1
+	}
+	size := sizeLong(int64(len(r))) + 1
+	for k, e := range r {
+		size += sizeString(k) + sizeBytes(e)
+	}
+	return size
+}
+
+func sizeNull(r interface{}) int {
+	return 0
+}
+
+func sizeString(r string) int {
+	return sizeLong(int64(len(r))) + len(r)
+}
+
+func sizeUnionNullMD5(r UnionNullMD5) int {
+	switch r.UnionType {
+	case UnionNullMD5TypeEnumNull:
+		return 1
+	case UnionNullMD5TypeEnumMD5:
+		return 1 + sizeMD5(r.MD5)
+
+	}
+	return 0
+}
+
+func sizeUnionNullMapBytes(r UnionNullMapBytes) int {
+	switch r.UnionType {
+	case UnionNullMapBytesTypeEnumNull:
+		return 1
+	case UnionNullMapBytesTypeEnumMapBytes:
+		return 1 + sizeMapBytes(r.MapBytes)
+
+	}
+	return 0
+}
+
+func sizeUnionNullString(r UnionNullString) int {
+	switch r.UnionType {
+	case UnionNullStringTypeEnumNull:
+		return 1
+	case UnionNullStringTypeEnumString:
+		return 1 + sizeString(r.String)
+
+	}
+	return 0
+}
+
+func validateHandshakeMatch(r HandshakeMatch, path string, errs ValidationErrors) ValidationErrors {
+	if r < 0 || r >= 3 {
+		return append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Invalid value %d for enum org.apache.avro.ipc.HandshakeMatch", int32(r))})
+	}
+	return errs
+}
+
+func validateHandshakeRequest(r *HandshakeRequest, path string, errs ValidationErrors) ValidationErrors {
+	if r == nil {
+		return append(errs, &ValidationError{Path: path, Message: "Nil value for record org.apache.avro.ipc.HandshakeRequest"})
+	}
+	errs = validateUnionNullString(r.ClientProtocol, validationPath(path, "clientProtocol"), errs)
+	errs = validateUnionNullMapBytes(r.Meta, validationPath(path, "meta"), errs)
+	return errs
+}
+
+func validateHandshakeResponse(r *HandshakeResponse, path string, errs ValidationErrors) ValidationErrors {
+	if r == nil {
+		return append(errs, &ValidationError{Path: path, Message: "Nil value for record org.apache.avro.ipc.HandshakeResponse"})
+	}
+	errs = validateHandshakeMatch(r.Match, validationPath(path, "match"), errs)
+	errs = validateUnionNullString(r.ServerProtocol, validationPath(path, "serverProtocol"), errs)
+	errs = validateUnionNullMD5(r.ServerHash, validationPath(path, "serverHash"), errs)
+	errs = validateUnionNullMapBytes(r.Meta, validationPath(path, "meta"), errs)
+	return errs
+}
+
+func validateUnionNullMD5(r UnionNullMD5, path string, errs ValidationErrors) ValidationErrors {
+	switch r.UnionType {
+	case UnionNullMD5TypeEnumNull:
+		return errs
+	case UnionNullMD5TypeEnumMD5:
+		return errs
+
+	}
+	return append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Invalid UnionType %v for UnionNullMD5", r.UnionType)})
+}
+
+func validateUnionNullMapBytes(r UnionNullMapBytes, path string, errs ValidationErrors) ValidationErrors {
+	switch r.UnionType {
+	case UnionNullMapBytesTypeEnumNull:
+		return errs
+	case UnionNullMapBytesTypeEnumMapBytes:
+		return errs
+
+	}
+	return append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Invalid UnionType %v for UnionNullMapBytes", r.UnionType)})
+}
+
+func validateUnionNullString(r UnionNullString, path string, errs ValidationErrors) ValidationErrors {
+	switch r.UnionType {
+	case UnionNullStringTypeEnumNull:
+		return errs
+	case UnionNullStringTypeEnumString:
+		return errs
+
+	}
+	return append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Invalid UnionType %v for UnionNullString", r.UnionType)})
+}
+
+func validationPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func valueOfUnionNullMD5(r UnionNullMD5) interface{} {
+	switch r.UnionType {
+	case UnionNullMD5TypeEnumMD5:
+		return r.MD5
+
+	}
+	return nil
+}
+
+func valueOfUnionNullMapBytes(r UnionNullMapBytes) interface{} {
+	switch r.UnionType {
+	case UnionNullMapBytesTypeEnumMapBytes:
+		return r.MapBytes
+
+	}
+	return nil
+}
+
+func valueOfUnionNullString(r UnionNullString) interface{} {
+	switch r.UnionType {
+	case UnionNullStringTypeEnumString:
+		return r.String
+
+	}
+	return nil
+}
+
+func wrapDecodeError(err error, segment, typ string) error {
+	if e, ok := err.(*DecodeError); ok {
+		if e.Path == "" || e.Path[0] == '[' {
+			e.Path = segment + e.Path
+		} else {
+			e.Path = segment + "." + e.Path
+		}
+		return e
+	}
+	return &DecodeError{Path: segment, Type: typ, Offset: -1, Err: err}
+}
+
+func writeBytes(r []byte, w io.Writer) error {
+	err := writeLong(int64(len(r)), w)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(r)
+	return err
+}
+
+func writeHandshakeMatch(r HandshakeMatch, w io.Writer) error {
+	return writeInt(int32(r), w)
+}
+
+func writeHandshakeRequest(r *HandshakeRequest, w io.Writer) error {
+	var err error
+	err = writeMD5(r.ClientHash, w)
+	if err != nil {
+		return err
+	}
+	err = writeUnionNullString(r.ClientProtocol, w)
+	if err != nil {
+		return err
+	}
+	err = writeMD5(r.ServerHash, w)
+	if err != nil {
+		return err
+	}
+	err = writeUnionNullMapBytes(r.Meta, w)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+func writeHandshakeResponse(r *HandshakeResponse, w io.Writer) error {
+	var err error
+	err = writeHandshakeMatch(r.Match, w)
+	if err != nil {
+		return err
+	}
+	err = writeUnionNullString(r.ServerProtocol, w)
+	if err != nil {
+		return err
+	}
+	err = writeUnionNullMD5(r.ServerHash, w)
+	if err != nil {
+		return err
+	}
+	err = writeUnionNullMapBytes(r.Meta, w)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func writeInt(r int32, w io.Writer) error {
+	downShift := uint32(31)
+	encoded := uint64((uint32(r) << 1) ^ uint32(r>>downShift))
+	const maxByteSize = 5
+	return encodeInt(w, maxByteSize, encoded)
+}
+
+func writeJSONBytes(r []byte, w *bytes.Buffer) error {
+	// Each byte is encoded as the code point with the same value, as in ISO-8859-1
+	runes := make([]rune, len(r))
+	for i, b := range r {
+		runes[i] = rune(b)
+	}
+	return writeJSONString(string(runes), w)
+}
+
+func writeJSONHandshakeMatch(r HandshakeMatch, w *bytes.Buffer) error {
+	switch r {
+	case BOTH:
+		return writeJSONString("BOTH", w)
+	case CLIENT:
+		return writeJSONString("CLIENT", w)
+	case NONE:
+		return writeJSONString("NONE", w)
+
+	}
+	return fmt.Errorf("Invalid value %v for HandshakeMatch", int32(r))
+}
+
+func writeJSONHandshakeRequest(r *HandshakeRequest, w *bytes.Buffer) error {
+	if r == nil {
+		return fmt.Errorf("Nil value for record org.apache.avro.ipc.HandshakeRequest")
+	}
+	w.WriteString("{")
+	w.WriteString("\"clientHash\":")
+	if err := writeJSONMD5(r.ClientHash, w); err != nil {
+		return err
+	}
+	w.WriteString(",\"clientProtocol\":")
+	if err := writeJSONUnionNullString(r.ClientProtocol, w); err != nil {
+		return err
+	}
+	w.WriteString(",\"serverHash\":")
+	if err := writeJSONMD5(r.ServerHash, w); err != nil {
+		return err
+	}
+	w.WriteString(",\"meta\":")
+	if err := writeJSONUnionNullMapBytes(r.Meta, w); err != nil {
+		return err
+	}
+
+	w.WriteString("}")
+	return nil
+}
+
+func writeJSONHandshakeResponse(r *HandshakeResponse, w *bytes.Buffer) error {
+	if r == nil {
+		return fmt.Errorf("Nil value for record org.apache.avro.ipc.HandshakeResponse")
+	}
+	w.WriteString("{")
+	w.WriteString("\"match\":")
+	if err := writeJSONHandshakeMatch(r.Match, w); err != nil {
+		return err
+	}
+	w.WriteString(",\"serverProtocol\":")
+	if err := writeJSONUnionNullString(r.ServerProtocol, w); err != nil {
+		return err
+	}
+	w.WriteString(",\"serverHash\":")
+	if err := writeJSONUnionNullMD5(r.ServerHash, w); err != nil {
+		return err
+	}
+	w.WriteString(",\"meta\":")
+	if err := writeJSONUnionNullMapBytes(r.Meta, w); err != nil {
+		return err
+	}
+
+	w.WriteString("}")
+	return nil
+}
+
+func writeJSONMD5(r MD5, w *bytes.Buffer) error {
+	return writeJSONBytes(r[:], w)
+}
+
+func writeJSONMapBytes(r map[string][]byte, w *bytes.Buffer) error {
+	// Keys are sorted so the output is deterministic
+	keys := make([]string, 0, len(r))
+	for k := range r {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w.WriteString("{")
+	for i, k := range keys {
+		if i > 0 {
+			w.WriteString(",")
+		}
+		if err := writeJSONString(k, w); err != nil {
+			return err
+		}
+		w.WriteString(":")
+		if err := writeJSONBytes(r[k], w); err != nil {
+			return err
+		}
+	}
+	w.WriteString("}")
+	return nil
+}
+
+func writeJSONNull(_ interface{}, w *bytes.Buffer) error {
+	w.WriteString("null")
+	return nil
+}
+
+func writeJSONString(r string, w *bytes.Buffer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(r); err != nil {
+		return err
+	}
+	// Encode always appends a newline
+	w.Truncate(w.Len() - 1)
+	return nil
+}
+
+func writeJSONUnionNullMD5(r UnionNullMD5, w *bytes.Buffer) error {
+	switch r.UnionType {
+	case UnionNullMD5TypeEnumNull:
+		w.WriteString("null")
+		return nil
+	case UnionNullMD5TypeEnumMD5:
+		w.WriteString("{\"org.apache.avro.ipc.MD5\":")
+		if err := writeJSONMD5(r.MD5, w); err != nil {
+			return err
+		}
+		w.WriteString("}")
+		return nil
+
+	}
+	return fmt.Errorf("Invalid value for UnionNullMD5")
+}
+
+func writeJSONUnionNullMapBytes(r UnionNullMapBytes, w *bytes.Buffer) error {
+	switch r.UnionType {
+	case UnionNullMapBytesTypeEnumNull:
+		w.WriteString("null")
+		return nil
+	case UnionNullMapBytesTypeEnumMapBytes:
+		w.WriteString("{\"map\":")
+		if err := writeJSONMapBytes(r.MapBytes, w); err != nil {
+			return err
+		}
+		w.WriteString("}")
+		return nil
+
+	}
+	return fmt.Errorf("Invalid value for UnionNullMapBytes")
+}
+
+func writeJSONUnionNullString(r UnionNullString, w *bytes.Buffer) error {
+	switch r.UnionType {
+	case UnionNullStringTypeEnumNull:
+		w.WriteString("null")
+		return nil
+	case UnionNullStringTypeEnumString:
+		w.WriteString("{\"string\":")
+		if err := writeJSONString(r.String, w); err != nil {
+			return err
+		}
+		w.WriteString("}")
+		return nil
+
+	}
+	return fmt.Errorf("Invalid value for UnionNullString")
+}
+
+func writeLong(r int64, w io.Writer) error {
+	downShift := uint64(63)
+	encoded := uint64((r << 1) ^ (r >> downShift))
+	const maxByteSize = 10
+	return encodeInt(w, maxByteSize, encoded)
+}
+
+func writeMD5(r MD5, w io.Writer) error {
+	_, err := w.Write(r[:])
+	return err
+}
+
+func writeMapBytes(r map[string][]byte, w io.Writer) error {
+	err := writeLong(int64(len(r)), w)
+	if err != nil || len(r) == 0 {
+		return err
+	}
+	for k, e := range r {
+		err = writeString(k, w)
+		if err != nil {
+			return err
+		}
+		err = writeBytes(e, w)
+		if err != nil {
+			return err
+		}
+	}
+	return writeLong(0, w)
+}
+
+func writeNull(_ interface{}, _ io.Writer) error {
+	return nil
+}
+
+func writeString(r string, w io.Writer) error {
+	err := writeLong(int64(len(r)), w)
+	if err != nil {
+		return err
+	}
+	if sw, ok := w.(StringWriter); ok {
+		_, err = sw.WriteString(r)
+	} else {
+		_, err = w.Write([]byte(r))
+	}
+	return err
+}
+
+func writeUnionNullMD5(r UnionNullMD5, w io.Writer) error {
+	err := writeLong(int64(r.UnionType), w)
+	if err != nil {
+		return err
+	}
+	switch r.UnionType {
+	case UnionNullMD5TypeEnumNull:
+		return writeNull(r.Null, w)
+	case UnionNullMD5TypeEnumMD5:
+		return writeMD5(r.MD5, w)
+
+	}
+	return fmt.Errorf("Invalid value for UnionNullMD5")
+}
+
+func writeUnionNullMapBytes(r UnionNullMapBytes, w io.Writer) error {
+	err := writeLong(int64(r.UnionType), w)
+	if err != nil {
+		return err
+	}
+	switch r.UnionType {
+	case UnionNullMapBytesTypeEnumNull:
+		return writeNull(r.Null, w)
+	case UnionNullMapBytesTypeEnumMapBytes:
+		return writeMapBytes(r.MapBytes, w)
+
+	}
+	return fmt.Errorf("Invalid value for UnionNullMapBytes")
+}
+
+func writeUnionNullString(r UnionNullString, w io.Writer) error {
+	err := writeLong(int64(r.UnionType), w)
+	if err != nil {
+		return err
+	}
+	switch r.UnionType {
+	case UnionNullStringTypeEnumNull:
+		return writeNull(r.Null, w)
+	case UnionNullStringTypeEnumString:
+		return writeString(r.String, w)
+
+	}
+	return fmt.Errorf("Invalid value for UnionNullString")
+}
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Grow does nothing: the writers ask for the largest size of a value, which would reallocate a slice that has exactly enough space
+func (w *sliceWriter) Grow(n int) {}
+
+func (w *sliceWriter) Write(p []byte) (int, error) {
+	*w = append(*w, p...)
+	return len(p), nil
+}
+
+func (w *sliceWriter) WriteByte(b byte) error {
+	*w = append(*w, b)
+	return nil
+}
+
+func (w *sliceWriter) WriteString(s string) (int, error) {
+	*w = append(*w, s...)
+	return len(s), nil
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+func (e *LengthError) Error() string {
+	if e.Length < 0 {
+		return fmt.Sprintf("Invalid negative %v length %v", e.Type, e.Length)
+	}
+	return fmt.Sprintf("%v length %v exceeds the limit of %v", e.Type, e.Length, e.Limit)
+}
+
+func (e *DepthError) Error() string {
+	return fmt.Sprintf("Nesting exceeds the limit of %v", e.Limit)
+}
+
+// Offset returns the number of bytes read from the stream
+func (d *Decoder) Offset() int64 {
+	return d.offset
+}
+
+func (d *Decoder) Read(p []byte) (int, error) {
+	if d.r == nil {
+		if len(p) > 0 && d.offset >= int64(len(d.data)) {
+			return 0, io.EOF
+		}
+		n := copy(p, d.data[d.offset:])
+		d.offset += int64(n)
+		return n, nil
+	}
+	n, err := d.r.Read(p)
+	d.offset += int64(n)
+	return n, err
+}
+
+func (d *Decoder) ReadByte() (byte, error) {
+	if d.r == nil {
+		if d.offset >= int64(len(d.data)) {
+			return 0, io.EOF
+		}
+		d.offset++
+		return d.data[d.offset-1], nil
+	}
+	if d.br == nil {
+		_, err := io.ReadFull(d, d.buf[:1])
+		return d.buf[0], err
+	}
+	b, err := d.br.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	d.offset++
+	return b, nil
+}
+
+// bytes reads a bytes value of length n, which is only a slice of the input if the Decoder aliases it
+func (d *Decoder) bytes(n int) ([]byte, error) {
+	if d.r == nil {
+		// Check that the data holds the whole value before copying it
+		b, err := d.slice(n)
+		if err != nil || d.alias {
+			return b, err
+		}
+		bb := make([]byte, n)
+		copy(bb, b)
+		return bb, nil
+	}
+	if n > maxDecoderScratch {
+		return d.readLarge(n)
+	}
+	bb := make([]byte, n)
+	if _, err := io.ReadFull(d, bb); err != nil {
+		return nil, err
+	}
+	return bb, nil
+}
+
+// next reads n bytes into the Decoder's scratch space. They're only valid until the next read.
+func (d *Decoder) next(n int) ([]byte, error) {
+	if d.r == nil {
+		return d.slice(n)
+	}
+	var buf []byte
+	switch {
+	case n <= len(d.buf):
+		buf = d.buf[:n]
+	case n <= cap(d.scratch):
+		buf = d.scratch[:n]
+	case n <= maxDecoderScratch:
+		d.scratch = make([]byte, n)
+		buf = d.scratch
+	default:
+		// Don't hold on to the space for the largest value in the stream
+		return d.readLarge(n)
+	}
+	if _, err := io.ReadFull(d, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// readLarge reads a value too large for the scratch space in chunks, so that a corrupt length can't make the
+// Decoder allocate much more than the input holds
+func (d *Decoder) readLarge(n int) ([]byte, error) {
+	var buf bytes.Buffer
+	copied, err := io.CopyN(&buf, d, int64(n))
+	if err == io.EOF && copied > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// skip discards the next n bytes, without allocating for them
+func (d *Decoder) skip(n int64) error {
+	if d.r == nil {
+		_, err := d.slice(int(n))
+		return err
+	}
+	discarder, _ := d.r.(interface {
+		Discard(int) (int, error)
+	})
+	for skipped := int64(0); skipped < n; {
+		chunk := n - skipped
+		if chunk > maxDecoderScratch {
+			chunk = maxDecoderScratch
+		}
+		var err error
+		if discarder != nil {
+			var discarded int
+			discarded, err = discarder.Discard(int(chunk))
+			d.offset += int64(discarded)
+			if err == io.EOF && skipped+int64(discarded) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+		} else {
+			_, err = d.next(int(chunk))
+			if err == io.EOF && skipped > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+		}
+		if err != nil {
+			return err
+		}
+		skipped += chunk
+	}
+	return nil
+}
+
+// slice returns the next n bytes of the Decoder's data without copying them
+func (d *Decoder) slice(n int) ([]byte, error) {
+	remaining := int64(len(d.data)) - d.offset
+	if int64(n) > remaining {
+		d.offset = int64(len(d.data))
+		if remaining == 0 {
+			return nil, io.EOF
+		}
+		return nil, io.ErrUnexpectedEOF
+	}
+	start := d.offset
+	d.offset += int64(n)
+	// Cap the slice, so appending to a value can't overwrite the rest of the data
+	return d.data[start:d.offset:d.offset], nil
+}
+
+func (e *DecodeError) Error() string {
+	if e.Offset < 0 {
+		return fmt.Sprintf("Error reading %v at %v: %v", e.Type, e.Path, e.Err)
+	}
+	return fmt.Sprintf("Error reading %v at %v (offset %v): %v", e.Type, e.Path, e.Offset, e.Err)
+}
+
+// Unwrap returns the underlying error, like io.ErrUnexpectedEOF or a *LengthError
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
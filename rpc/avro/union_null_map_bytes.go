@@ -0,0 +1,87 @@
+/*
+ * CODE GENERATED AUTOMATICALLY WITH github.com/alanctgardner/gogen-avro
+ * THIS FILE SHOULD NOT BE EDITED BY HAND
+ */
+
+package avro
+
+import (
+	"fmt"
+)
+
+type UnionNullMapBytes struct {
+	Null      interface{}
+	MapBytes  map[string][]byte
+	UnionType UnionNullMapBytesTypeEnum
+}
+
+type UnionNullMapBytesTypeEnum int
+
+const (
+	UnionNullMapBytesTypeEnumNull     UnionNullMapBytesTypeEnum = 0
+	UnionNullMapBytesTypeEnumMapBytes UnionNullMapBytesTypeEnum = 1
+)
+
+// NewUnionNullMapBytes returns a UnionNullMapBytes holding the MapBytes branch
+func NewUnionNullMapBytes(v map[string][]byte) UnionNullMapBytes {
+	return UnionNullMapBytes{MapBytes: v, UnionType: UnionNullMapBytesTypeEnumMapBytes}
+}
+
+// NewUnionNullMapBytesMapBytes returns a UnionNullMapBytes holding the MapBytes branch
+func NewUnionNullMapBytesMapBytes(v map[string][]byte) UnionNullMapBytes {
+	return UnionNullMapBytes{MapBytes: v, UnionType: UnionNullMapBytesTypeEnumMapBytes}
+}
+
+// NewUnionNullMapBytesNull returns a UnionNullMapBytes holding null
+func NewUnionNullMapBytesNull() UnionNullMapBytes {
+	return UnionNullMapBytes{UnionType: UnionNullMapBytesTypeEnumNull}
+}
+
+// Clone returns a deep copy of the union, which shares no slices, maps or records with it
+func (u UnionNullMapBytes) Clone() UnionNullMapBytes {
+	return cloneUnionNullMapBytes(u)
+}
+
+// Diff returns the values which differ between the union and other, with their paths inside the union's value
+func (u UnionNullMapBytes) Diff(other UnionNullMapBytes) []FieldDiff {
+	return diffUnionNullMapBytes(u, other, "", nil)
+}
+
+// Equals returns whether other holds the same branch as the union, with an equal value
+func (u UnionNullMapBytes) Equals(other UnionNullMapBytes) bool {
+	return equalUnionNullMapBytes(u, other)
+}
+
+// IsMapBytes returns whether the union holds the MapBytes branch
+func (u UnionNullMapBytes) IsMapBytes() bool {
+	return u.UnionType == UnionNullMapBytesTypeEnumMapBytes
+}
+
+// IsNull returns whether the union holds the Null branch
+func (u UnionNullMapBytes) IsNull() bool {
+	return u.UnionType == UnionNullMapBytesTypeEnumNull
+}
+
+// Value returns the value of the branch held by the union, or nil if it holds null or an invalid branch
+func (u UnionNullMapBytes) Value() interface{} {
+	switch u.UnionType {
+	case UnionNullMapBytesTypeEnumMapBytes:
+		return u.MapBytes
+
+	}
+	return nil
+}
+
+// Set stores v in the union, selecting the branch by its Go type
+func (u *UnionNullMapBytes) Set(v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		*u = UnionNullMapBytes{UnionType: UnionNullMapBytesTypeEnumNull}
+		return nil
+	case map[string][]byte:
+		*u = UnionNullMapBytes{MapBytes: v, UnionType: UnionNullMapBytesTypeEnumMapBytes}
+		return nil
+
+	}
+	return fmt.Errorf("Invalid type %T for UnionNullMapBytes", v)
+}
@@ -0,0 +1,87 @@
+/*
+ * CODE GENERATED AUTOMATICALLY WITH github.com/alanctgardner/gogen-avro
+ * THIS FILE SHOULD NOT BE EDITED BY HAND
+ */
+
+package avro
+
+import (
+	"fmt"
+)
+
+type UnionNullMD5 struct {
+	Null      interface{}
+	MD5       MD5
+	UnionType UnionNullMD5TypeEnum
+}
+
+type UnionNullMD5TypeEnum int
+
+const (
+	UnionNullMD5TypeEnumNull UnionNullMD5TypeEnum = 0
+	UnionNullMD5TypeEnumMD5  UnionNullMD5TypeEnum = 1
+)
+
+// NewUnionNullMD5 returns a UnionNullMD5 holding the MD5 branch
+func NewUnionNullMD5(v MD5) UnionNullMD5 {
+	return UnionNullMD5{MD5: v, UnionType: UnionNullMD5TypeEnumMD5}
+}
+
+// NewUnionNullMD5MD5 returns a UnionNullMD5 holding the MD5 branch
+func NewUnionNullMD5MD5(v MD5) UnionNullMD5 {
+	return UnionNullMD5{MD5: v, UnionType: UnionNullMD5TypeEnumMD5}
+}
+
+// NewUnionNullMD5Null returns a UnionNullMD5 holding null
+func NewUnionNullMD5Null() UnionNullMD5 {
+	return UnionNullMD5{UnionType: UnionNullMD5TypeEnumNull}
+}
+
+// Clone returns a deep copy of the union, which shares no slices, maps or records with it
+func (u UnionNullMD5) Clone() UnionNullMD5 {
+	return u
+}
+
+// Diff returns the values which differ between the union and other, with their paths inside the union's value
+func (u UnionNullMD5) Diff(other UnionNullMD5) []FieldDiff {
+	return diffUnionNullMD5(u, other, "", nil)
+}
+
+// Equals returns whether other holds the same branch as the union, with an equal value
+func (u UnionNullMD5) Equals(other UnionNullMD5) bool {
+	return equalUnionNullMD5(u, other)
+}
+
+// IsMD5 returns whether the union holds the MD5 branch
+func (u UnionNullMD5) IsMD5() bool {
+	return u.UnionType == UnionNullMD5TypeEnumMD5
+}
+
+// IsNull returns whether the union holds the Null branch
+func (u UnionNullMD5) IsNull() bool {
+	return u.UnionType == UnionNullMD5TypeEnumNull
+}
+
+// Value returns the value of the branch held by the union, or nil if it holds null or an invalid branch
+func (u UnionNullMD5) Value() interface{} {
+	switch u.UnionType {
+	case UnionNullMD5TypeEnumMD5:
+		return u.MD5
+
+	}
+	return nil
+}
+
+// Set stores v in the union, selecting the branch by its Go type
+func (u *UnionNullMD5) Set(v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		*u = UnionNullMD5{UnionType: UnionNullMD5TypeEnumNull}
+		return nil
+	case MD5:
+		*u = UnionNullMD5{MD5: v, UnionType: UnionNullMD5TypeEnumMD5}
+		return nil
+
+	}
+	return fmt.Errorf("Invalid type %T for UnionNullMD5", v)
+}
@@ -0,0 +1,87 @@
+/*
+ * CODE GENERATED AUTOMATICALLY WITH github.com/alanctgardner/gogen-avro
+ * THIS FILE SHOULD NOT BE EDITED BY HAND
+ */
+
+package avro
+
+import (
+	"fmt"
+)
+
+type UnionNullString struct {
+	Null      interface{}
+	String    string
+	UnionType UnionNullStringTypeEnum
+}
+
+type UnionNullStringTypeEnum int
+
+const (
+	UnionNullStringTypeEnumNull   UnionNullStringTypeEnum = 0
+	UnionNullStringTypeEnumString UnionNullStringTypeEnum = 1
+)
+
+// NewUnionNullString returns a UnionNullString holding the String branch
+func NewUnionNullString(v string) UnionNullString {
+	return UnionNullString{String: v, UnionType: UnionNullStringTypeEnumString}
+}
+
+// NewUnionNullStringNull returns a UnionNullString holding null
+func NewUnionNullStringNull() UnionNullString {
+	return UnionNullString{UnionType: UnionNullStringTypeEnumNull}
+}
+
+// NewUnionNullStringString returns a UnionNullString holding the String branch
+func NewUnionNullStringString(v string) UnionNullString {
+	return UnionNullString{String: v, UnionType: UnionNullStringTypeEnumString}
+}
+
+// Clone returns a deep copy of the union, which shares no slices, maps or records with it
+func (u UnionNullString) Clone() UnionNullString {
+	return u
+}
+
+// Diff returns the values which differ between the union and other, with their paths inside the union's value
+func (u UnionNullString) Diff(other UnionNullString) []FieldDiff {
+	return diffUnionNullString(u, other, "", nil)
+}
+
+// Equals returns whether other holds the same branch as the union, with an equal value
+func (u UnionNullString) Equals(other UnionNullString) bool {
+	return equalUnionNullString(u, other)
+}
+
+// IsNull returns whether the union holds the Null branch
+func (u UnionNullString) IsNull() bool {
+	return u.UnionType == UnionNullStringTypeEnumNull
+}
+
+// IsString returns whether the union holds the String branch
+func (u UnionNullString) IsString() bool {
+	return u.UnionType == UnionNullStringTypeEnumString
+}
+
+// Value returns the value of the branch held by the union, or nil if it holds null or an invalid branch
+func (u UnionNullString) Value() interface{} {
+	switch u.UnionType {
+	case UnionNullStringTypeEnumString:
+		return u.String
+
+	}
+	return nil
+}
+
+// Set stores v in the union, selecting the branch by its Go type
+func (u *UnionNullString) Set(v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		*u = UnionNullString{UnionType: UnionNullStringTypeEnumNull}
+		return nil
+	case string:
+		*u = UnionNullString{String: v, UnionType: UnionNullStringTypeEnumString}
+		return nil
+
+	}
+	return fmt.Errorf("Invalid type %T for UnionNullString", v)
+}
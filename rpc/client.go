@@ -0,0 +1,187 @@
+package rpc
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/alanctgardner/gogen-avro/rpc/avro"
+)
+
+/*
+  Serializable is implemented by the generated request record of every message.
+*/
+type Serializable interface {
+	Serialize(w io.Writer) error
+}
+
+/*
+  Transceiver sends an unframed request to a server and returns the unframed response.
+*/
+type Transceiver interface {
+	Transceive(request []byte) ([]byte, error)
+}
+
+/*
+  HTTPTransceiver sends each request in the body of an HTTP POST.
+*/
+type HTTPTransceiver struct {
+	url        string
+	httpClient *http.Client
+
+	// The size in bytes of the longest response the transceiver reads. A limit of 0 disables the check.
+	MaxResponseBytes int64
+}
+
+/*
+  Create a new HTTPTransceiver for the server at the given URL, using http.DefaultClient.
+*/
+func NewHTTPTransceiver(url string) *HTTPTransceiver {
+	return NewHTTPTransceiverWithClient(url, http.DefaultClient)
+}
+
+/*
+  Create a new HTTPTransceiver for the server at the given URL, sending requests with the given http.Client.
+*/
+func NewHTTPTransceiverWithClient(url string, httpClient *http.Client) *HTTPTransceiver {
+	return &HTTPTransceiver{url: url, httpClient: httpClient, MaxResponseBytes: DefaultMaxMessageBytes}
+}
+
+func (t *HTTPTransceiver) Transceive(request []byte) ([]byte, error) {
+	var body bytes.Buffer
+	if err := writeFrames(&body, request); err != nil {
+		return nil, err
+	}
+	resp, err := t.httpClient.Post(t.url, ContentType, &body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return readFrames(resp.Body, t.MaxResponseBytes)
+}
+
+/*
+  Client calls the messages of a protocol on a remote server. Every call includes a handshake,
+  so the Client can be used with stateless transports like HTTP. The client only sends its full
+  protocol definition when the server doesn't recognize the hash of the protocol.
+*/
+type Client struct {
+	protocol    *Protocol
+	transceiver Transceiver
+
+	lock         sync.Mutex
+	serverHash   [16]byte
+	sendProtocol bool
+}
+
+/*
+  Create a new Client for the protocol with the given JSON definition.
+*/
+func NewClient(protocolJson string, transceiver Transceiver) (*Client, error) {
+	protocol, err := NewProtocol(protocolJson)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		protocol:    protocol,
+		transceiver: transceiver,
+		serverHash:  protocol.Hash(),
+	}, nil
+}
+
+func (c *Client) Protocol() *Protocol {
+	return c.protocol
+}
+
+/*
+  Call the message with the given request record. If the call succeeds, readResponse is called to decode
+  the response. If the server returns an error, readError is called to decode the message's error union,
+  and the error it returns is returned by Call. Both are ignored for one-way messages.
+*/
+func (c *Client) Call(message string, request Serializable, readResponse func(io.Reader) error, readError func(io.Reader) error) error {
+	oneWay, ok := c.protocol.Message(message)
+	if !ok {
+		return &UnknownMessageError{Message: message}
+	}
+
+	var call bytes.Buffer
+	if err := writeMetadata(nil, &call); err != nil {
+		return err
+	}
+	if err := writeString(message, &call); err != nil {
+		return err
+	}
+	if err := request.Serialize(&call); err != nil {
+		return err
+	}
+
+	// If the server doesn't know our protocol, it responds with NONE and we retry with the protocol definition
+	for attempt := 0; attempt < 2; attempt++ {
+		r, match, err := c.transceive(call.Bytes())
+		if err != nil {
+			return err
+		}
+		if match == avro.NONE {
+			continue
+		}
+		if oneWay {
+			return nil
+		}
+		return c.readCallResponse(r, readResponse, readError)
+	}
+	return &HandshakeError{Match: avro.NONE.String()}
+}
+
+func (c *Client) transceive(call []byte) (*bytes.Reader, avro.HandshakeMatch, error) {
+	c.lock.Lock()
+	handshake := &avro.HandshakeRequest{
+		ClientHash: avro.MD5(c.protocol.Hash()),
+		ServerHash: avro.MD5(c.serverHash),
+	}
+	if c.sendProtocol {
+		handshake.ClientProtocol = avro.UnionNullString{String: c.protocol.JSON(), UnionType: avro.UnionNullStringTypeEnumString}
+	}
+	c.lock.Unlock()
+
+	var request bytes.Buffer
+	if err := handshake.Serialize(&request); err != nil {
+		return nil, 0, err
+	}
+	request.Write(call)
+
+	response, err := c.transceiver.Transceive(request.Bytes())
+	if err != nil {
+		return nil, 0, err
+	}
+	r := bytes.NewReader(response)
+	handshakeResponse, err := avro.DeserializeHandshakeResponse(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if handshakeResponse.ServerHash.UnionType == avro.UnionNullMD5TypeEnumMD5 {
+		c.serverHash = [16]byte(handshakeResponse.ServerHash.MD5)
+	}
+	c.sendProtocol = handshakeResponse.Match == avro.NONE
+	return r, handshakeResponse.Match, nil
+}
+
+func (c *Client) readCallResponse(r *bytes.Reader, readResponse func(io.Reader) error, readError func(io.Reader) error) error {
+	if _, err := readMetadata(r); err != nil {
+		return err
+	}
+	isError, err := readBool(r)
+	if err != nil {
+		return err
+	}
+	if isError {
+		return readError(r)
+	}
+	return readResponse(r)
+}
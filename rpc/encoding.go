@@ -0,0 +1,160 @@
+package rpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+/*
+  Binary encoders for the parts of the call framing which aren't generated records:
+  the call metadata map, the message name, the error flag and string errors.
+*/
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+func writeLong(value int64, w io.Writer) error {
+	encoded := uint64((value << 1) ^ (value >> 63))
+	var buf [10]byte
+	n := 0
+	for encoded >= 0x80 {
+		buf[n] = byte(encoded) | 0x80
+		encoded >>= 7
+		n++
+	}
+	buf[n] = byte(encoded)
+	_, err := w.Write(buf[:n+1])
+	return err
+}
+
+func readLong(r byteReader) (int64, error) {
+	var encoded uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		encoded |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return int64(encoded>>1) ^ -int64(encoded&1), nil
+		}
+	}
+	return 0, fmt.Errorf("Invalid variable-length integer")
+}
+
+func writeBool(value bool, w io.Writer) error {
+	b := []byte{0}
+	if value {
+		b[0] = 1
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+func readBool(r byteReader) (bool, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return false, err
+	}
+	return b == 1, nil
+}
+
+func writeBytes(value []byte, w io.Writer) error {
+	if err := writeLong(int64(len(value)), w); err != nil {
+		return err
+	}
+	_, err := w.Write(value)
+	return err
+}
+
+func readBytes(r byteReader) ([]byte, error) {
+	size, err := readLong(r)
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("Invalid negative length %v", size)
+	}
+	// Copy the bytes as they're read, rather than allocating the length read from the message up front
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, size); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeString(value string, w io.Writer) error {
+	return writeBytes([]byte(value), w)
+}
+
+func readString(r byteReader) (string, error) {
+	b, err := readBytes(r)
+	return string(b), err
+}
+
+/*
+  Write the call metadata, a map of string to bytes, as a single block.
+*/
+func writeMetadata(meta map[string][]byte, w io.Writer) error {
+	if len(meta) > 0 {
+		if err := writeLong(int64(len(meta)), w); err != nil {
+			return err
+		}
+		for k, v := range meta {
+			if err := writeString(k, w); err != nil {
+				return err
+			}
+			if err := writeBytes(v, w); err != nil {
+				return err
+			}
+		}
+	}
+	return writeLong(0, w)
+}
+
+func readMetadata(r byteReader) (map[string][]byte, error) {
+	meta := make(map[string][]byte)
+	for {
+		count, err := readLong(r)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return meta, nil
+		}
+		if count < 0 {
+			// A negative count is followed by the size of the block in bytes
+			count = -count
+			if _, err := readLong(r); err != nil {
+				return nil, err
+			}
+		}
+		for i := int64(0); i < count; i++ {
+			k, err := readString(r)
+			if err != nil {
+				return nil, err
+			}
+			v, err := readBytes(r)
+			if err != nil {
+				return nil, err
+			}
+			meta[k] = v
+		}
+	}
+}
+
+/*
+  Write a string error, which is always the first branch of a message's error union.
+*/
+func writeStringError(message string, w io.Writer) error {
+	if err := writeLong(0, w); err != nil {
+		return err
+	}
+	return writeString(message, w)
+}
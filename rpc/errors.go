@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	"fmt"
+)
+
+/*
+  Error is a string error returned by the remote end of a call. Errors declared by the
+  message are returned as their generated types instead.
+*/
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+type UnknownMessageError struct {
+	Message string
+}
+
+func (e *UnknownMessageError) Error() string {
+	return fmt.Sprintf("Unknown message %q", e.Message)
+}
+
+/*
+  HandshakeError is returned by a Client when the server doesn't accept its protocol,
+  even after the client has sent the full protocol definition.
+*/
+type HandshakeError struct {
+	Match string
+}
+
+func (e *HandshakeError) Error() string {
+	return fmt.Sprintf("Handshake failed, server responded with match %v", e.Match)
+}
+
+/*
+  MessageSizeError is returned when a request or response is longer than the reader's limit.
+*/
+type MessageSizeError struct {
+	Limit int64
+}
+
+func (e *MessageSizeError) Error() string {
+	return fmt.Sprintf("Avro RPC message is longer than the limit of %v bytes", e.Limit)
+}
+
+type HTTPError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("RPC server returned status %v", e.Status)
+}
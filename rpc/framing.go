@@ -0,0 +1,72 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// The content type of Avro RPC requests and responses over HTTP
+const ContentType = "avro/binary"
+
+// The largest buffer written by writeFrames. Readers accept buffers of any size, up to the size of the message.
+const maxFrameSize = 8192
+
+// The default limit on the size of a request read by a Server, or a response read by an HTTPTransceiver
+const DefaultMaxMessageBytes = 16 << 20
+
+// The default number of client protocol hashes remembered by a Server
+const DefaultMaxClientProtocols = 1024
+
+/*
+  Write the message as a sequence of buffers, each prefixed with its 4-byte big-endian
+  length, followed by a zero-length buffer which terminates the message.
+*/
+func writeFrames(w io.Writer, message []byte) error {
+	var length [4]byte
+	for len(message) > 0 {
+		size := len(message)
+		if size > maxFrameSize {
+			size = maxFrameSize
+		}
+		binary.BigEndian.PutUint32(length[:], uint32(size))
+		if _, err := w.Write(length[:]); err != nil {
+			return err
+		}
+		if _, err := w.Write(message[:size]); err != nil {
+			return err
+		}
+		message = message[size:]
+	}
+	binary.BigEndian.PutUint32(length[:], 0)
+	_, err := w.Write(length[:])
+	return err
+}
+
+/*
+  Read a sequence of buffers up to the terminating zero-length buffer, and return their concatenation.
+  Returns a *MessageSizeError if the message is longer than limit bytes. A limit of 0 disables the check.
+*/
+func readFrames(r io.Reader, limit int64) ([]byte, error) {
+	var message bytes.Buffer
+	var length [4]byte
+	for {
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			return nil, err
+		}
+		size := int64(binary.BigEndian.Uint32(length[:]))
+		if size == 0 {
+			return message.Bytes(), nil
+		}
+		if limit > 0 && int64(message.Len())+size > limit {
+			return nil, &MessageSizeError{Limit: limit}
+		}
+		// The buffer is copied as it arrives, so a false length can't make us allocate more than was sent
+		if _, err := io.CopyN(&message, r, size); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+}
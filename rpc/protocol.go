@@ -0,0 +1,74 @@
+// Package rpc implements Avro RPC over HTTP: the handshake which exchanges protocol hashes,
+// the framing of calls and responses, and a client and server http.Handler for a protocol.
+// Generated protocol interfaces can be bound to a Client or Server with the New<Protocol>Client
+// and New<Protocol>Server functions gogen-avro generates for each .avpr file.
+package rpc
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+)
+
+/*
+  Protocol is the JSON definition of an Avro protocol, along with its MD5 hash and the
+  messages it declares.
+
+  The hash is the MD5 of the JSON as it's given, which for generated protocols is the .avpr file
+  with the whitespace removed. The Java implementation hashes its own rendering of the protocol,
+  so the hashes of the same protocol differ between them. A handshake with a Java peer never
+  reaches a BOTH match, so the server sends its full protocol back with every response.
+*/
+type Protocol struct {
+	json     string
+	hash     [16]byte
+	messages map[string]bool
+}
+
+/*
+  Parse the JSON definition of a protocol, as returned by the generated <Protocol>Protocol() function.
+*/
+func NewProtocol(protocolJson string) (*Protocol, error) {
+	var definition struct {
+		Protocol string `json:"protocol"`
+		Messages map[string]struct {
+			OneWay bool `json:"one-way"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(protocolJson), &definition); err != nil {
+		return nil, err
+	}
+	if definition.Protocol == "" {
+		return nil, fmt.Errorf("Protocol definition has no protocol name")
+	}
+
+	messages := make(map[string]bool)
+	for name, m := range definition.Messages {
+		messages[name] = m.OneWay
+	}
+	return &Protocol{
+		json:     protocolJson,
+		hash:     md5.Sum([]byte(protocolJson)),
+		messages: messages,
+	}, nil
+}
+
+func (p *Protocol) JSON() string {
+	return p.json
+}
+
+/*
+  The MD5 hash of the protocol JSON, which identifies the protocol during the handshake.
+  It isn't compatible with the hash computed by the Java implementation.
+*/
+func (p *Protocol) Hash() [16]byte {
+	return p.hash
+}
+
+/*
+  Whether the protocol declares the message, and whether it's one-way.
+*/
+func (p *Protocol) Message(name string) (oneWay bool, ok bool) {
+	oneWay, ok = p.messages[name]
+	return oneWay, ok
+}
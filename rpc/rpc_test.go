@@ -0,0 +1,247 @@
+package rpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/rpc/avro"
+	"github.com/stretchr/testify/assert"
+)
+
+const echoProtocol = `{"protocol":"Echo","namespace":"com.example","messages":{"echo":{"request":[{"name":"message","type":"string"}],"response":"string"},"fail":{"request":[],"response":"null"},"log":{"request":[{"name":"message","type":"string"}],"response":"null","one-way":true}}}`
+
+// The same messages as echoProtocol, but a different hash
+const echoClientProtocol = `{"protocol":"Echo","namespace":"com.example","doc":"Client copy","messages":{"echo":{"request":[{"name":"message","type":"string"}],"response":"string"},"fail":{"request":[],"response":"null"},"log":{"request":[{"name":"message","type":"string"}],"response":"null","one-way":true}}}`
+
+type stringRequest string
+
+func (s stringRequest) Serialize(w io.Writer) error {
+	return writeString(string(s), w)
+}
+
+func readStringError(r io.Reader) error {
+	br := r.(byteReader)
+	branch, err := readLong(br)
+	if err != nil {
+		return err
+	}
+	if branch != 0 {
+		return fmt.Errorf("Unexpected error branch %v", branch)
+	}
+	message, err := readString(br)
+	if err != nil {
+		return err
+	}
+	return &Error{Message: message}
+}
+
+func newEchoServer(t *testing.T, logged *[]string) *Server {
+	server, err := NewServer(echoProtocol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, server.Handle("echo", func(r io.Reader, w io.Writer) (bool, error) {
+		message, err := readString(r.(byteReader))
+		if err != nil {
+			return false, err
+		}
+		return false, writeString(message, w)
+	}))
+	assert.Nil(t, server.Handle("fail", func(r io.Reader, w io.Writer) (bool, error) {
+		return false, fmt.Errorf("failed on purpose")
+	}))
+	assert.Nil(t, server.Handle("log", func(r io.Reader, w io.Writer) (bool, error) {
+		message, err := readString(r.(byteReader))
+		*logged = append(*logged, message)
+		return false, err
+	}))
+	return server
+}
+
+/* A Transceiver which calls the Server directly, and records the handshake match of each response */
+type recordingTransceiver struct {
+	server  *Server
+	matches []string
+}
+
+func (t *recordingTransceiver) Transceive(request []byte) ([]byte, error) {
+	response, err := t.server.Respond(request)
+	if err == nil {
+		// The match is the first field of the handshake response
+		t.matches = append(t.matches, []string{"BOTH", "CLIENT", "NONE"}[response[0]/2])
+	}
+	return response, err
+}
+
+func echo(client *Client, message string) (string, error) {
+	var response string
+	err := client.Call("echo", stringRequest(message), func(r io.Reader) error {
+		var err error
+		response, err = readString(r.(byteReader))
+		return err
+	}, readStringError)
+	return response, err
+}
+
+func TestFramesRoundTrip(t *testing.T) {
+	message := bytes.Repeat([]byte("avro"), maxFrameSize)
+	var buf bytes.Buffer
+	if err := writeFrames(&buf, message); err != nil {
+		t.Fatal(err)
+	}
+	// Four full buffers, each with a length prefix, and the terminating empty buffer
+	assert.Equal(t, len(message)+5*4, buf.Len())
+
+	decoded, err := readFrames(&buf, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, message, decoded)
+}
+
+func TestFramesLimit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeFrames(&buf, bytes.Repeat([]byte("avro"), maxFrameSize)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := readFrames(&buf, 3*maxFrameSize)
+	assert.Equal(t, &MessageSizeError{Limit: 3 * maxFrameSize}, err)
+
+	// A frame which claims to be 4 GiB long fails when the data runs out
+	_, err = readFrames(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 1, 2, 3}), 0)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestServerRejectsLongRequests(t *testing.T) {
+	server, err := NewServer(echoProtocol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.MaxRequestBytes = 1024
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	var body bytes.Buffer
+	if err := writeFrames(&body, make([]byte, 4096)); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(httpServer.URL, ContentType, &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
+
+func TestHTTPCall(t *testing.T) {
+	var logged []string
+	httpServer := httptest.NewServer(newEchoServer(t, &logged))
+	defer httpServer.Close()
+
+	client, err := NewClient(echoProtocol, NewHTTPTransceiver(httpServer.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := echo(client, "hello")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", response)
+
+	err = client.Call("fail", stringRequest(""), nil, readStringError)
+	assert.Equal(t, &Error{Message: "failed on purpose"}, err)
+
+	assert.Nil(t, client.Call("log", stringRequest("one-way"), nil, nil))
+	assert.Equal(t, []string{"one-way"}, logged)
+}
+
+func TestHandshakeSendsProtocolWhenUnknown(t *testing.T) {
+	var logged []string
+	transceiver := &recordingTransceiver{server: newEchoServer(t, &logged)}
+	client, err := NewClient(echoClientProtocol, transceiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := echo(client, "first")
+	assert.Nil(t, err)
+	assert.Equal(t, "first", response)
+	// The server doesn't know the client's hash, so the client resends with its protocol
+	// and the server hash it learned from the first response
+	assert.Equal(t, []string{"NONE", "BOTH"}, transceiver.matches)
+
+	response, err = echo(client, "second")
+	assert.Nil(t, err)
+	assert.Equal(t, "second", response)
+	// The server has cached the client protocol, so it isn't sent again
+	assert.Equal(t, []string{"NONE", "BOTH", "BOTH"}, transceiver.matches)
+}
+
+func TestHandshakeForgetsClientsOverLimit(t *testing.T) {
+	var logged []string
+	server := newEchoServer(t, &logged)
+	server.MaxClientProtocols = 1
+	transceiver := &recordingTransceiver{server: server}
+	first, err := NewClient(echoClientProtocol, transceiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewClient(strings.Replace(echoClientProtocol, "Client copy", "Second client copy", 1), transceiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = echo(first, "first")
+	assert.Nil(t, err)
+	_, err = echo(second, "second")
+	assert.Nil(t, err)
+	// The second client's protocol replaced the first's, so the first client has to send its protocol again
+	_, err = echo(first, "third")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"NONE", "BOTH", "NONE", "BOTH", "NONE", "BOTH"}, transceiver.matches)
+
+	// Clients can't grow the server's memory by sending new protocols
+	server.MaxClientProtocols = 10
+	for i := 0; i < 100; i++ {
+		var request bytes.Buffer
+		handshake := &avro.HandshakeRequest{
+			ClientHash:     avro.MD5{byte(i)},
+			ClientProtocol: avro.UnionNullString{String: echoClientProtocol, UnionType: avro.UnionNullStringTypeEnumString},
+			ServerHash:     avro.MD5(server.Protocol().Hash()),
+		}
+		assert.Nil(t, handshake.Serialize(&request))
+		_, err := server.Respond(request.Bytes())
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 10, len(server.clients))
+}
+
+func TestUnknownMessage(t *testing.T) {
+	var logged []string
+	server := newEchoServer(t, &logged)
+	assert.Equal(t, &UnknownMessageError{Message: "missing"}, server.Handle("missing", nil))
+
+	client, err := NewClient(echoProtocol, &recordingTransceiver{server: server})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Call("missing", stringRequest(""), nil, readStringError)
+	assert.Equal(t, &UnknownMessageError{Message: "missing"}, err)
+}
+
+func TestUnhandledMessage(t *testing.T) {
+	server, err := NewServer(echoProtocol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(echoProtocol, &recordingTransceiver{server: server})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = echo(client, "hello")
+	assert.Equal(t, &Error{Message: `Unknown message "echo"`}, err)
+}
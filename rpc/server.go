@@ -0,0 +1,225 @@
+package rpc
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/alanctgardner/gogen-avro/rpc/avro"
+)
+
+/*
+  HandlerFunc reads the parameters of a message from the request and invokes it. It writes
+  the encoded response to the response writer and returns false, or, if the message fails with
+  one of its declared errors, writes the encoded error union and returns true. Any error returned
+  by the HandlerFunc itself is sent to the client as a string error.
+*/
+type HandlerFunc func(request io.Reader, response io.Writer) (bool, error)
+
+/*
+  Server dispatches calls to the HandlerFunc registered for each message of a protocol.
+  It implements http.Handler, so it can be served directly by net/http.
+
+  The server accepts any client protocol during the handshake. Messages are dispatched by name
+  and their parameters decoded with the server's generated types, so the client and server
+  protocols must agree on the definition of the messages they share. The server remembers the
+  hashes of the client protocols it has been sent, so clients don't have to send them on every call.
+*/
+type Server struct {
+	protocol *Protocol
+
+	// The size in bytes of the longest request body ServeHTTP reads. A limit of 0 disables the check.
+	MaxRequestBytes int64
+	// The number of client protocol hashes the server remembers. Once it's reached, a new hash replaces
+	// an arbitrary one, and clients whose hash was forgotten are asked for their protocol again.
+	// A limit of 0 disables the check.
+	MaxClientProtocols int
+
+	lock     sync.RWMutex
+	handlers map[string]HandlerFunc
+	clients  map[[16]byte]bool
+}
+
+/*
+  Create a new Server for the protocol with the given JSON definition.
+*/
+func NewServer(protocolJson string) (*Server, error) {
+	protocol, err := NewProtocol(protocolJson)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		protocol:           protocol,
+		MaxRequestBytes:    DefaultMaxMessageBytes,
+		MaxClientProtocols: DefaultMaxClientProtocols,
+		handlers:           make(map[string]HandlerFunc),
+		clients:            make(map[[16]byte]bool),
+	}, nil
+}
+
+func (s *Server) Protocol() *Protocol {
+	return s.protocol
+}
+
+/*
+  Register the handler for a message. Returns an *UnknownMessageError if the protocol doesn't declare the message.
+*/
+func (s *Server) Handle(message string, handler HandlerFunc) error {
+	if _, ok := s.protocol.Message(message); !ok {
+		return &UnknownMessageError{Message: message}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.handlers[message] = handler
+	return nil
+}
+
+/*
+  Perform the handshake and the call in an unframed request, and return the unframed response.
+  An error is returned only if the request can't be decoded - errors from the call are encoded in the response.
+*/
+func (s *Server) Respond(request []byte) ([]byte, error) {
+	r := bytes.NewReader(request)
+	handshake, err := avro.DeserializeHandshakeRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var response bytes.Buffer
+	handshakeResponse := s.handshake(handshake)
+	if err := handshakeResponse.Serialize(&response); err != nil {
+		return nil, err
+	}
+	// The client must resend the call with its protocol, or a request with only a handshake is a ping
+	if handshakeResponse.Match == avro.NONE || r.Len() == 0 {
+		return response.Bytes(), nil
+	}
+
+	if _, err := readMetadata(r); err != nil {
+		return nil, err
+	}
+	message, err := readString(r)
+	if err != nil {
+		return nil, err
+	}
+
+	oneWay, ok := s.protocol.Message(message)
+	s.lock.RLock()
+	handler, hasHandler := s.handlers[message]
+	s.lock.RUnlock()
+
+	// One-way messages have no response, so errors from the handler can't be returned to the client
+	if oneWay && hasHandler {
+		handler(r, ioutil.Discard)
+		return response.Bytes(), nil
+	}
+
+	if err := writeMetadata(nil, &response); err != nil {
+		return nil, err
+	}
+	if !ok || !hasHandler {
+		if err := s.writeError(&UnknownMessageError{Message: message}, &response); err != nil {
+			return nil, err
+		}
+		return response.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	isError, err := handler(r, &body)
+	if err != nil {
+		if err := s.writeError(err, &response); err != nil {
+			return nil, err
+		}
+		return response.Bytes(), nil
+	}
+	if err := writeBool(isError, &response); err != nil {
+		return nil, err
+	}
+	response.Write(body.Bytes())
+	return response.Bytes(), nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Avro RPC requests must use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	body := r.Body
+	if s.MaxRequestBytes > 0 {
+		body = http.MaxBytesReader(w, r.Body, s.MaxRequestBytes)
+	}
+	request, err := readFrames(body, s.MaxRequestBytes)
+	if _, tooLong := err.(*MessageSizeError); tooLong {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response, err := s.Respond(request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	writeFrames(w, response)
+}
+
+func (s *Server) handshake(request *avro.HandshakeRequest) *avro.HandshakeResponse {
+	clientHash := [16]byte(request.ClientHash)
+
+	knownClient := true
+	s.lock.Lock()
+	switch {
+	case clientHash == s.protocol.Hash():
+	case request.ClientProtocol.UnionType == avro.UnionNullStringTypeEnumString:
+		s.rememberClient(clientHash)
+	default:
+		knownClient = s.clients[clientHash]
+	}
+	s.lock.Unlock()
+
+	response := &avro.HandshakeResponse{}
+	switch {
+	case !knownClient:
+		response.Match = avro.NONE
+	case [16]byte(request.ServerHash) == s.protocol.Hash():
+		response.Match = avro.BOTH
+		return response
+	default:
+		response.Match = avro.CLIENT
+	}
+	response.ServerProtocol = avro.UnionNullString{String: s.protocol.JSON(), UnionType: avro.UnionNullStringTypeEnumString}
+	response.ServerHash = avro.UnionNullMD5{MD5: avro.MD5(s.protocol.Hash()), UnionType: avro.UnionNullMD5TypeEnumMD5}
+	return response
+}
+
+/*
+  Add a client protocol hash, replacing an arbitrary one if the server already remembers MaxClientProtocols.
+  Must be called with the lock held.
+*/
+func (s *Server) rememberClient(clientHash [16]byte) {
+	if s.clients[clientHash] {
+		return
+	}
+	for hash := range s.clients {
+		if s.MaxClientProtocols <= 0 || len(s.clients) < s.MaxClientProtocols {
+			break
+		}
+		delete(s.clients, hash)
+	}
+	s.clients[clientHash] = true
+}
+
+/*
+  Write the error flag and the error as the string branch of the message's error union.
+*/
+func (s *Server) writeError(err error, w io.Writer) error {
+	if err := writeBool(true, w); err != nil {
+		return err
+	}
+	return writeStringError(err.Error(), w)
+}
@@ -0,0 +1,90 @@
+package avro
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func exampleEvent() *Event {
+	return &Event{
+		Flag:     true,
+		Count:    -7,
+		ID:       1234567890123,
+		Ratio:    0.5,
+		Score:    2.25,
+		Payload:  []byte{0x00, 0x10, 0xff},
+		Name:     "<café>",
+		Level:    WARN,
+		Hash:     Hash{'a', 'b', 0x01, 0xe9},
+		Tags:     []string{"x", "y"},
+		Counters: map[string]int64{"b": 2, "a": 1},
+		Note:     UnionNullString{String: "hello", UnionType: UnionNullStringTypeEnumString},
+		Source:   UnionLongSourceLevel{Source: &Source{Host: "localhost"}, UnionType: UnionLongSourceLevelTypeEnumSource},
+		Retries:  1,
+		Priority: UnionIntNull{UnionType: UnionIntNullTypeEnumNull},
+	}
+}
+
+const exampleEventJSON = `{"nothing":null,"flag":true,"count":-7,"id":1234567890123,"ratio":0.5,"score":2.25,` +
+	`"payload":"\u0000\u0010ÿ","name":"<café>","level":"WARN","hash":"ab\u0001é","tags":["x","y"],` +
+	`"counters":{"a":1,"b":2},"note":{"string":"hello"},"source":{"com.example.json.Source":{"host":"localhost"}},` +
+	`"retries":1,"priority":null}`
+
+func TestMarshalAvroJSON(t *testing.T) {
+	encoded, err := exampleEvent().MarshalAvroJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, exampleEventJSON, string(encoded))
+}
+
+func TestMarshalAvroJSONNilRecord(t *testing.T) {
+	event := exampleEvent()
+	event.Source.Source = nil
+	_, err := event.MarshalAvroJSON()
+	assert.EqualError(t, err, "Nil value for record com.example.json.Source")
+
+	_, err = (*Event)(nil).MarshalAvroJSON()
+	assert.NotNil(t, err)
+}
+
+func TestUnmarshalAvroJSON(t *testing.T) {
+	var decoded Event
+	if err := decoded.UnmarshalAvroJSON([]byte(exampleEventJSON)); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, exampleEvent(), &decoded)
+}
+
+func TestUnmarshalAvroJSONDefaults(t *testing.T) {
+	var decoded Event
+	err := decoded.UnmarshalAvroJSON([]byte(`{"nothing":null,"flag":false,"count":0,"id":0,"ratio":0,"score":0,` +
+		`"payload":"","name":"","level":"DEBUG","hash":"abcd","tags":[],"counters":{},"source":{"long":5}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, UnionNullString{UnionType: UnionNullStringTypeEnumNull}, decoded.Note)
+	assert.Equal(t, int32(3), decoded.Retries)
+	assert.Equal(t, UnionIntNull{Int: 1, UnionType: UnionIntNullTypeEnumInt}, decoded.Priority)
+	assert.Equal(t, UnionLongSourceLevel{Long: 5, UnionType: UnionLongSourceLevelTypeEnumLong}, decoded.Source)
+}
+
+func TestUnmarshalAvroJSONErrors(t *testing.T) {
+	cases := []string{
+		// Missing field without a default
+		`{}`,
+		// Union value which isn't wrapped in its type
+		`{"nothing":null,"flag":false,"count":0,"id":0,"ratio":0,"score":0,"payload":"","name":"","level":"DEBUG","hash":"abcd","tags":[],"counters":{},"source":5}`,
+		// Unknown enum symbol
+		`{"nothing":null,"flag":false,"count":0,"id":0,"ratio":0,"score":0,"payload":"","name":"","level":"TRACE","hash":"abcd","tags":[],"counters":{},"source":{"long":5}}`,
+		// Fixed value of the wrong size
+		`{"nothing":null,"flag":false,"count":0,"id":0,"ratio":0,"score":0,"payload":"","name":"","level":"DEBUG","hash":"abc","tags":[],"counters":{},"source":{"long":5}}`,
+		// Int out of range
+		`{"nothing":null,"flag":false,"count":3000000000,"id":0,"ratio":0,"score":0,"payload":"","name":"","level":"DEBUG","hash":"abcd","tags":[],"counters":{},"source":{"long":5}}`,
+	}
+	for _, c := range cases {
+		var decoded Event
+		assert.NotNil(t, decoded.UnmarshalAvroJSON([]byte(c)), c)
+	}
+}
@@ -0,0 +1,189 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func comparator(t testing.TB) *types.BinaryComparator {
+	schema, err := ioutil.ReadFile("reading.avsc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := types.NewBinaryComparator(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func sign(i int) int {
+	switch {
+	case i < 0:
+		return -1
+	case i > 0:
+		return 1
+	}
+	return 0
+}
+
+// Values are picked from small sets, so that many pairs are equal up to a late field
+func randomReading(r *rand.Rand) *Reading {
+	reading := &Reading{
+		Sensor: []string{"", "a", "ab", "b", "é"}[r.Intn(5)],
+		Seq:    int64(r.Intn(3) - 1),
+		ID:     ID{byte(r.Intn(2)), byte(r.Intn(2) * 200)},
+		Attrs:  map[string][]int64{},
+		Ok:     r.Intn(2) == 0,
+	}
+	switch r.Intn(4) {
+	case 0:
+		reading.Value = UnionNullDoubleString{UnionType: UnionNullDoubleStringTypeEnumNull}
+	case 1:
+		reading.Value = UnionNullDoubleString{Double: []float64{-1, 0, 1, math.NaN()}[r.Intn(4)], UnionType: UnionNullDoubleStringTypeEnumDouble}
+	default:
+		reading.Value = UnionNullDoubleString{String: []string{"x", "y"}[r.Intn(2)], UnionType: UnionNullDoubleStringTypeEnumString}
+	}
+	for i := r.Intn(3); i > 0; i-- {
+		reading.Samples = append(reading.Samples, &Sample{
+			Level: Level(r.Intn(2)),
+			V:     float32(r.Intn(2)),
+			Raw:   []byte{byte(r.Intn(256))},
+		})
+	}
+	for i := r.Intn(3); i > 0; i-- {
+		reading.Attrs[string('a'+rune(i))] = []int64{int64(r.Intn(10))}
+	}
+	return reading
+}
+
+func encode(t testing.TB, r *rand.Rand, reading *Reading) []byte {
+	var buf bytes.Buffer
+	var err error
+	if r.Intn(2) == 0 {
+		err = reading.Serialize(&buf)
+	} else {
+		// Split the array into blocks with their sizes, which the comparison has to line up
+		defer func(size int) { StreamBlockBytes = size }(StreamBlockBytes)
+		StreamBlockBytes = 1
+		err = reading.SerializeStreamingSamples(&buf, func(enc *ArraySampleEncoder) error {
+			for _, s := range reading.Samples {
+				if err := enc.Encode(s); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBinaryCompareAgreesWithDecoded(t *testing.T) {
+	c := comparator(t)
+	r := rand.New(rand.NewSource(1))
+	results := make(map[int]int)
+	for i := 0; i < 5000; i++ {
+		a, b := randomReading(r), randomReading(r)
+		if i%10 == 0 {
+			// Equal records, which may still be encoded differently
+			b, _ = DeserializeReading(bytes.NewReader(encode(t, r, a)))
+			b.Attrs = map[string][]int64{"other": {1, 2, 3}}
+		}
+		expected := sign(a.Compare(b))
+		encodedA, encodedB := encode(t, r, a), encode(t, r, b)
+		actual, err := c.Compare(encodedA, encodedB)
+		assert.Nil(t, err)
+		if !assert.Equal(t, expected, sign(actual)) {
+			t.Logf("a: %+v\nb: %+v", a, b)
+			return
+		}
+		results[expected]++
+	}
+	// Every outcome was exercised
+	assert.Equal(t, 3, len(results))
+	assert.True(t, results[0] >= 500)
+}
+
+func TestBinaryCompareTrailingBytes(t *testing.T) {
+	c := comparator(t)
+	r := rand.New(rand.NewSource(2))
+	reading := randomReading(r)
+	data := encode(t, r, reading)
+	result, err := c.Compare(data, append(data, 1, 2, 3))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, result)
+}
+
+func TestBinaryCompareTruncated(t *testing.T) {
+	c := comparator(t)
+	r := rand.New(rand.NewSource(3))
+	reading := randomReading(r)
+	data := encode(t, r, reading)
+	_, err := c.Compare(data, data[:len(data)-1])
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestBinaryCompareMaps(t *testing.T) {
+	_, err := types.NewBinaryComparator([]byte(`{"type": "record", "name": "Bag", "fields": [{"name": "m", "type": {"type": "map", "values": "int"}}]}`))
+	assert.EqualError(t, err, "Unable to compare Bag: it contains a map outside of an ignored field")
+
+	c, err := types.NewBinaryComparator([]byte(`{"type": "array", "items": "int"}`))
+	assert.Nil(t, err)
+	result, err := c.Compare([]byte{2, 2, 0}, []byte{4, 2, 0, 0})
+	assert.Nil(t, err)
+	assert.Equal(t, -1, result)
+}
+
+func TestBinaryCompareLengthLimits(t *testing.T) {
+	schema := []byte(`{"type": "array", "items": "null"}`)
+	c, err := types.NewBinaryComparator(schema)
+	assert.Nil(t, err)
+
+	// A block of 2^62 nulls takes 11 bytes, but would take as many steps to compare
+	data := make([]byte, binary.MaxVarintLen64+1)
+	data = data[:binary.PutVarint(data, 1<<62)+1]
+	assert.Equal(t, 11, len(data))
+	_, err = c.Compare(data, data)
+	assert.Equal(t, &types.LengthError{Type: "array", Length: 1 << 62, Limit: types.DefaultMaxArrayLength}, err)
+
+	// The generic codec rejects the same input
+	codec, err := types.NewGenericCodec(schema)
+	assert.Nil(t, err)
+	_, err = codec.Unmarshal(data)
+	assert.IsType(t, &types.LengthError{}, err)
+
+	// The count of a block with a byte size can't be negated to a positive count
+	data = make([]byte, 2*binary.MaxVarintLen64+1)
+	n := binary.PutVarint(data, math.MinInt64)
+	n += binary.PutVarint(data[n:], 0)
+	data = data[:n+1]
+	c.MaxArrayLength = 0
+	_, err = c.Compare(data, data)
+	assert.Equal(t, &types.LengthError{Type: "array", Length: math.MinInt64}, err)
+}
+
+func BenchmarkBinaryCompare(b *testing.B) {
+	c := comparator(b)
+	r := rand.New(rand.NewSource(4))
+	reading := randomReading(r)
+	data := encode(b, r, reading)
+	other := append([]byte(nil), data...)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Compare(data, other); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
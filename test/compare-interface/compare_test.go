@@ -0,0 +1,57 @@
+package avro
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sign(i int) int {
+	switch {
+	case i < 0:
+		return -1
+	case i > 0:
+		return 1
+	}
+	return 0
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func longPtr(l int64) *int64 {
+	return &l
+}
+
+func TestCompareInterfaceUnions(t *testing.T) {
+	base := &Entry{Value: UnionNullStringLongString{Value: "a"}, Size: longPtr(1), Comment: strPtr("x")}
+	cases := []struct {
+		name     string
+		other    *Entry
+		expected int
+	}{
+		{"equal", &Entry{Value: UnionNullStringLongString{Value: "a"}, Size: longPtr(1), Comment: strPtr("x")}, 0},
+		{"null branch first", &Entry{Value: nil, Size: longPtr(1), Comment: strPtr("x")}, 1},
+		{"later branch", &Entry{Value: UnionNullStringLongLong{Value: 0}, Size: longPtr(1), Comment: strPtr("x")}, -1},
+		{"branch value", &Entry{Value: UnionNullStringLongString{Value: "b"}, Size: longPtr(1), Comment: strPtr("x")}, -1},
+		{"null second branch", &Entry{Value: UnionNullStringLongString{Value: "a"}, Size: nil, Comment: strPtr("x")}, -1},
+		{"optional value", &Entry{Value: UnionNullStringLongString{Value: "a"}, Size: longPtr(0), Comment: strPtr("x")}, 1},
+		{"descending null first", &Entry{Value: UnionNullStringLongString{Value: "a"}, Size: longPtr(1), Comment: nil}, -1},
+		{"descending value", &Entry{Value: UnionNullStringLongString{Value: "a"}, Size: longPtr(1), Comment: strPtr("y")}, 1},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, sign(base.Compare(c.other)), c.name)
+		assert.Equal(t, -c.expected, sign(c.other.Compare(base)), c.name)
+	}
+}
+
+func TestCompareInterfaceUnionPointerBranches(t *testing.T) {
+	base := &Entry{Value: UnionNullStringLongString{Value: "a"}, Size: longPtr(1)}
+	pointer := &Entry{Value: &UnionNullStringLongString{Value: "a"}, Size: longPtr(1)}
+	assert.Equal(t, 0, base.Compare(pointer))
+	assert.Equal(t, 0, pointer.Compare(base))
+
+	pointer.Value = &UnionNullStringLongLong{Value: 0}
+	assert.Equal(t, -1, sign(base.Compare(pointer)))
+}
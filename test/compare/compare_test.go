@@ -0,0 +1,110 @@
+package avro
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func item() *Item {
+	return &Item{
+		Name:     "b",
+		Priority: 5,
+		Score:    1.5,
+		Ratio:    0.5,
+		Active:   true,
+		Tag:      Tag{1, 2},
+		Level:    MEDIUM,
+		Parts:    []int64{1, 2},
+		Blob:     []byte{3},
+		Ref:      UnionNullStringLong{String: "x", UnionType: UnionNullStringLongTypeEnumString},
+		Child:    UnionNullChild{Child: &Child{Weight: 1}, UnionType: UnionNullChildTypeEnumChild},
+		Meta:     map[string]string{"a": "b"},
+		Note:     "note",
+	}
+}
+
+func sign(i int) int {
+	switch {
+	case i < 0:
+		return -1
+	case i > 0:
+		return 1
+	}
+	return 0
+}
+
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		name   string
+		change func(*Item)
+		// The sign of item().Compare(changed)
+		expected int
+	}{
+		{"equal", func(i *Item) {}, 0},
+		{"string", func(i *Item) { i.Name = "c" }, -1},
+		{"string prefix", func(i *Item) { i.Name = "" }, 1},
+		{"code point order", func(i *Item) { i.Name = "é" }, -1},
+		{"descending", func(i *Item) { i.Priority = 6 }, 1},
+		{"double", func(i *Item) { i.Score = -1 }, 1},
+		{"NaN sorts last", func(i *Item) { i.Score = math.NaN() }, -1},
+		{"float", func(i *Item) { i.Ratio = 0.75 }, -1},
+		{"boolean", func(i *Item) { i.Active = false }, 1},
+		{"fixed", func(i *Item) { i.Tag = Tag{1, 255} }, -1},
+		{"enum by symbol position", func(i *Item) { i.Level = HIGH }, -1},
+		{"array element", func(i *Item) { i.Parts = []int64{1, 1, 5} }, 1},
+		{"shorter array first", func(i *Item) { i.Parts = []int64{1, 2, 0} }, -1},
+		{"bytes unsigned", func(i *Item) { i.Blob = []byte{200} }, -1},
+		{"union branch", func(i *Item) { i.Ref = UnionNullStringLong{UnionType: UnionNullStringLongTypeEnumNull} }, 1},
+		{"union later branch", func(i *Item) { i.Ref = UnionNullStringLong{Long: 0, UnionType: UnionNullStringLongTypeEnumLong} }, -1},
+		{"union value", func(i *Item) { i.Ref.String = "w" }, 1},
+		{"nested record", func(i *Item) { i.Child.Child = &Child{Weight: 2} }, -1},
+		{"ignored nested field", func(i *Item) { i.Child.Child = &Child{Weight: 1, Label: "z"} }, 0},
+		{"ignored map", func(i *Item) { i.Meta = nil }, 0},
+		{"ignored string", func(i *Item) { i.Note = "other" }, 0},
+	}
+	for _, c := range cases {
+		changed := item()
+		c.change(changed)
+		assert.Equal(t, c.expected, sign(item().Compare(changed)), c.name)
+		assert.Equal(t, -c.expected, sign(changed.Compare(item())), c.name)
+	}
+}
+
+func TestCompareFieldOrder(t *testing.T) {
+	// Earlier fields decide the order before later ones are compared
+	a, b := item(), item()
+	a.Name, b.Name = "a", "b"
+	a.Score, b.Score = 100, 0
+	assert.Equal(t, -1, sign(a.Compare(b)))
+}
+
+func TestSortItems(t *testing.T) {
+	var items []*Item
+	for _, name := range []string{"c", "a", "b"} {
+		for p := int32(0); p < 3; p++ {
+			i := item()
+			i.Name, i.Priority = name, p
+			items = append(items, i)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Compare(items[j]) < 0 })
+	var order []string
+	for _, i := range items {
+		order = append(order, i.Name+string('0'+rune(i.Priority)))
+	}
+	assert.Equal(t, []string{"a2", "a1", "a0", "b2", "b1", "b0", "c2", "c1", "c0"}, order)
+}
+
+func TestMapsAreNotComparable(t *testing.T) {
+	// The specification doesn't order maps, so records which compare them have no Compare method
+	_, ok := interface{}(&Bag{}).(interface{ Compare(*Bag) int })
+	assert.False(t, ok)
+}
+
+func TestSchemaKeepsOrder(t *testing.T) {
+	assert.Contains(t, item().Schema(), `"order":"descending"`)
+	assert.NotContains(t, item().CanonicalSchema(), "order")
+}
@@ -0,0 +1,118 @@
+package avro
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/confluent"
+	"github.com/alanctgardner/gogen-avro/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func deserializeUser(r io.Reader) (container.AvroRecord, error) {
+	return DeserializeUser(r)
+}
+
+func deserializeOrder(r io.Reader) (container.AvroRecord, error) {
+	return DeserializeOrder(r)
+}
+
+func newResolver() *confluent.MemoryResolver {
+	resolver := confluent.NewMemoryResolver()
+	resolver.Register(1, (&User{}).Schema())
+	resolver.Register(2, (&Order{}).Schema())
+	return resolver
+}
+
+func newDeserializer(t *testing.T, resolver confluent.SchemaResolver) *confluent.Deserializer {
+	deserializer := confluent.NewDeserializer(resolver)
+	if err := deserializer.Register((&User{}).Schema(), deserializeUser); err != nil {
+		t.Fatal(err)
+	}
+	if err := deserializer.Register((&Order{}).Schema(), deserializeOrder); err != nil {
+		t.Fatal(err)
+	}
+	return deserializer
+}
+
+func TestWireFormat(t *testing.T) {
+	serializer := confluent.NewSerializer(newResolver())
+	encoded, err := serializer.Marshal(&User{Name: "a", Age: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Magic byte, big-endian schema ID, then the string "a" and the int 1
+	assert.Equal(t, []byte{0, 0, 0, 0, 1, 2, 'a', 2}, encoded)
+}
+
+func TestRoundTripPicksTypeByID(t *testing.T) {
+	resolver := newResolver()
+	serializer := confluent.NewSerializer(resolver)
+	deserializer := newDeserializer(t, resolver)
+
+	records := []container.AvroRecord{
+		&User{Name: "Alice", Age: 30},
+		&Order{ID: 12, Items: []string{"apple", "pear"}},
+		&User{Name: "Bob", Age: 40},
+	}
+
+	var buf bytes.Buffer
+	for _, r := range records {
+		if err := serializer.Serialize(r, &buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, expected := range records {
+		actual, err := deserializer.Deserialize(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestResolverSchemaFormattingIgnored(t *testing.T) {
+	// The registry may return the schema with different formatting or extra attributes
+	resolver := confluent.NewMemoryResolver()
+	resolver.Register(7, `{"type": "record", "name": "User", "namespace": "com.example.confluent", "doc": "A user",
+		"fields": [{"name": "name", "type": "string"}, {"name": "age", "type": "int"}]}`)
+	deserializer := newDeserializer(t, resolver)
+
+	record, err := deserializer.Unmarshal([]byte{0, 0, 0, 0, 7, 2, 'a', 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &User{Name: "a", Age: 1}, record)
+}
+
+func TestUnknownSchemaID(t *testing.T) {
+	deserializer := newDeserializer(t, newResolver())
+	_, err := deserializer.Unmarshal([]byte{0, 0, 0, 0, 3, 2, 'a', 2})
+	assert.Equal(t, &confluent.UnknownSchemaIDError{ID: 3}, err)
+}
+
+func TestUnregisteredType(t *testing.T) {
+	resolver := newResolver()
+	deserializer := confluent.NewDeserializer(resolver)
+	if err := deserializer.Register((&User{}).Schema(), deserializeUser); err != nil {
+		t.Fatal(err)
+	}
+	_, err := deserializer.Unmarshal([]byte{0, 0, 0, 0, 2, 2, 0})
+	_, ok := err.(*confluent.UnregisteredTypeError)
+	assert.True(t, ok)
+}
+
+func TestInvalidMagicByte(t *testing.T) {
+	deserializer := newDeserializer(t, newResolver())
+	_, err := deserializer.Unmarshal([]byte{1, 0, 0, 0, 1, 2, 'a', 2})
+	assert.Equal(t, &confluent.InvalidMagicByteError{MagicByte: 1}, err)
+}
+
+func TestUnknownSchema(t *testing.T) {
+	serializer := confluent.NewSerializer(confluent.NewMemoryResolver())
+	_, err := serializer.Marshal(&User{})
+	_, ok := err.(*confluent.UnknownSchemaError)
+	assert.True(t, ok)
+}
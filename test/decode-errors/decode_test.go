@@ -0,0 +1,102 @@
+package avro
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func fixtureOrder() *Order {
+	order := &Order{
+		ID:   7,
+		Tags: map[string]Status{"a": CLOSED},
+		Note: UnionNullString{UnionType: UnionNullStringTypeEnumNull},
+	}
+	for _, sku := range []string{"a", "b", "c", "d"} {
+		order.Items = append(order.Items, &Item{Sku: sku, Price: 1.5})
+	}
+	return order
+}
+
+func encode(t *testing.T, order *Order) []byte {
+	var buf bytes.Buffer
+	if err := order.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeValidOrder(t *testing.T) {
+	decoded, err := DeserializeOrder(bytes.NewReader(encode(t, fixtureOrder())))
+	assert.Nil(t, err)
+	assert.Equal(t, fixtureOrder(), decoded)
+}
+
+func TestDecodeErrorPath(t *testing.T) {
+	data := encode(t, fixtureOrder())
+	// id, the block count, three items and the sku of the fourth, then half of its price
+	truncated := data[:1+1+3*10+2+4]
+
+	_, err := DeserializeOrder(bytes.NewReader(truncated))
+	assert.Equal(t, &DecodeError{Path: "Order.items[3].price", Type: "double", Offset: int64(len(truncated)), Err: io.ErrUnexpectedEOF}, err)
+	assert.EqualError(t, err, "Error reading double at Order.items[3].price (offset 38): unexpected EOF")
+}
+
+func TestDecodeErrorMapValue(t *testing.T) {
+	data := encode(t, fixtureOrder())
+	// Stop after the key of the first tag
+	truncated := data[:1+1+4*10+1+1+2]
+
+	_, err := DeserializeOrder(bytes.NewReader(truncated))
+	decodeErr := err.(*DecodeError)
+	assert.Equal(t, `Order.tags["a"]`, decodeErr.Path)
+	assert.Equal(t, "com.example.decode.Status", decodeErr.Type)
+	assert.Equal(t, io.EOF, decodeErr.Unwrap())
+}
+
+func TestDecodeErrorUnion(t *testing.T) {
+	order := fixtureOrder()
+	order.Items = nil
+	order.Tags = map[string]Status{}
+	data := encode(t, order)
+	// Replace the null branch of the note with an index outside the union
+	data[len(data)-1] = 10
+
+	_, err := DeserializeOrder(bytes.NewReader(data))
+	assert.EqualError(t, err, "Error reading union at Order.note (offset 4): Invalid value for UnionNullString")
+}
+
+func TestDecodeErrorEmptyInput(t *testing.T) {
+	// The end of the input before a record is io.EOF itself, so the end of a stream can be compared with ==
+	_, err := DeserializeOrder(bytes.NewReader(nil))
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, io.EOF, DeserializeOrderInto(bytes.NewReader(nil), &Order{}))
+	_, _, err = UnmarshalOrder(nil)
+	assert.Equal(t, io.EOF, err)
+
+	d := NewDecoder(bytes.NewReader(encode(t, fixtureOrder())))
+	_, err = DeserializeOrder(d)
+	assert.Nil(t, err)
+	_, err = DeserializeOrder(d)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDecoderNamesRejected(t *testing.T) {
+	schemas := map[string]string{
+		`{"type": "record", "name": "Decoder", "fields": []}`:                       "Decoder is generated as Decoder, which is the name of a declaration in every generated package",
+		`{"type": "record", "name": "DecodeError", "namespace": "x", "fields": []}`: "x.DecodeError is generated as DecodeError, which is the name of a declaration in every generated package",
+		`{"type": "fixed", "name": "byteReader", "size": 1}`:                        "byteReader is generated as ByteReader, which is the name of a declaration in every generated package",
+		`{"type": "enum", "name": "Limit", "symbols": ["MaxMapLength", "OTHER"]}`:   "Symbol MaxMapLength of enum Limit is generated as MaxMapLength, which is the name of a declaration in every generated package",
+		`{"type": "enum", "name": "Setting", "symbols": ["StreamBlockBytes"]}`:      "Symbol StreamBlockBytes of enum Setting is generated as StreamBlockBytes, which is the name of a declaration in every generated package",
+	}
+	for schema, expected := range schemas {
+		namespace := types.NewNamespace()
+		if _, err := namespace.FieldDefinitionForSchema([]byte(schema)); err != nil {
+			t.Fatal(err)
+		}
+		assert.EqualError(t, namespace.CheckNames(), expected)
+	}
+}
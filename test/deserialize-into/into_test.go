@@ -0,0 +1,147 @@
+package avro
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func fixtureBatch(events int) *Batch {
+	batch := &Batch{
+		ID:     int64(events),
+		Source: &Source{Host: "a", Checksum: []byte{1, 2, 3}},
+		Events: []*Event{},
+		Chunks: [][]byte{{4}, {}},
+		Labels: map[string]string{"env": "prod"},
+		Parent: UnionNullSource{UnionType: UnionNullSourceTypeEnumNull},
+	}
+	for i := 0; i < events; i++ {
+		batch.Events = append(batch.Events, &Event{Name: "e", Payload: []byte{byte(i)}, Values: []float64{float64(i), 0.5}})
+	}
+	return batch
+}
+
+func encode(t testing.TB, batch *Batch) []byte {
+	var buf bytes.Buffer
+	if err := batch.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDeserializeIntoEmpty(t *testing.T) {
+	expected := fixtureBatch(3)
+	dst := &Batch{}
+	assert.Nil(t, DeserializeBatchInto(bytes.NewReader(encode(t, expected)), dst))
+	assert.Equal(t, expected, dst)
+
+	// Empty arrays and maps decode the same way as Deserialize
+	empty := &Batch{Source: &Source{Checksum: []byte{}}, Events: []*Event{}, Chunks: [][]byte{}, Labels: map[string]string{}}
+	dst = &Batch{}
+	assert.Nil(t, DeserializeBatchInto(bytes.NewReader(encode(t, empty)), dst))
+	assert.Equal(t, empty, dst)
+}
+
+func TestDeserializeIntoReuses(t *testing.T) {
+	dst := &Batch{}
+	assert.Nil(t, DeserializeBatchInto(bytes.NewReader(encode(t, fixtureBatch(3))), dst))
+	source, events, event, labels := dst.Source, dst.Events, dst.Events[1], dst.Labels
+
+	expected := fixtureBatch(2)
+	expected.Parent = UnionNullSource{Source: &Source{Host: "p", Checksum: []byte{}}, UnionType: UnionNullSourceTypeEnumSource}
+	delete(expected.Labels, "env")
+	expected.Labels["team"] = "data"
+	assert.Nil(t, DeserializeBatchInto(bytes.NewReader(encode(t, expected)), dst))
+	assert.Equal(t, expected, dst)
+
+	assert.True(t, source == dst.Source)
+	assert.True(t, &events[0] == &dst.Events[0])
+	assert.True(t, event == dst.Events[1])
+	assert.Equal(t, reflect.ValueOf(labels).Pointer(), reflect.ValueOf(dst.Labels).Pointer())
+}
+
+func TestDeserializeIntoError(t *testing.T) {
+	data := encode(t, fixtureBatch(3))
+	err := DeserializeBatchInto(bytes.NewReader(data[:len(data)-8]), &Batch{})
+	decodeErr := err.(*DecodeError)
+	assert.Equal(t, "Batch.labels", decodeErr.Path)
+}
+
+func TestReset(t *testing.T) {
+	batch := fixtureBatch(3)
+	events, source := batch.Events, batch.Source
+	batch.Reset()
+
+	assert.Equal(t, &Batch{Source: &Source{Checksum: []byte{}}, Events: []*Event{}, Chunks: [][]byte{}, Labels: map[string]string{}}, batch)
+	assert.True(t, source == batch.Source)
+	assert.Equal(t, cap(events), cap(batch.Events))
+	assert.True(t, &events[:1][0] == &batch.Events[:1][0])
+
+	expected := fixtureBatch(1)
+	assert.Nil(t, DeserializeBatchInto(bytes.NewReader(encode(t, expected)), batch))
+	assert.Equal(t, expected, batch)
+}
+
+func TestPool(t *testing.T) {
+	pool := sync.Pool{New: func() interface{} { return &Batch{} }}
+	for i := 0; i < 5; i++ {
+		batch := pool.Get().(*Batch)
+		expected := fixtureBatch(i)
+		assert.Nil(t, DeserializeBatchInto(bytes.NewReader(encode(t, expected)), batch))
+		assert.Equal(t, expected, batch)
+		batch.Reset()
+		pool.Put(batch)
+	}
+}
+
+func BenchmarkDeserializeInto(b *testing.B) {
+	data := encode(b, fixtureBatch(16))
+	dst := &Batch{}
+	r := bytes.NewReader(data)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.Reset(data)
+		if err := DeserializeBatchInto(r, dst); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDeserialize(b *testing.B) {
+	data := encode(b, fixtureBatch(16))
+	r := bytes.NewReader(data)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.Reset(data)
+		if _, err := DeserializeBatch(r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestMethodNamesRejected(t *testing.T) {
+	schemas := map[string]string{
+		`{"type": "record", "name": "Job", "fields": [{"name": "reset", "type": "boolean"}]}`:   `Field "reset" of record Job is generated as Reset, which is the name of a method of the record`,
+		`{"type": "record", "name": "Job", "fields": [{"name": "compare", "type": "int"}]}`:     `Field "compare" of record Job is generated as Compare, which is the name of a method of the record`,
+		`{"type": "record", "name": "Job", "fields": [{"name": "schema", "type": "string"}]}`:   `Field "schema" of record Job is generated as Schema, which is the name of a method of the record`,
+		`{"type": "error", "name": "Failure", "fields": [{"name": "error", "type": "string"}]}`: `Field "error" of record Failure is generated as Error, which is the name of a method of the record`,
+	}
+	for schema, expected := range schemas {
+		namespace := types.NewNamespace()
+		if _, err := namespace.FieldDefinitionForSchema([]byte(schema)); err != nil {
+			t.Fatal(err)
+		}
+		assert.EqualError(t, namespace.CheckNames(), expected)
+	}
+
+	// Only error records have an Error method
+	namespace := types.NewNamespace()
+	if _, err := namespace.FieldDefinitionForSchema([]byte(`{"type": "record", "name": "Job", "fields": [{"name": "error", "type": "string"}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, namespace.CheckNames())
+}
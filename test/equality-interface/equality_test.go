@@ -0,0 +1,80 @@
+package avro
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func account() *Account {
+	limit := int64(10)
+	yes := true
+	return &Account{
+		Owner:  UnionNullStringTeamTeam{Value: &Team{Members: []string{"a", "b"}}},
+		Limit:  &limit,
+		Parent: &Team{Members: []string{"root"}},
+		Flags:  []*bool{&yes, nil},
+	}
+}
+
+func TestEqualsInterfaceUnions(t *testing.T) {
+	assert.True(t, account().Equals(account()))
+
+	changes := map[string]func(*Account){
+		"branch":       func(a *Account) { a.Owner = UnionNullStringTeamString{Value: "a"} },
+		"null branch":  func(a *Account) { a.Owner = nil },
+		"branch value": func(a *Account) { a.Owner.(UnionNullStringTeamTeam).Value.Members[1] = "c" },
+		"optional":     func(a *Account) { *a.Limit = 11 },
+		"optional nil": func(a *Account) { a.Limit = nil },
+		"record nil":   func(a *Account) { a.Parent = nil },
+		"array item":   func(a *Account) { a.Flags[1] = a.Flags[0] },
+	}
+	for name, change := range changes {
+		changed := account()
+		change(changed)
+		assert.False(t, account().Equals(changed), name)
+		assert.False(t, changed.Equals(account()), name)
+	}
+}
+
+func TestCloneInterfaceUnions(t *testing.T) {
+	original := account()
+	clone := original.Clone()
+	assert.True(t, original.Equals(clone))
+
+	clone.Owner.(UnionNullStringTeamTeam).Value.Members[0] = "z"
+	*clone.Limit = 20
+	*clone.Flags[0] = false
+	clone.Parent.Members[0] = "other"
+	assert.Equal(t, account(), original)
+}
+
+func TestDiffInterfaceUnions(t *testing.T) {
+	old, updated := account(), account()
+	updated.Owner = UnionNullStringTeamString{Value: "solo"}
+	*updated.Limit = 5
+	updated.Parent.Members = append(updated.Parent.Members, "extra")
+	updated.Flags[1] = updated.Flags[0]
+	assert.Equal(t, []FieldDiff{
+		{Path: "owner", Old: old.Owner.(UnionNullStringTeamTeam).Value, New: "solo"},
+		{Path: "limit", Old: int64(10), New: int64(5)},
+		{Path: "parent.members[1]", New: "extra"},
+		{Path: "flags[1]", New: true},
+	}, old.Diff(updated))
+
+	updated = account()
+	updated.Limit = nil
+	assert.Equal(t, []FieldDiff{{Path: "limit", Old: int64(10)}}, old.Diff(updated))
+}
+
+func TestEqualsInterfaceUnionPointerBranches(t *testing.T) {
+	pointer := account()
+	pointer.Owner = &UnionNullStringTeamTeam{Value: &Team{Members: []string{"a", "b"}}}
+	assert.True(t, account().Equals(pointer))
+	assert.True(t, pointer.Equals(account()))
+	assert.Empty(t, account().Diff(pointer))
+
+	clone := pointer.Clone()
+	clone.Owner.(UnionNullStringTeamTeam).Value.Members[0] = "z"
+	assert.Equal(t, "a", pointer.Owner.(*UnionNullStringTeamTeam).Value.Members[0])
+}
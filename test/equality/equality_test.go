@@ -0,0 +1,159 @@
+package avro
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func customer() *Customer {
+	return &Customer{
+		ID:     1,
+		Name:   "Ada",
+		Score:  2.5,
+		Avatar: []byte{1, 2},
+		Tier:   PRO,
+		Key:    Key{3, 4},
+		Address: &Address{
+			City:  "London",
+			Lines: []string{"1 Main St", "Flat 2"},
+		},
+		Orders: []*Order{
+			{Sku: "a", Qty: 1, Tags: map[string]string{"gift": "yes"}},
+			{Sku: "b", Qty: 2, Tags: map[string]string{}},
+		},
+		Prefs:   map[string][]int64{"x": {1, 2}},
+		Contact: NewUnionNullStringAddressAddress(&Address{City: "Paris"}),
+	}
+}
+
+func TestEquals(t *testing.T) {
+	assert.True(t, customer().Equals(customer()))
+	assert.True(t, (*Customer)(nil).Equals(nil))
+	assert.False(t, customer().Equals(nil))
+
+	// Nil and empty slices and maps have the same encoding
+	a, b := customer(), customer()
+	a.Avatar, b.Avatar = nil, []byte{}
+	a.Orders[1].Tags = nil
+	a.Address.Lines, b.Address.Lines = []string{}, []string{}
+	assert.True(t, a.Equals(b))
+
+	a.Score, b.Score = math.NaN(), math.NaN()
+	assert.True(t, a.Equals(b))
+
+	changes := map[string]func(*Customer){
+		"id":           func(c *Customer) { c.ID = 2 },
+		"score":        func(c *Customer) { c.Score = 3 },
+		"avatar":       func(c *Customer) { c.Avatar[1] = 0 },
+		"tier":         func(c *Customer) { c.Tier = FREE },
+		"key":          func(c *Customer) { c.Key[0] = 0 },
+		"nested":       func(c *Customer) { c.Address.Lines[1] = "Flat 3" },
+		"array length": func(c *Customer) { c.Orders = c.Orders[:1] },
+		"map value":    func(c *Customer) { c.Orders[0].Tags["gift"] = "no" },
+		"map key":      func(c *Customer) { c.Prefs = map[string][]int64{"y": {1, 2}} },
+		"branch":       func(c *Customer) { c.Contact = NewUnionNullStringAddressString("Paris") },
+		"branch value": func(c *Customer) { c.Contact.Address.City = "Rome" },
+		"nil record":   func(c *Customer) { c.Address = nil },
+	}
+	for name, change := range changes {
+		changed := customer()
+		change(changed)
+		assert.False(t, customer().Equals(changed), name)
+		assert.False(t, changed.Equals(customer()), name)
+	}
+}
+
+func TestClone(t *testing.T) {
+	original := customer()
+	clone := original.Clone()
+	assert.True(t, original.Equals(clone))
+	assert.Equal(t, original, clone)
+
+	// Changing the clone leaves the original alone
+	clone.Avatar[0] = 9
+	clone.Address.Lines[0] = "changed"
+	clone.Orders[0].Tags["gift"] = "no"
+	clone.Orders[1].Qty = 5
+	clone.Prefs["x"][0] = 7
+	clone.Contact.Address.City = "Rome"
+	assert.Equal(t, customer(), original)
+
+	assert.Nil(t, (*Customer)(nil).Clone())
+	empty := (&Customer{}).Clone()
+	assert.Nil(t, empty.Avatar)
+	assert.Nil(t, empty.Orders)
+	assert.Nil(t, empty.Prefs)
+}
+
+func TestDiff(t *testing.T) {
+	old, updated := customer(), customer()
+	assert.Empty(t, old.Diff(updated))
+
+	updated.Name = "Grace"
+	updated.Tier = FREE
+	updated.Address.Lines = updated.Address.Lines[:1]
+	updated.Orders[0].Tags["gift"] = "no"
+	updated.Orders[0].Tags["note"] = "fragile"
+	updated.Orders = append(updated.Orders, &Order{Sku: "c"})
+	updated.Prefs["x"][1] = 3
+	updated.Contact.Address.City = "Rome"
+
+	assert.Equal(t, []FieldDiff{
+		{Path: "name", Old: "Ada", New: "Grace"},
+		{Path: "tier", Old: PRO, New: FREE},
+		{Path: "address.lines[1]", Old: "Flat 2"},
+		{Path: `orders[0].tags["gift"]`, Old: "yes", New: "no"},
+		{Path: `orders[0].tags["note"]`, New: "fragile"},
+		{Path: "orders[2]", New: updated.Orders[2]},
+		{Path: `prefs["x"][1]`, Old: int64(2), New: int64(3)},
+		{Path: "contact.city", Old: "Paris", New: "Rome"},
+	}, old.Diff(updated))
+
+	updated = customer()
+	updated.Contact = NewUnionNullStringAddressNull()
+	updated.Address = nil
+	assert.Equal(t, []FieldDiff{
+		{Path: "address", Old: old.Address},
+		{Path: "contact", Old: old.Contact.Address, New: nil},
+	}, old.Diff(updated))
+}
+
+func BenchmarkEquals(b *testing.B) {
+	x, y := customer(), customer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if !x.Equals(y) {
+			b.Fatal("not equal")
+		}
+	}
+}
+
+func BenchmarkDeepEqual(b *testing.B) {
+	x, y := customer(), customer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if !reflect.DeepEqual(x, y) {
+			b.Fatal("not equal")
+		}
+	}
+}
+
+func TestEqualityNamesRejected(t *testing.T) {
+	schemas := map[string]string{
+		`{"type": "record", "name": "Job", "fields": [{"name": "equals", "type": "boolean"}]}`: `Field "equals" of record Job is generated as Equals, which is the name of a method of the record`,
+		`{"type": "record", "name": "Job", "fields": [{"name": "clone", "type": "boolean"}]}`:  `Field "clone" of record Job is generated as Clone, which is the name of a method of the record`,
+		`{"type": "record", "name": "Job", "fields": [{"name": "diff", "type": "string"}]}`:    `Field "diff" of record Job is generated as Diff, which is the name of a method of the record`,
+		`{"type": "fixed", "name": "FieldDiff", "size": 2}`:                                    "FieldDiff is generated as FieldDiff, which is the name of a declaration in every generated package",
+	}
+	for schema, expected := range schemas {
+		namespace := types.NewNamespace()
+		if _, err := namespace.FieldDefinitionForSchema([]byte(schema)); err != nil {
+			t.Fatal(err)
+		}
+		assert.EqualError(t, namespace.CheckNames(), expected)
+	}
+}
@@ -0,0 +1,55 @@
+package avro
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"io/ioutil"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const expectedCanonicalSchema = `{"name":"com.example.fingerprint.FingerprintRecord","type":"record","fields":[{"name":"id","type":"long"},{"name":"name","type":"string"},{"name":"status","type":{"name":"com.example.fingerprint.Status","type":"enum","symbols":["ACTIVE","INACTIVE"]}},{"name":"hash","type":{"name":"com.example.other.Hash","type":"fixed","size":16}},{"name":"previousHash","type":["null","com.example.other.Hash"]},{"name":"tags","type":{"type":"map","values":{"type":"array","items":"com.example.fingerprint.Status"}}},{"name":"child","type":{"name":"com.example.fingerprint.Child","type":"record","fields":[{"name":"value","type":"double"}]}}]}`
+
+func TestCanonicalSchema(t *testing.T) {
+	record := &FingerprintRecord{}
+	assert.Equal(t, expectedCanonicalSchema, record.CanonicalSchema())
+}
+
+func TestSchemaFingerprints(t *testing.T) {
+	record := &FingerprintRecord{}
+	canonical := []byte(record.CanonicalSchema())
+	assert.Equal(t, uint64(0x4a66543b40aeb35b), record.SchemaFingerprint())
+	assert.Equal(t, types.CRC64Fingerprint(canonical), record.SchemaFingerprint())
+	assert.Equal(t, md5.Sum(canonical), record.SchemaFingerprintMD5())
+	assert.Equal(t, sha256.Sum256(canonical), record.SchemaFingerprintSHA256())
+}
+
+func TestCanonicalFormFromSchema(t *testing.T) {
+	schemaJson, err := ioutil.ReadFile("fingerprint.avsc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	namespace := types.NewNamespace()
+	field, err := namespace.FieldDefinitionForSchema(schemaJson)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = field.ResolveReferences(namespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonical, err := types.CanonicalForm(field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, expectedCanonicalSchema, canonical)
+}
+
+func TestCRC64SpecFingerprints(t *testing.T) {
+	// Test vectors from the Avro spec test suite
+	assert.Equal(t, int64(7195948357588979594), int64(types.CRC64Fingerprint([]byte(`"null"`))))
+	assert.Equal(t, int64(-6970731678124411036), int64(types.CRC64Fingerprint([]byte(`"boolean"`))))
+	assert.Equal(t, int64(8247732601305521295), int64(types.CRC64Fingerprint([]byte(`"int"`))))
+}
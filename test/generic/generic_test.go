@@ -0,0 +1,261 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func fixtureReading() *Reading {
+	return &Reading{
+		Valid:       true,
+		Sequence:    -42,
+		Timestamp:   1500000000000,
+		Temperature: 21.5,
+		Pressure:    1013.25,
+		Raw:         []byte{0, 1, 2, 0xff},
+		Sensor:      "kitchen",
+		Unit:        FAHRENHEIT,
+		Checksum:    Checksum{0xde, 0xad, 0xbe, 0xef},
+		Samples:     []float64{1.5, -2.25, 3},
+		Labels:      map[string]string{"room": "kitchen"},
+		Location:    UnionNullLocation{Location: &Location{Latitude: 51.5, Longitude: -0.12}, UnionType: UnionNullLocationTypeEnumLocation},
+		Calibration: UnionNullLongStringUnit{Unit: KELVIN, UnionType: UnionNullLongStringUnitTypeEnumUnit},
+	}
+}
+
+func fixtureGenericReading() map[string]interface{} {
+	return map[string]interface{}{
+		"nothing":     nil,
+		"valid":       true,
+		"sequence":    int32(-42),
+		"timestamp":   int64(1500000000000),
+		"temperature": float32(21.5),
+		"pressure":    1013.25,
+		"raw":         []byte{0, 1, 2, 0xff},
+		"sensor":      "kitchen",
+		"unit":        "FAHRENHEIT",
+		"checksum":    []byte{0xde, 0xad, 0xbe, 0xef},
+		"samples":     []interface{}{1.5, -2.25, float64(3)},
+		"labels":      map[string]interface{}{"room": "kitchen"},
+		"location": map[string]interface{}{
+			"com.example.generic.Location": map[string]interface{}{"latitude": 51.5, "longitude": -0.12},
+		},
+		"calibration": map[string]interface{}{"com.example.generic.Unit": "KELVIN"},
+	}
+}
+
+func readingCodec(t *testing.T) *types.GenericCodec {
+	codec, err := types.NewGenericCodec([]byte(fixtureReading().Schema()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return codec
+}
+
+func TestGenericEncodeParity(t *testing.T) {
+	var generated bytes.Buffer
+	if err := fixtureReading().Serialize(&generated); err != nil {
+		t.Fatal(err)
+	}
+
+	var generic bytes.Buffer
+	if err := readingCodec(t).Encode(&generic, fixtureGenericReading()); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, generated.Bytes(), generic.Bytes())
+}
+
+func TestGenericDecodeParity(t *testing.T) {
+	var generated bytes.Buffer
+	if err := fixtureReading().Serialize(&generated); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := readingCodec(t).Decode(&generated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fixtureGenericReading(), value)
+	assert.Equal(t, 0, generated.Len())
+}
+
+func TestGenericEncodeDecodedByGenerated(t *testing.T) {
+	reading := fixtureGenericReading()
+	reading["location"] = nil
+	reading["calibration"] = map[string]interface{}{"string": "factory"}
+	reading["samples"] = []interface{}{}
+	reading["labels"] = map[string]interface{}{}
+
+	data, err := readingCodec(t).Marshal(reading)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DeserializeReading(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, UnionNullLocationTypeEnumNull, decoded.Location.UnionType)
+	assert.Equal(t, UnionNullLongStringUnitTypeEnumString, decoded.Calibration.UnionType)
+	assert.Equal(t, "factory", decoded.Calibration.String)
+	assert.Len(t, decoded.Samples, 0)
+	assert.Len(t, decoded.Labels, 0)
+}
+
+func TestGenericMapsAreSorted(t *testing.T) {
+	codec, err := types.NewGenericCodec([]byte(`{"type": "map", "values": "int"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := codec.Marshal(map[string]interface{}{"b": int32(2), "a": int32(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []byte{4, 2, 'a', 2, 2, 'b', 4, 0}, data)
+}
+
+func TestGenericNegativeBlockCount(t *testing.T) {
+	codec, err := types.NewGenericCodec([]byte(`{"type": "array", "items": "int"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A block of -2 items with a byte size of 2, followed by a block of 1 item
+	value, err := codec.Unmarshal([]byte{3, 4, 2, 4, 2, 6, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []interface{}{int32(1), int32(2), int32(3)}, value)
+}
+
+func TestGenericRecursiveRecord(t *testing.T) {
+	codec, err := types.NewGenericCodec([]byte(`{"type": "record", "name": "Node", "fields": [
+		{"name": "value", "type": "int"},
+		{"name": "next", "type": ["null", "Node"]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := map[string]interface{}{
+		"value": int32(1),
+		"next": map[string]interface{}{
+			"Node": map[string]interface{}{"value": int32(2), "next": nil},
+		},
+	}
+	data, err := codec.Marshal(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []byte{2, 2, 4, 0}, data)
+
+	value, err := codec.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, list, value)
+}
+
+func TestGenericErrors(t *testing.T) {
+	codec := readingCodec(t)
+
+	reading := fixtureGenericReading()
+	reading["sequence"] = 42
+	_, err := codec.Marshal(reading)
+	assert.EqualError(t, err, `Expected int32 for field "sequence", got int`)
+
+	reading = fixtureGenericReading()
+	delete(reading, "sensor")
+	_, err = codec.Marshal(reading)
+	assert.EqualError(t, err, `Missing value for field "sensor" of record com.example.generic.Reading`)
+
+	reading = fixtureGenericReading()
+	reading["unit"] = "RANKINE"
+	_, err = codec.Marshal(reading)
+	assert.EqualError(t, err, `Invalid symbol "RANKINE" for enum com.example.generic.Unit`)
+
+	reading = fixtureGenericReading()
+	reading["calibration"] = map[string]interface{}{"double": 1.0}
+	_, err = codec.Marshal(reading)
+	assert.EqualError(t, err, `Invalid branch "double" for union field "calibration"`)
+
+	reading = fixtureGenericReading()
+	reading["checksum"] = []byte{1}
+	_, err = codec.Marshal(reading)
+	assert.EqualError(t, err, `Expected 4 bytes for fixed com.example.generic.Checksum, got 1`)
+}
+
+func TestDecodeInvalidValues(t *testing.T) {
+	var buf bytes.Buffer
+	if err := fixtureReading().Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.Bytes()
+	// The first byte is the boolean, and the second is the int -42
+	assert.Equal(t, []byte{1, 83}, encoded[:2])
+
+	invalidBool := append([]byte{2}, encoded[1:]...)
+	_, err := readingCodec(t).Decode(bytes.NewReader(invalidBool))
+	assert.EqualError(t, err, `Invalid value 2 for boolean field "valid"`)
+	_, err = DeserializeReading(bytes.NewReader(invalidBool))
+	assert.Contains(t, err.Error(), "Invalid boolean value 2")
+
+	// 2^40 doesn't fit in an int
+	overflow := append([]byte{1, 0x80, 0x80, 0x80, 0x80, 0x80, 0x40}, encoded[2:]...)
+	_, err = readingCodec(t).Decode(bytes.NewReader(overflow))
+	assert.EqualError(t, err, `Value 1099511627776 of int field "sequence" overflows an int`)
+	_, err = DeserializeReading(bytes.NewReader(overflow))
+	assert.Contains(t, err.Error(), "Varint overflows an int")
+
+	// The tenth byte of a long only holds its top bit, so neither 2^64 nor an 11-byte varint fits in a long
+	for _, varint := range [][]byte{
+		append(bytes.Repeat([]byte{0x80}, 9), 0x02),
+		append(bytes.Repeat([]byte{0x80}, 10), 0x01),
+	} {
+		overflow = append(append([]byte{}, encoded[:2]...), varint...)
+		_, err = readingCodec(t).Decode(bytes.NewReader(overflow))
+		assert.Contains(t, err.Error(), "varint overflows a 64-bit integer")
+		_, err = DeserializeReading(bytes.NewReader(overflow))
+		assert.EqualError(t, err, "Error reading long at Reading.timestamp (offset 12): Varint overflows a long")
+	}
+}
+
+func TestGenericLimits(t *testing.T) {
+	codec, err := types.NewGenericCodec([]byte(`{"type": "array", "items": "string"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A block of one string with a negative length
+	_, err = codec.Unmarshal([]byte{2, 1})
+	assert.Equal(t, &types.LengthError{Type: "string", Length: -1, Limit: types.DefaultMaxStringLength}, err)
+
+	codec.MaxArrayLength = 1
+	_, err = codec.Unmarshal([]byte{4, 0, 0, 0})
+	assert.EqualError(t, err, "array length 2 exceeds the limit of 1")
+
+	codec.MaxStringLength = 2
+	_, err = codec.Unmarshal([]byte{2, 6, 'a', 'b', 'c', 0})
+	assert.EqualError(t, err, "string length 3 exceeds the limit of 2")
+}
+
+func TestGenericMaxDepth(t *testing.T) {
+	codec, err := types.NewGenericCodec([]byte(`{"type": "record", "name": "Node", "fields": [
+		{"name": "value", "type": "int"},
+		{"name": "next", "type": ["null", "Node"]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A list of four nodes
+	data := []byte{2, 2, 4, 2, 6, 2, 8, 0}
+	_, err = codec.Unmarshal(data)
+	assert.Nil(t, err)
+
+	codec.MaxDepth = 3
+	_, err = codec.Unmarshal(data)
+	assert.Equal(t, &types.DepthError{Limit: 3}, err)
+	assert.EqualError(t, err, "Nesting exceeds the limit of 3")
+}
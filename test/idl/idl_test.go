@@ -0,0 +1,91 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type shopServer struct {
+	orders map[string]*Order
+}
+
+func (s *shopServer) Audit(request *ShopAuditRequest) error {
+	return nil
+}
+
+func (s *shopServer) Cancel(request *ShopCancelRequest) error {
+	delete(s.orders, request.ID)
+	return nil
+}
+
+func (s *shopServer) Find(request *ShopFindRequest) (UnionNullOrder, error) {
+	if order, ok := s.orders[request.ID]; ok {
+		return UnionNullOrder{Order: order, UnionType: UnionNullOrderTypeEnumOrder}, nil
+	}
+	return UnionNullOrder{}, nil
+}
+
+func (s *shopServer) Ping(request *ShopPingRequest) error {
+	return nil
+}
+
+func (s *shopServer) Place(request *ShopPlaceRequest) (string, error) {
+	if len(request.Order.Items) == 0 {
+		return "", &OrderRejected{Reason: "no items"}
+	}
+	s.orders[request.Order.ID] = request.Order
+	return request.Order.ID, nil
+}
+
+func TestIDLInterface(t *testing.T) {
+	var shop Shop = &shopServer{orders: make(map[string]*Order)}
+	_, err := shop.Place(&ShopPlaceRequest{Order: &Order{ID: "1"}})
+	assert.Equal(t, &OrderRejected{Reason: "no items"}, err)
+}
+
+func TestIDLRecordRoundTrip(t *testing.T) {
+	order := &Order{
+		ID:       "1",
+		Items:    []*LineItem{{Sku: "abc", Quantity: 2, Price: &Money{Amount: 250, Currency: "EUR"}}},
+		Labels:   map[string]string{"gift": "yes"},
+		Priority: HIGH,
+		Status:   SHIPPED,
+		Note:     UnionNullString{String: "leave at the door", UnionType: UnionNullStringTypeEnumString},
+		Version:  UnionNullLong{UnionType: UnionNullLongTypeEnumNull},
+		Total:    []byte{0x01, 0xf4},
+		Checksum: UnionNullChecksum{Checksum: Checksum{1, 2, 3}, UnionType: UnionNullChecksumTypeEnumChecksum},
+	}
+	var buf bytes.Buffer
+	if err := order.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DeserializeOrder(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, order, decoded)
+}
+
+func TestIDLProtocolJSON(t *testing.T) {
+	var protocol struct {
+		Namespace string                     `json:"namespace"`
+		Doc       string                     `json:"doc"`
+		Types     []map[string]interface{}   `json:"types"`
+		Messages  map[string]json.RawMessage `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(ShopProtocol()), &protocol); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "com.example.shop", protocol.Namespace)
+	assert.Equal(t, "The order service.\nOrders are placed & shipped.", protocol.Doc)
+
+	var names []string
+	for _, t := range protocol.Types {
+		names = append(names, t["name"].(string))
+	}
+	assert.Equal(t, []string{"Money", "Checksum", "Status", "AuditEvent", "Priority", "LineItem", "Order", "OrderRejected"}, names)
+	assert.Len(t, protocol.Messages, 5)
+}
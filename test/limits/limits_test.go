@@ -0,0 +1,170 @@
+package avro
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Encodes a long with the zig-zag varint encoding
+func encodeLong(v int64) []byte {
+	var buf bytes.Buffer
+	if err := writeLong(v, &buf); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// The decoders wrap the LengthError with the path of the field
+func cause(err error) error {
+	if e, ok := err.(*DecodeError); ok {
+		return e.Err
+	}
+	return err
+}
+
+func TestLimitsAllowValidInput(t *testing.T) {
+	blob := &Blob{Name: "a", Data: []byte{1, 2, 3}, Parts: []interface{}{nil, nil}, Attributes: map[string]int32{"k": 1}}
+	var buf bytes.Buffer
+	if err := blob.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DeserializeBlob(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, blob, decoded)
+}
+
+func TestNegativeLengths(t *testing.T) {
+	_, err := DeserializeBlob(bytes.NewReader(encodeLong(-5)))
+	assert.Equal(t, &LengthError{Type: "string", Length: -5, Limit: MaxStringLength}, cause(err))
+	assert.EqualError(t, err, "Error reading string at Blob.name (offset 1): Invalid negative string length -5")
+
+	_, err = DeserializeBlob(bytes.NewReader(concat(encodeLong(0), encodeLong(-1))))
+	assert.EqualError(t, cause(err), "Invalid negative bytes length -1")
+}
+
+func TestHostileLengths(t *testing.T) {
+	// A length of 2^40 is rejected before anything is allocated
+	_, err := DeserializeBlob(bytes.NewReader(encodeLong(1 << 40)))
+	assert.Equal(t, &LengthError{Type: "string", Length: 1 << 40, Limit: MaxStringLength}, cause(err))
+
+	_, err = DeserializeBlob(bytes.NewReader(concat(encodeLong(0), encodeLong(1<<40))))
+	assert.EqualError(t, cause(err), "bytes length 1099511627776 exceeds the limit of 134217728")
+
+	// An array of nulls takes no space, so its count alone could keep the decoder busy
+	_, err = DeserializeBlob(bytes.NewReader(concat(encodeLong(0), encodeLong(0), encodeLong(1<<40))))
+	assert.Equal(t, &LengthError{Type: "array", Length: 1 << 40, Limit: MaxArrayLength}, cause(err))
+}
+
+func TestLimitOverBlocks(t *testing.T) {
+	defer func(limit int64) { MaxMapLength = limit }(MaxMapLength)
+	MaxMapLength = 3
+
+	// A block of two entries with its size in bytes, then a block of two more
+	entry := concat(encodeLong(1), []byte{'k'}, encodeLong(1))
+	data := concat(encodeLong(0), encodeLong(0), encodeLong(0), encodeLong(-2), encodeLong(6), entry, entry, encodeLong(2), entry, entry, encodeLong(0))
+	_, err := DeserializeBlob(bytes.NewReader(data))
+	assert.Equal(t, &LengthError{Type: "map", Length: 4, Limit: 3}, cause(err))
+}
+
+func TestConfiguredLimits(t *testing.T) {
+	defer func(limit int64) { MaxArrayLength = limit }(MaxArrayLength)
+
+	blob := &Blob{Parts: []interface{}{nil, nil, nil}, Attributes: map[string]int32{}}
+	var buf bytes.Buffer
+	if err := blob.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	MaxArrayLength = 2
+	_, err := DeserializeBlob(bytes.NewReader(data))
+	assert.EqualError(t, cause(err), "array length 3 exceeds the limit of 2")
+
+	// A limit of 0 disables the check
+	MaxArrayLength = 0
+	decoded, err := DeserializeBlob(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.Len(t, decoded.Parts, 3)
+}
+
+func chain(length int) *Node {
+	var head *Node
+	for i := 0; i < length; i++ {
+		next := UnionNullNode{UnionType: UnionNullNodeTypeEnumNull}
+		if head != nil {
+			next = UnionNullNode{Node: head, UnionType: UnionNullNodeTypeEnumNode}
+		}
+		head = &Node{Value: int32(i), Next: next}
+	}
+	return head
+}
+
+func TestDepthLimit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := chain(MaxDepth).Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := append([]byte{}, buf.Bytes()...)
+	decoded, _, err := UnmarshalNode(data)
+	assert.Nil(t, err)
+	assert.Equal(t, chain(MaxDepth), decoded)
+
+	buf.Reset()
+	if err := chain(MaxDepth + 1).Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = UnmarshalNode(buf.Bytes())
+	assert.Equal(t, &DepthError{Limit: MaxDepth}, cause(err))
+
+	// Every record in a long run of 0x02 bytes holds another one
+	d := NewDecoder(bytes.NewReader(bytes.Repeat([]byte{2}, 1<<20)))
+	_, err = DeserializeNode(d)
+	assert.Equal(t, &DepthError{Limit: MaxDepth}, cause(err))
+	assert.EqualError(t, cause(err), "Nesting exceeds the limit of 128")
+	err = DeserializeNodeInto(bytes.NewReader(bytes.Repeat([]byte{2}, 1<<20)), &Node{})
+	assert.Equal(t, &DepthError{Limit: MaxDepth}, cause(err))
+
+	// The depth is back to 0 after an error, so the Decoder can read the records after it
+	d = NewDecoder(bytes.NewReader(append(bytes.Repeat([]byte{2}, 2*MaxDepth), data...)))
+	_, err = DeserializeNode(d)
+	assert.Equal(t, &DepthError{Limit: MaxDepth}, cause(err))
+	_, err = DeserializeNode(d)
+	assert.Nil(t, err)
+}
+
+// The number of bytes allocated by f
+func allocated(f func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	f()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+func TestTruncatedLengthsDontAllocate(t *testing.T) {
+	// Lengths under the limits, for values which aren't in the input
+	longString := concat(encodeLong(100<<20), []byte("abc"))
+	longBytes := concat(encodeLong(1), []byte("a"), encodeLong(100<<20), []byte{1, 2, 3})
+	for _, data := range [][]byte{longString, longBytes} {
+		var err error
+		assert.True(t, allocated(func() { _, _, err = UnmarshalBlob(data) }) < 1<<20)
+		assert.Equal(t, io.ErrUnexpectedEOF, cause(err))
+		assert.True(t, allocated(func() { _, err = DeserializeBlob(bytes.NewReader(data)) }) < 1<<20)
+		assert.Equal(t, io.ErrUnexpectedEOF, cause(err))
+		// Reused records allocate the same way when their fields are too small for the value
+		assert.True(t, allocated(func() { err = DeserializeBlobInto(bytes.NewReader(data), &Blob{}) }) < 1<<20)
+		assert.Equal(t, io.ErrUnexpectedEOF, cause(err))
+		assert.True(t, allocated(func() { err = DeserializeBlobInto(newSliceDecoder(data, false), &Blob{}) }) < 1<<20)
+		assert.Equal(t, io.ErrUnexpectedEOF, cause(err))
+	}
+}
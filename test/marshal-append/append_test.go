@@ -0,0 +1,111 @@
+package avro
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fixtureEvents() []*Event {
+	return []*Event{
+		{
+			Source:   &Source{},
+			Tags:     []string{},
+			Counters: map[string]int64{},
+			Parent:   UnionNullSourceLong{UnionType: UnionNullSourceLongTypeEnumNull},
+		},
+		{
+			ID:       -1 << 62,
+			Count:    -2147483648,
+			Ratio:    1.5,
+			Score:    -2.5,
+			Active:   true,
+			Name:     strings.Repeat("n", 200),
+			Body:     bytes.Repeat([]byte{1}, 70000),
+			Digest:   Digest{1, 2, 3},
+			Level:    WARN,
+			Source:   &Source{Host: "localhost", Port: 8080},
+			Tags:     []string{"a", "", strings.Repeat("t", 64)},
+			Counters: map[string]int64{"z": 1 << 56},
+			Parent:   UnionNullSourceLong{Source: &Source{Host: "h"}, UnionType: UnionNullSourceLongTypeEnumSource},
+		},
+		{
+			Source:   &Source{Port: -65},
+			Tags:     []string{},
+			Counters: map[string]int64{"x": 64},
+			Parent:   UnionNullSourceLong{Long: 9223372036854775807, UnionType: UnionNullSourceLongTypeEnumLong},
+		},
+	}
+}
+
+func serialize(t *testing.T, event *Event) []byte {
+	var buf bytes.Buffer
+	if err := event.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestEncodedSize(t *testing.T) {
+	for _, event := range fixtureEvents() {
+		assert.Equal(t, len(serialize(t, event)), event.EncodedSize())
+	}
+}
+
+func TestMarshalAppend(t *testing.T) {
+	for _, event := range fixtureEvents() {
+		expected := serialize(t, event)
+
+		data, err := event.MarshalAppend(nil)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, data)
+
+		// The encoding is appended after what's already in dst
+		data, err = event.MarshalAppend([]byte{0xff})
+		assert.Nil(t, err)
+		assert.Equal(t, append([]byte{0xff}, expected...), data)
+	}
+}
+
+func TestMarshalAppendExactSize(t *testing.T) {
+	for _, event := range fixtureEvents() {
+		dst := make([]byte, 0, event.EncodedSize())
+		data, err := event.MarshalAppend(dst)
+		assert.Nil(t, err)
+		assert.Equal(t, len(data), cap(data))
+		// No reallocation means the encoding went straight into dst
+		assert.Equal(t, &dst[:1][0], &data[0])
+	}
+}
+
+func TestMarshalAppendError(t *testing.T) {
+	event := fixtureEvents()[0]
+	event.Parent.UnionType = 7
+	dst := []byte{1, 2}
+	data, err := event.MarshalAppend(dst)
+	assert.EqualError(t, err, "Invalid value for UnionNullSourceLong")
+	assert.Equal(t, dst, data)
+}
+
+func BenchmarkMarshalAppend(b *testing.B) {
+	event := fixtureEvents()[2]
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := event.MarshalAppend(make([]byte, 0, event.EncodedSize())); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSerializeBuffer(b *testing.B) {
+	event := fixtureEvents()[2]
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var buf bytes.Buffer
+		if err := event.Serialize(&buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+package avro
+
+//go:generate $GOPATH/bin/gogen-avro --namespace-packages=github.com/alanctgardner/gogen-avro/test/namespace-packages . order.avsc receipt.avsc
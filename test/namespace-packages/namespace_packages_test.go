@@ -0,0 +1,88 @@
+// The namespace packages are imported by this package, so the test is outside it
+package avro_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/generator"
+	avro "github.com/alanctgardner/gogen-avro/test/namespace-packages"
+	"github.com/alanctgardner/gogen-avro/test/namespace-packages/com/example/geo"
+	"github.com/alanctgardner/gogen-avro/test/namespace-packages/com/example/people"
+	"github.com/alanctgardner/gogen-avro/test/namespace-packages/com/example/shop"
+	"github.com/alanctgardner/gogen-avro/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func fixtureOrder() *shop.Order {
+	return &shop.Order{
+		ID:   shop.OrderID{5, 6, 7, 8},
+		User: &shop.User{Login: "manager"},
+		Customer: &people.Customer{
+			User:    &people.User{Email: "a@example.com", Tier: people.GOLD},
+			Address: people.NewUnionNullComExampleGeoAddress(&geo.Address{City: "Leeds"}),
+		},
+		Tier:       people.BASIC,
+		Deliveries: []*geo.Address{{City: "York"}},
+		Contact:    shop.NewUnionNullComExamplePeopleCustomerComExampleGeoAddressComExampleGeoAddress(&geo.Address{City: "Hull"}),
+		Total:      12.5,
+	}
+}
+
+func TestNamespacePackages(t *testing.T) {
+	// Records with the same name in different namespaces are different types in different packages
+	receipt := &avro.Receipt{Order: fixtureOrder(), Paid: true}
+	var buf bytes.Buffer
+	assert.Nil(t, receipt.Serialize(&buf))
+	assert.Equal(t, buf.Len(), receipt.EncodedSize())
+	decoded, err := avro.DeserializeReceipt(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	assert.True(t, receipt.Equals(decoded))
+	assert.Equal(t, shop.User{Login: "manager"}, *decoded.Order.User)
+	assert.Equal(t, "GOLD", decoded.Order.Customer.User.Tier.String())
+
+	into := &avro.Receipt{Order: &shop.Order{}}
+	assert.Nil(t, avro.DeserializeReceiptInto(bytes.NewReader(buf.Bytes()), into))
+	assert.True(t, receipt.Equals(into))
+
+	// The records of another namespace's package are encoded as JSON by their own methods
+	data, err := receipt.MarshalAvroJSON()
+	assert.Nil(t, err)
+	fromJSON := &avro.Receipt{}
+	assert.Nil(t, fromJSON.UnmarshalAvroJSON(data))
+	assert.True(t, receipt.Equals(fromJSON))
+}
+
+func TestNamespacePackagesCompareAndCopy(t *testing.T) {
+	order := fixtureOrder()
+	clone := order.Clone()
+	clone.Customer.User.Email = "b@example.com"
+	clone.Deliveries[0].City = "Leeds"
+	assert.Equal(t, "a@example.com", order.Customer.User.Email)
+	assert.Equal(t, "York", order.Deliveries[0].City)
+
+	// Paths go through the records of other packages
+	assert.Equal(t, []shop.FieldDiff{
+		{Path: "customer.user.email", Old: "a@example.com", New: "b@example.com"},
+		{Path: "deliveries[0].city", Old: "York", New: "Leeds"},
+	}, order.Diff(clone))
+	assert.Equal(t, -1, order.Compare(clone))
+
+	order.Customer.User.Tier = 5
+	assert.EqualError(t, order.ValidateAll(), "customer.user.tier: Invalid value 5 for enum com.example.people.Tier")
+}
+
+func TestNamespaceCyclesRejected(t *testing.T) {
+	// Customer refers to Address, which refers back to the namespace of Customer
+	schema := `{"type": "record", "name": "Customer", "namespace": "com.example.people", "fields": [
+		{"name": "address", "type": {"type": "record", "name": "Address", "namespace": "com.example.geo", "fields": [
+			{"name": "residents", "type": {"type": "array", "items": {"type": "record", "name": "Resident", "namespace": "com.example.people", "fields": []}}}
+		]}}
+	]}`
+	namespace := types.NewNamespace()
+	namespace.NamespacePackages = "example.com/avro"
+	if _, err := namespace.FieldDefinitionForSchema([]byte(schema)); err != nil {
+		t.Fatal(err)
+	}
+	assert.EqualError(t, namespace.AddNamespacePackages(generator.NewPackage("avro")), "The namespaces com.example.geo -> com.example.people -> com.example.geo refer to each other in a cycle, so their packages would import each other (com.example.geo.Address refers to com.example.people.Resident, com.example.people.Customer refers to com.example.geo.Address)")
+}
@@ -0,0 +1,121 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func fixtureProfile() *Profile {
+	nickname := "ace"
+	age := int32(30)
+	avatar := []byte{1, 2}
+	score := int64(7)
+	flag := true
+	return &Profile{
+		Nickname: &nickname,
+		Age:      &age,
+		Avatar:   &avatar,
+		Address:  &Address{City: "Oslo"},
+		Key:      &Key{0xca, 0xfe},
+		Scores:   []*int64{&score, nil},
+		Flags:    map[string]*bool{"admin": &flag},
+		Contact:  UnionNullStringLong{Long: 5, UnionType: UnionNullStringLongTypeEnumLong},
+	}
+}
+
+func roundTrip(t *testing.T, profile *Profile) []byte {
+	var buf bytes.Buffer
+	if err := profile.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	decoded, err := DeserializeProfile(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, profile, decoded)
+	return data
+}
+
+func TestOptionalPointersRoundTrip(t *testing.T) {
+	roundTrip(t, fixtureProfile())
+	roundTrip(t, &Profile{
+		Scores: []*int64{},
+		Flags:  map[string]*bool{},
+	})
+}
+
+func TestOptionalPointersEncoding(t *testing.T) {
+	data := roundTrip(t, &Profile{
+		Scores: []*int64{nil},
+		Flags:  map[string]*bool{"x": nil},
+	})
+	expected := []byte{
+		0,
+		// null is the second branch of age and flags
+		2,
+		0, 0, 0,
+		2, 0, 0,
+		2, 2, 'x', 2, 0,
+		0,
+	}
+	assert.Equal(t, expected, data)
+}
+
+func TestOptionalPointersGenericParity(t *testing.T) {
+	codec, err := types.NewGenericCodec([]byte(fixtureProfile().Schema()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := codec.Unmarshal(roundTrip(t, fixtureProfile()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := value.(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"string": "ace"}, profile["nickname"])
+	assert.Equal(t, map[string]interface{}{"int": int32(30)}, profile["age"])
+	assert.Equal(t, map[string]interface{}{"com.example.optional.Address": map[string]interface{}{"city": "Oslo"}}, profile["address"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"long": int64(7)}, nil}, profile["scores"])
+}
+
+func TestOptionalPointersJSON(t *testing.T) {
+	data, err := fixtureProfile().MarshalAvroJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `{"nickname":{"string":"ace"},"age":{"int":30},"avatar":{"bytes":"\u0001\u0002"},"address":{"com.example.optional.Address":{"city":"Oslo"}},"key":{"com.example.optional.Key":"Êþ"},"scores":[{"long":7},null],"flags":{"admin":{"boolean":true}},"contact":{"long":5}}`, string(data))
+
+	decoded := &Profile{}
+	if err := decoded.UnmarshalAvroJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fixtureProfile(), decoded)
+
+	// Missing fields take their defaults, and the default of age is for its int branch
+	defaulted := &Profile{}
+	if err := defaulted.UnmarshalAvroJSON([]byte(`{"avatar":null,"address":null,"key":null,"scores":[],"flags":{},"contact":null}`)); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, defaulted.Nickname)
+	assert.Equal(t, int32(0), *defaulted.Age)
+}
+
+func TestOptionalPointersSchema(t *testing.T) {
+	// The schema is unchanged by the representation
+	schema := fixtureProfile().Schema()
+	assert.Contains(t, schema, `{"default":null,"name":"nickname","type":["null","string"]}`)
+	assert.Contains(t, schema, `{"default":0,"name":"age","type":["int","null"]}`)
+}
+
+func TestOptionalPointersEncodedSize(t *testing.T) {
+	for _, profile := range []*Profile{fixtureProfile(), {Scores: []*int64{nil}, Flags: map[string]*bool{"x": nil}}} {
+		data, err := profile.MarshalAppend(nil)
+		assert.Nil(t, err)
+		assert.Equal(t, roundTrip(t, profile), data)
+		assert.Equal(t, len(data), profile.EncodedSize())
+	}
+}
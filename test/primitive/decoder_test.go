@@ -0,0 +1,93 @@
+package avro
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Hides the io.ByteReader implementation of the underlying reader
+type plainReader struct {
+	r io.Reader
+}
+
+func (p *plainReader) Read(b []byte) (int, error) {
+	return p.r.Read(b)
+}
+
+func fixtureStream(t testing.TB, records []PrimitiveTestRecord) []byte {
+	var buf bytes.Buffer
+	for _, r := range records {
+		if err := r.Serialize(&buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func fixtureRecords() []PrimitiveTestRecord {
+	return []PrimitiveTestRecord{
+		{1, 2, 3.4, 5.6, "789", true, []byte{1, 2, 3, 4}},
+		{-1, 1 << 40, 0, -1, string(make([]byte, 100<<10)), false, []byte{}},
+		{2147483647, -9223372036854775807, 1, 2, "", true, []byte{5}},
+	}
+}
+
+func TestDecoderStream(t *testing.T) {
+	records := fixtureRecords()
+	d := NewDecoder(&plainReader{bytes.NewReader(fixtureStream(t, records))})
+	for _, expected := range records {
+		decoded, err := DeserializePrimitiveTestRecord(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, expected, *decoded)
+	}
+	_, err := DeserializePrimitiveTestRecord(d)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, int64(len(fixtureStream(t, records))), d.Offset())
+}
+
+func TestDeserializeUnbuffered(t *testing.T) {
+	// Without a Decoder, a reader which isn't an io.ByteReader is read one value at a time, so consecutive records can be read from it
+	records := fixtureRecords()
+	r := &plainReader{bytes.NewReader(fixtureStream(t, records))}
+	for _, expected := range records {
+		decoded, err := DeserializePrimitiveTestRecord(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, expected, *decoded)
+	}
+}
+
+func BenchmarkDeserializePrimitiveRecordDecoder(b *testing.B) {
+	record := PrimitiveTestRecord{1, 2, 3.4, 5.6, "789", true, []byte{1, 2, 3, 4}}
+	recordBytes := fixtureStream(b, []PrimitiveTestRecord{record})
+	stream := bytes.Repeat(recordBytes, b.N)
+	d := NewDecoder(bytes.NewReader(stream))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := DeserializePrimitiveTestRecord(d)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDeserializePrimitiveRecordBuffered(b *testing.B) {
+	record := PrimitiveTestRecord{1, 2, 3.4, 5.6, "789", true, []byte{1, 2, 3, 4}}
+	stream := bytes.Repeat(fixtureStream(b, []PrimitiveTestRecord{record}), b.N)
+	d := NewDecoder(&plainReader{bytes.NewReader(stream)})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := DeserializePrimitiveTestRecord(d)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
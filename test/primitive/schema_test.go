@@ -136,6 +136,7 @@ func BenchmarkDeserializePrimitiveRecord(b *testing.B) {
 		b.Fatal(err)
 	}
 	recordBytes := buf.Bytes()
+	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		bb := bytes.NewBuffer(recordBytes)
 		_, err := DeserializePrimitiveTestRecord(bb)
@@ -172,6 +173,7 @@ func BenchmarkDeserializePrimitiveGoavro(b *testing.B) {
 		b.Fatal(err)
 	}
 	recordBytes := buf.Bytes()
+	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		bb := bytes.NewBuffer(recordBytes)
 		_, err := codec.Decode(bb)
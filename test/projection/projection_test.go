@@ -0,0 +1,143 @@
+package avro
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func writerCodec(t testing.TB) *types.GenericCodec {
+	schema, err := ioutil.ReadFile("writer.avsc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	codec, err := types.NewGenericCodec(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return codec
+}
+
+func writerEvent(payload int) map[string]interface{} {
+	source := map[string]interface{}{"host": "db", "port": int32(5432), "key": []byte("01234567")}
+	last := map[string]interface{}{"label": "second", "weight": int32(2), "next": nil}
+	chain := map[string]interface{}{"label": "first", "weight": int32(1), "next": map[string]interface{}{"com.example.projection.Link": last}}
+	tags := make([]interface{}, 0)
+	for i := 0; i < payload; i++ {
+		tags = append(tags, strings.Repeat("t", payload))
+	}
+	return map[string]interface{}{
+		"id":         int64(42),
+		"name":       strings.Repeat("n", payload),
+		"payload":    bytes.Repeat([]byte{1}, payload),
+		"source":     source,
+		"tags":       tags,
+		"attributes": map[string]interface{}{"a": 1.5},
+		"level":      "INFO",
+		"history": []interface{}{
+			map[string]interface{}{"at": int64(1), "note": "created", "active": true},
+			map[string]interface{}{"at": int64(2), "note": strings.Repeat("x", payload), "active": false},
+		},
+		"origin": map[string]interface{}{"com.example.projection.Source": source},
+		"chain":  chain,
+		"ratio":  float32(0.5),
+		"score":  2.5,
+		"extra":  map[string]interface{}{"long": int64(7)},
+	}
+}
+
+func encodeWriter(t testing.TB, value map[string]interface{}) []byte {
+	data, err := writerCodec(t).Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestProjection(t *testing.T) {
+	data := encodeWriter(t, writerEvent(3))
+	event, err := DeserializeEventFromWriter(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Event{
+		Score:   2.5,
+		ID:      42,
+		Source:  &Source{Host: "db"},
+		History: []*Change{{At: 1}, {At: 2}},
+		Origin:  UnionNullStringSource{Source: &Source{Host: "db"}, UnionType: UnionNullStringSourceTypeEnumSource},
+		// The recursive record is projected onto itself at every level
+		Chain: &Link{
+			Label: "first",
+			Next:  UnionNullLink{Link: &Link{Label: "second", Next: UnionNullLink{UnionType: UnionNullLinkTypeEnumNull}}, UnionType: UnionNullLinkTypeEnumLink},
+		},
+	}
+	assert.Equal(t, expected, event)
+
+	// The whole input is consumed
+	d := NewDecoder(bytes.NewReader(append(data, data...)))
+	for i := 0; i < 2; i++ {
+		event, err = DeserializeEventFromWriter(d)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, event)
+	}
+	assert.Equal(t, int64(2*len(data)), d.Offset())
+}
+
+func TestProjectionSkipsWithoutAllocating(t *testing.T) {
+	// Both sizes are larger than the Decoder's fixed buffer, so each needs the same scratch space
+	small := encodeWriter(t, writerEvent(100))
+	large := encodeWriter(t, writerEvent(1000))
+	allocs := func(data []byte) float64 {
+		return testing.AllocsPerRun(10, func() {
+			if _, err := DeserializeEventFromWriter(bytes.NewReader(data)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	assert.Equal(t, allocs(small), allocs(large))
+}
+
+func TestSkipBlockByteSize(t *testing.T) {
+	var block bytes.Buffer
+	writeLong(-2, &block)
+	// The byte size covers items which aren't valid strings, so they can only be skipped using it
+	writeLong(3, &block)
+	block.Write([]byte{0xff, 0xff, 0xff})
+	writeLong(1, &block)
+	writeString("abc", &block)
+	writeLong(0, &block)
+
+	d := NewDecoder(bytes.NewReader(block.Bytes()))
+	assert.Nil(t, skipArrayString(d))
+	assert.Equal(t, int64(block.Len()), d.Offset())
+
+	// The same data from a slice
+	d = newSliceDecoder(block.Bytes(), false)
+	assert.Nil(t, skipArrayString(d))
+	assert.Equal(t, int64(block.Len()), d.Offset())
+}
+
+func TestProjectionErrors(t *testing.T) {
+	data := encodeWriter(t, writerEvent(3))
+	// Stop in the middle of the skipped name
+	_, err := DeserializeEventFromWriter(bytes.NewReader(data[:3]))
+	assert.EqualError(t, err, "Error reading string at Event.name (offset 3): unexpected EOF")
+
+	_, err = DeserializeEventFromWriter(bytes.NewReader(data[:len(data)-10]))
+	assert.Equal(t, "Event.score", err.(*DecodeError).Path)
+}
+
+func BenchmarkProjection(b *testing.B) {
+	data := encodeWriter(b, writerEvent(100))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := DeserializeEventFromWriter(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
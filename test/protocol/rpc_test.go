@@ -0,0 +1,68 @@
+package avro
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingMailServer struct {
+	mailServer
+}
+
+func (f *failingMailServer) Count(request *MailCountRequest) (int64, error) {
+	return 0, fmt.Errorf("mailbox %v is unavailable", request.To)
+}
+
+func newMailClient(t *testing.T, impl Mail) (*MailClient, func()) {
+	server, err := NewMailServer(impl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	client, err := NewMailClient(rpc.NewHTTPTransceiver(httpServer.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, httpServer.Close
+}
+
+func TestRPCRoundTrip(t *testing.T) {
+	impl := &mailServer{}
+	client, closeServer := newMailClient(t, impl)
+	defer closeServer()
+
+	// The client implements the protocol interface
+	var mail Mail = client
+
+	status, err := mail.Send(&MailSendRequest{Message: &Message{To: "a", From: "b", Body: "hi"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "delivered", status)
+
+	count, err := mail.Count(&MailCountRequest{To: "a"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	assert.Nil(t, mail.Ping(&MailPingRequest{}))
+	assert.Nil(t, mail.Clear(&MailClearRequest{To: "a"}))
+	assert.Len(t, impl.sent, 0)
+}
+
+func TestRPCDeclaredError(t *testing.T) {
+	client, closeServer := newMailClient(t, &mailServer{})
+	defer closeServer()
+
+	_, err := client.Send(&MailSendRequest{Message: &Message{To: "a", From: "b"}})
+	assert.Equal(t, &Curse{Message: "empty body"}, err)
+}
+
+func TestRPCStringError(t *testing.T) {
+	client, closeServer := newMailClient(t, &failingMailServer{})
+	defer closeServer()
+
+	_, err := client.Count(&MailCountRequest{To: "a"})
+	assert.Equal(t, &rpc.Error{Message: "mailbox a is unavailable"}, err)
+}
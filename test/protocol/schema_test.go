@@ -0,0 +1,101 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mailServer struct {
+	sent []*Message
+}
+
+func (m *mailServer) Send(request *MailSendRequest) (string, error) {
+	if request.Message.Body == "" {
+		return "", &Curse{Message: "empty body"}
+	}
+	m.sent = append(m.sent, request.Message)
+	return "delivered", nil
+}
+
+func (m *mailServer) Count(request *MailCountRequest) (int64, error) {
+	return int64(len(m.sent)), nil
+}
+
+func (m *mailServer) Clear(request *MailClearRequest) error {
+	m.sent = nil
+	return nil
+}
+
+func (m *mailServer) Ping(request *MailPingRequest) error {
+	return nil
+}
+
+func TestProtocolInterface(t *testing.T) {
+	var server Mail = &mailServer{}
+
+	status, err := server.Send(&MailSendRequest{Message: &Message{To: "a", From: "b", Body: "hi"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "delivered", status)
+
+	_, err = server.Send(&MailSendRequest{Message: &Message{To: "a", From: "b"}})
+	curse, ok := err.(*Curse)
+	assert.True(t, ok)
+	assert.Equal(t, "empty body", curse.Message)
+	assert.Contains(t, err.Error(), "empty body")
+}
+
+func TestRequestRoundTrip(t *testing.T) {
+	request := &MailCountRequest{To: "someone", Unread: true}
+	var buf bytes.Buffer
+	if err := request.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	// The request record encodes exactly like the parameter list
+	assert.Equal(t, []byte{14, 's', 'o', 'm', 'e', 'o', 'n', 'e', 1}, buf.Bytes())
+
+	decoded, err := DeserializeMailCountRequest(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, request, decoded)
+}
+
+func TestEmptyRequestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&MailPingRequest{}).Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, buf.Len())
+
+	decoded, err := DeserializeMailPingRequest(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &MailPingRequest{}, decoded)
+}
+
+func TestErrorUnion(t *testing.T) {
+	errors := UnionStringCurse{Curse: &Curse{Message: "boom"}, UnionType: UnionStringCurseTypeEnumCurse}
+	var buf bytes.Buffer
+	if err := writeUnionStringCurse(errors, &buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := readUnionStringCurse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, errors, decoded)
+}
+
+func TestProtocolJSON(t *testing.T) {
+	var protocol map[string]interface{}
+	if err := json.Unmarshal([]byte(MailProtocol()), &protocol); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Mail", protocol["protocol"])
+	assert.Equal(t, "com.example.mail", protocol["namespace"])
+	assert.Len(t, protocol["messages"], 4)
+}
@@ -0,0 +1,75 @@
+package avro
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func hash(t *testing.T, d *Document) [32]byte {
+	var buf bytes.Buffer
+	assert.Nil(t, d.Serialize(&buf))
+	return sha256.Sum256(buf.Bytes())
+}
+
+func TestSortedKeysField(t *testing.T) {
+	headers := make(map[string]string)
+	for i := 0; i < 50; i++ {
+		headers[fmt.Sprint("header-", i)] = fmt.Sprint(i)
+	}
+	doc := &Document{Title: "doc", Headers: headers, Meta: map[string]float64{"size": 1}, Footer: UnionNullMapString{UnionType: UnionNullMapStringTypeEnumNull}}
+	first := hash(t, doc)
+	for i := 0; i < 10; i++ {
+		// Equal maps built in a different order encode the same way
+		copied := make(map[string]string)
+		for k, v := range headers {
+			copied[k] = v
+		}
+		assert.Equal(t, first, hash(t, &Document{Title: "doc", Headers: copied, Meta: map[string]float64{"size": 1}, Footer: UnionNullMapString{UnionType: UnionNullMapStringTypeEnumNull}}))
+	}
+
+	var buf bytes.Buffer
+	assert.Nil(t, doc.Serialize(&buf))
+	decoded, err := DeserializeDocument(&buf)
+	assert.Nil(t, err)
+	assert.True(t, doc.Equals(decoded))
+}
+
+func TestSortedKeysSchema(t *testing.T) {
+	// The option is kept in the schema, and left out of the canonical form
+	assert.Contains(t, (&Document{}).Schema(), `"sorted_keys":true`)
+	assert.NotContains(t, (&Document{}).CanonicalSchema(), "sorted_keys")
+}
+
+func TestSortedKeysNested(t *testing.T) {
+	// The maps in pages and footer have sorted keys, and the maps of the same types in drafts and notes don't
+	entries := func() map[string]string {
+		m := make(map[string]string)
+		for i := 0; i < 50; i++ {
+			m[fmt.Sprint("entry-", i)] = fmt.Sprint(i)
+		}
+		return m
+	}
+	doc := func() *Document {
+		return &Document{
+			Pages:  []map[string]string{entries(), entries()},
+			Footer: UnionNullMapString{MapString: entries(), UnionType: UnionNullMapStringTypeEnumMapString},
+		}
+	}
+	first := hash(t, doc())
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, first, hash(t, doc()))
+	}
+
+	unsorted := doc()
+	unsorted.Drafts = []map[string]string{entries()}
+	unsorted.Notes = UnionNullMapString{MapString: entries(), UnionType: UnionNullMapStringTypeEnumMapString}
+	var buf bytes.Buffer
+	assert.Nil(t, unsorted.Serialize(&buf))
+	decoded, err := DeserializeDocument(&buf)
+	assert.Nil(t, err)
+	assert.True(t, unsorted.Equals(decoded))
+}
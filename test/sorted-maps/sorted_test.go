@@ -0,0 +1,67 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func inventory() *Inventory {
+	inv := &Inventory{
+		Counts:    make(map[string]int64),
+		Locations: make(map[string]map[string]string),
+		Scratch:   map[string]int32{"x": 1, "y": 2},
+	}
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("item-%02d", 19-i)
+		inv.Counts[key] = int64(i)
+		inv.Locations[key] = map[string]string{"b": "shelf", "a": "aisle", "c": key}
+	}
+	return inv
+}
+
+func TestSortedMapEncoding(t *testing.T) {
+	inv := inventory()
+	inv.Scratch = nil
+	var expected bytes.Buffer
+	writeLong(20, &expected)
+	for i := 0; i < 20; i++ {
+		writeString(fmt.Sprintf("item-%02d", i), &expected)
+		writeLong(int64(19-i), &expected)
+	}
+	writeLong(0, &expected)
+	writeLong(20, &expected)
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("item-%02d", i)
+		writeString(key, &expected)
+		writeLong(3, &expected)
+		for _, kv := range [][2]string{{"a", "aisle"}, {"b", "shelf"}, {"c", key}} {
+			writeString(kv[0], &expected)
+			writeString(kv[1], &expected)
+		}
+		writeLong(0, &expected)
+	}
+	writeLong(0, &expected)
+	writeLong(0, &expected)
+
+	for i := 0; i < 10; i++ {
+		var buf bytes.Buffer
+		assert.Nil(t, inv.Serialize(&buf))
+		assert.Equal(t, expected.Bytes(), buf.Bytes())
+
+		appended, err := inv.MarshalAppend(nil)
+		assert.Nil(t, err)
+		assert.Equal(t, expected.Bytes(), appended)
+	}
+}
+
+func TestSortedMapRoundTrip(t *testing.T) {
+	inv := inventory()
+	var buf bytes.Buffer
+	assert.Nil(t, inv.Serialize(&buf))
+	decoded, err := DeserializeInventory(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, inv, decoded)
+}
@@ -0,0 +1,173 @@
+package avro
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func row(i int) *Row {
+	return &Row{Seq: int64(i), Label: fmt.Sprintf("row %d", i)}
+}
+
+func streamRows(n int) func(*ArrayRowEncoder) error {
+	return func(enc *ArrayRowEncoder) error {
+		for i := 0; i < n; i++ {
+			if err := enc.Encode(row(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Records the size of the largest write, to show the encoder doesn't buffer the whole array
+type maxWriter struct {
+	bytes.Buffer
+	max int
+}
+
+func (w *maxWriter) Write(p []byte) (int, error) {
+	if len(p) > w.max {
+		w.max = len(p)
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestSerializeStreamingArray(t *testing.T) {
+	defer func(size int) { StreamBlockBytes = size }(StreamBlockBytes)
+	StreamBlockBytes = 100
+
+	export := &Export{ID: 7, Totals: map[string]int64{"rows": 50}, Footer: "done"}
+	var buf maxWriter
+	assert.Nil(t, export.SerializeStreamingRows(&buf, streamRows(50)))
+	assert.True(t, buf.max < 200)
+
+	// The rows are in blocks with a negative count and their size in bytes
+	data := buf.Bytes()
+	d := NewDecoder(bytes.NewReader(data[1:]))
+	count, _ := readLong(d)
+	size, _ := readLong(d)
+	assert.True(t, count < 0 && count > -50)
+	assert.True(t, size >= 100)
+
+	decoded, err := DeserializeExport(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), decoded.ID)
+	assert.Equal(t, "done", decoded.Footer)
+	assert.Equal(t, 50, len(decoded.Rows))
+	for i, r := range decoded.Rows {
+		assert.Equal(t, row(i), r)
+	}
+
+	// Other implementations can read the blocks
+	schema, err := ioutil.ReadFile("export.avsc")
+	assert.Nil(t, err)
+	codec, err := types.NewGenericCodec(schema)
+	assert.Nil(t, err)
+	generic, err := codec.Unmarshal(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 50, len(generic.(map[string]interface{})["rows"].([]interface{})))
+}
+
+func TestSerializeStreamingEmpty(t *testing.T) {
+	export := &Export{Rows: []*Row{row(1)}, Totals: map[string]int64{}}
+	var streamed, serialized bytes.Buffer
+	assert.Nil(t, export.SerializeStreamingRows(&streamed, streamRows(0)))
+	export.Rows = nil
+	assert.Nil(t, export.Serialize(&serialized))
+	assert.Equal(t, serialized.Bytes(), streamed.Bytes())
+}
+
+func TestSerializeStreamingMap(t *testing.T) {
+	export := &Export{Rows: []*Row{row(1), row(2)}, Totals: map[string]int64{"ignored": 1}}
+	var buf bytes.Buffer
+	err := export.SerializeStreamingTotals(&buf, func(enc *MapLongEncoder) error {
+		for i := 0; i < 3; i++ {
+			if err := enc.Encode(fmt.Sprint("k", i), int64(i)); err != nil {
+				return err
+			}
+			if err := enc.Flush(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+
+	decoded, err := DeserializeExport(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, export.Rows, decoded.Rows)
+	assert.Equal(t, map[string]int64{"k0": 0, "k1": 1, "k2": 2}, decoded.Totals)
+}
+
+func TestSerializeStreamingCallbackError(t *testing.T) {
+	failed := errors.New("failed")
+	export := &Export{}
+	err := export.SerializeStreamingRows(ioutil.Discard, func(enc *ArrayRowEncoder) error {
+		return failed
+	})
+	assert.Equal(t, failed, err)
+}
+
+func TestDeserializeStreamingArray(t *testing.T) {
+	export := &Export{ID: 3, Totals: map[string]int64{"a": 1}, Footer: "end"}
+	var buf bytes.Buffer
+	assert.Nil(t, export.SerializeStreamingRows(&buf, streamRows(1000)))
+
+	var seen int
+	decoded, err := DeserializeExportStreamingRows(&buf, func(r *Row) error {
+		assert.Equal(t, row(seen), r)
+		seen++
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1000, seen)
+	assert.Equal(t, export, decoded)
+}
+
+func TestDeserializeStreamingMap(t *testing.T) {
+	export := &Export{Rows: []*Row{row(1)}, Totals: map[string]int64{"a": 1, "b": 2}}
+	var buf bytes.Buffer
+	assert.Nil(t, export.Serialize(&buf))
+
+	entries := make(map[string]int64)
+	decoded, err := DeserializeExportStreamingTotals(&buf, func(key string, value int64) error {
+		entries[key] = value
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, export.Totals, entries)
+	assert.Nil(t, decoded.Totals)
+	assert.Equal(t, export.Rows, decoded.Rows)
+}
+
+func TestDeserializeStreamingCallbackError(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, (&Export{}).SerializeStreamingRows(&buf, streamRows(10)))
+
+	failed := errors.New("failed")
+	_, err := DeserializeExportStreamingRows(&buf, func(r *Row) error {
+		if r.Seq == 4 {
+			return failed
+		}
+		return nil
+	})
+	assert.Equal(t, "Export.rows[4]", err.(*DecodeError).Path)
+	assert.True(t, errors.Is(err, failed))
+}
+
+func BenchmarkSerializeStreaming(b *testing.B) {
+	export := &Export{Totals: map[string]int64{}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := export.SerializeStreamingRows(ioutil.Discard, streamRows(1000)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
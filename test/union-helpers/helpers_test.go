@@ -0,0 +1,113 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnionConstructors(t *testing.T) {
+	email := NewUnionNullString("a@example.com")
+	assert.Equal(t, UnionNullString{String: "a@example.com", UnionType: UnionNullStringTypeEnumString}, email)
+	assert.Equal(t, email, NewUnionNullStringString("a@example.com"))
+	assert.Equal(t, UnionNullString{UnionType: UnionNullStringTypeEnumNull}, NewUnionNullStringNull())
+
+	balance := NewUnionIntDoubleNullDouble(1.5)
+	assert.True(t, balance.IsDouble())
+	assert.False(t, balance.IsInt())
+	assert.False(t, balance.IsNull())
+	assert.True(t, NewUnionIntDoubleNullNull().IsNull())
+
+	account := &Account{
+		Email:   email,
+		Balance: balance,
+		Owner:   NewUnionPersonSystemArrayStringMapLongPerson(&Person{Name: "Ada"}),
+	}
+	var buf bytes.Buffer
+	if err := account.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DeserializeAccount(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, account, decoded)
+}
+
+func TestUnionValue(t *testing.T) {
+	assert.Equal(t, "a", NewUnionNullString("a").Value())
+	assert.Nil(t, NewUnionNullStringNull().Value())
+	assert.Equal(t, int32(3), NewUnionIntDoubleNullInt(3).Value())
+	assert.Equal(t, ADMIN, NewUnionPersonSystemArrayStringMapLongSystem(ADMIN).Value())
+	assert.Equal(t, []string{"x"}, NewUnionPersonSystemArrayStringMapLongArrayString([]string{"x"}).Value())
+
+	invalid := UnionIntDoubleNull{Int: 3, UnionType: 7}
+	assert.Nil(t, invalid.Value())
+}
+
+func TestUnionSet(t *testing.T) {
+	var balance UnionIntDoubleNull
+	assert.Nil(t, balance.Set(2.5))
+	assert.Equal(t, NewUnionIntDoubleNullDouble(2.5), balance)
+
+	assert.Nil(t, balance.Set(int32(4)))
+	assert.True(t, balance.IsInt())
+	assert.Equal(t, int32(4), balance.Int)
+
+	assert.Nil(t, balance.Set(nil))
+	assert.True(t, balance.IsNull())
+
+	// The Go type has to match the branch exactly
+	assert.EqualError(t, balance.Set(4), "Invalid type int for UnionIntDoubleNull")
+	assert.EqualError(t, balance.Set("4"), "Invalid type string for UnionIntDoubleNull")
+
+	var owner UnionPersonSystemArrayStringMapLong
+	assert.Nil(t, owner.Set(map[string]int64{"jobs": 2}))
+	assert.True(t, owner.IsMapLong())
+	assert.Nil(t, owner.Set(&Person{Name: "Ada"}))
+	assert.True(t, owner.IsPerson())
+	assert.Nil(t, owner.Set(BATCH))
+	assert.True(t, owner.IsSystem())
+
+	// There's no null branch
+	assert.EqualError(t, owner.Set(nil), "Invalid type <nil> for UnionPersonSystemArrayStringMapLong")
+}
+
+func TestUnionSetResetsOtherBranches(t *testing.T) {
+	var balance UnionIntDoubleNull
+	assert.Nil(t, balance.Set(2.5))
+	assert.Nil(t, balance.Set(int32(4)))
+	assert.Equal(t, NewUnionIntDoubleNullInt(4), balance)
+	assert.Nil(t, balance.Set(nil))
+	assert.Equal(t, NewUnionIntDoubleNullNull(), balance)
+}
+
+func TestUnionHelpersNamedLikeBranches(t *testing.T) {
+	// The branches called Value, Set and IsNull take the names of those helpers, so only the others are generated
+	holder := NewUnionNullValueSetIsNullValue(&Value{Amount: 1})
+	assert.True(t, holder.IsValue())
+	assert.False(t, holder.IsSet())
+	assert.Equal(t, int32(1), holder.Value.Amount)
+
+	a, b := &Account{Holder: holder}, &Account{Holder: NewUnionNullValueSetIsNullSet(&Set{Items: []string{"a"}})}
+	assert.True(t, a.Equals(a.Clone()))
+	assert.False(t, a.Equals(b))
+	assert.Equal(t, []FieldDiff{{Path: "holder", Old: a.Holder.Value, New: b.Holder.Set}}, a.Diff(b))
+}
+
+func TestUnionEquality(t *testing.T) {
+	owner := NewUnionPersonSystemArrayStringMapLongPerson(&Person{Name: "Ada"})
+	assert.True(t, owner.Equals(NewUnionPersonSystemArrayStringMapLongPerson(&Person{Name: "Ada"})))
+	assert.False(t, owner.Equals(NewUnionPersonSystemArrayStringMapLongPerson(&Person{Name: "Bob"})))
+	assert.False(t, owner.Equals(NewUnionPersonSystemArrayStringMapLongSystem(ADMIN)))
+
+	clone := owner.Clone()
+	clone.Person.Name = "Bob"
+	assert.Equal(t, "Ada", owner.Person.Name)
+
+	assert.Equal(t, []FieldDiff{{Path: "name", Old: "Ada", New: "Bob"}}, owner.Diff(clone))
+	assert.Equal(t, []FieldDiff{{Old: owner.Person, New: ADMIN}}, owner.Diff(NewUnionPersonSystemArrayStringMapLongSystem(ADMIN)))
+	assert.Empty(t, NewUnionIntDoubleNullInt(1).Diff(NewUnionIntDoubleNullInt(1)))
+	assert.True(t, NewUnionIntDoubleNullInt(1).Clone().Equals(NewUnionIntDoubleNullInt(1)))
+}
@@ -0,0 +1,175 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func fixtureDrawing() *Drawing {
+	return &Drawing{
+		Title: UnionNullStringString{Value: "hi"},
+		Scale: nil,
+		Shape: UnionCircleSquareBuiltinSquare{Value: &Square{Side: 2}},
+		Layers: []UnionIntString{
+			UnionIntStringInt{Value: 1},
+			UnionIntStringString{Value: "a"},
+		},
+		Tags: map[string]UnionNullBool{"x": nil},
+	}
+}
+
+func TestUnionInterfaceEncoding(t *testing.T) {
+	var buf bytes.Buffer
+	if err := fixtureDrawing().Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{
+		2, 4, 'h', 'i',
+		// null is the second branch of scale
+		2,
+		2, 0, 0, 0, 0, 0, 0, 0, 0x40,
+		4, 0, 2, 2, 2, 'a', 0,
+		2, 2, 'x', 0, 0,
+	}
+	assert.Equal(t, expected, buf.Bytes())
+
+	decoded, err := DeserializeDrawing(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fixtureDrawing(), decoded)
+}
+
+func TestUnionInterfaceBranches(t *testing.T) {
+	shapes := []UnionCircleSquareBuiltin{
+		UnionCircleSquareBuiltinCircle{Value: &Circle{Radius: 1.5}},
+		UnionCircleSquareBuiltinSquare{Value: &Square{Side: 3}},
+		UnionCircleSquareBuiltinBuiltin{Value: LINE},
+	}
+	for _, shape := range shapes {
+		drawing := fixtureDrawing()
+		drawing.Shape = shape
+		drawing.Scale = UnionDoubleNullDouble{Value: 0.5}
+
+		var buf bytes.Buffer
+		if err := drawing.Serialize(&buf); err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DeserializeDrawing(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, drawing, decoded)
+
+		switch s := decoded.Shape.(type) {
+		case UnionCircleSquareBuiltinCircle:
+			assert.Equal(t, 1.5, s.Value.Radius)
+		case UnionCircleSquareBuiltinSquare:
+			assert.Equal(t, float64(3), s.Value.Side)
+		case UnionCircleSquareBuiltinBuiltin:
+			assert.Equal(t, LINE, s.Value)
+		default:
+			t.Fatalf("Unexpected shape %v", s)
+		}
+	}
+}
+
+func TestUnionInterfaceNilWithoutNullBranch(t *testing.T) {
+	drawing := fixtureDrawing()
+	drawing.Shape = nil
+
+	var buf bytes.Buffer
+	err := drawing.Serialize(&buf)
+	assert.EqualError(t, err, "Invalid value for UnionCircleSquareBuiltin")
+}
+
+func TestUnionInterfaceJSON(t *testing.T) {
+	data, err := fixtureDrawing().MarshalAvroJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `{"title":{"string":"hi"},"scale":null,"shape":{"com.example.shapes.Square":{"side":2}},"layers":[{"int":1},{"string":"a"}],"tags":{"x":null}}`, string(data))
+
+	decoded := &Drawing{}
+	if err := decoded.UnmarshalAvroJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fixtureDrawing(), decoded)
+}
+
+type canvasServer struct{}
+
+func (c *canvasServer) Draw(request *CanvasDrawRequest) (int64, error) {
+	switch shape := request.Drawing.Shape.(type) {
+	case UnionCircleSquareBuiltinCircle:
+		return 0, &OutOfInk{Color: "red"}
+	case UnionCircleSquareBuiltinBuiltin:
+		return 0, fmt.Errorf("cannot draw %v", shape.Value)
+	}
+	return 4, nil
+}
+
+func TestUnionInterfaceRPCErrors(t *testing.T) {
+	server, err := NewCanvasServer(&canvasServer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	client, err := NewCanvasClient(rpc.NewHTTPTransceiver(httpServer.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	drawing := fixtureDrawing()
+	lines, err := client.Draw(&CanvasDrawRequest{Drawing: drawing})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), lines)
+
+	drawing.Shape = UnionCircleSquareBuiltinCircle{Value: &Circle{Radius: 1}}
+	_, err = client.Draw(&CanvasDrawRequest{Drawing: drawing})
+	assert.Equal(t, &OutOfInk{Color: "red"}, err)
+
+	drawing.Shape = UnionCircleSquareBuiltinBuiltin{Value: DOT}
+	_, err = client.Draw(&CanvasDrawRequest{Drawing: drawing})
+	assert.Equal(t, &rpc.Error{Message: "cannot draw DOT"}, err)
+}
+
+func TestUnionInterfaceEncodedSize(t *testing.T) {
+	drawing := fixtureDrawing()
+	var buf bytes.Buffer
+	if err := drawing.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, buf.Len(), drawing.EncodedSize())
+
+	data, err := drawing.MarshalAppend(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, buf.Bytes(), data)
+}
+
+func TestUnionInterfacePointerBranches(t *testing.T) {
+	drawing := fixtureDrawing()
+	drawing.Title = &UnionNullStringString{Value: "hi"}
+	drawing.Shape = &UnionCircleSquareBuiltinSquare{Value: &Square{Side: 2}}
+	drawing.Layers[1] = &UnionIntStringString{Value: "a"}
+
+	var buf bytes.Buffer
+	if err := drawing.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var expected bytes.Buffer
+	if err := fixtureDrawing().Serialize(&expected); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, expected.Bytes(), buf.Bytes())
+	assert.Equal(t, buf.Len(), drawing.EncodedSize())
+
+	drawing.Shape = (*UnionCircleSquareBuiltinSquare)(nil)
+	assert.EqualError(t, drawing.Serialize(&buf), "Invalid value for UnionCircleSquareBuiltin")
+}
@@ -0,0 +1,123 @@
+package avro
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fixtureMessage() *Message {
+	return &Message{
+		Key:       "user-1",
+		Payload:   []byte("hello"),
+		Checksum:  Checksum{1, 2, 3, 4},
+		Chunks:    [][]byte{{5, 6}, {}},
+		Headers:   map[string]string{"type": "greeting"},
+		Timestamp: 1 << 40,
+	}
+}
+
+func encode(t testing.TB, message *Message) []byte {
+	var buf bytes.Buffer
+	if err := message.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUnmarshal(t *testing.T) {
+	data := encode(t, fixtureMessage())
+	// Only the first record is decoded
+	stream := append(append([]byte{}, data...), data...)
+
+	message, n, err := UnmarshalMessage(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fixtureMessage(), message)
+	assert.Equal(t, len(data), n)
+
+	message, n, err = UnmarshalMessage(stream[n:])
+	assert.Nil(t, err)
+	assert.Equal(t, fixtureMessage(), message)
+	assert.Equal(t, len(data), n)
+}
+
+func TestUnmarshalCopies(t *testing.T) {
+	data := encode(t, fixtureMessage())
+	message, _, err := UnmarshalMessage(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range data {
+		data[i] = 0
+	}
+	assert.Equal(t, fixtureMessage(), message)
+}
+
+func TestUnmarshalNoCopy(t *testing.T) {
+	data := encode(t, fixtureMessage())
+	message, n, err := UnmarshalMessageNoCopy(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fixtureMessage(), message)
+	assert.Equal(t, len(data), n)
+
+	// Bytes fields alias the input, but strings and fixed values don't
+	offset := bytes.Index(data, []byte("hello"))
+	data[offset] = 'j'
+	assert.Equal(t, []byte("jello"), message.Payload)
+	data[bytes.Index(data, []byte("user-1"))] = 'U'
+	assert.Equal(t, "user-1", message.Key)
+
+	// Appending to an aliased value doesn't overwrite the rest of the input
+	message.Payload = append(message.Payload, '!')
+	assert.Equal(t, byte(1), data[offset+5])
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	data := encode(t, fixtureMessage())
+
+	_, n, err := UnmarshalMessage(data[:len(data)-1])
+	assert.Equal(t, 0, n)
+	assert.Equal(t, &DecodeError{Path: "Message.timestamp", Type: "long", Offset: int64(len(data) - 1), Err: err.(*DecodeError).Err}, err)
+
+	_, _, err = UnmarshalMessageNoCopy(data[:10])
+	assert.EqualError(t, err, "Error reading bytes at Message.payload (offset 10): unexpected EOF")
+
+	_, _, err = UnmarshalMessage(nil)
+	assert.Equal(t, io.EOF, err)
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	data := encode(b, fixtureMessage())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := UnmarshalMessage(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalNoCopy(b *testing.B) {
+	data := encode(b, fixtureMessage())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := UnmarshalMessageNoCopy(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDeserializeFromBytesReader(b *testing.B) {
+	data := encode(b, fixtureMessage())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := DeserializeMessage(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package avro
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/registry"
+	"github.com/stretchr/testify/assert"
+)
+
+/* Records the paths registered with, and the schemas sent to them */
+type registrations struct {
+	lock    sync.Mutex
+	paths   []string
+	schemas []string
+}
+
+func (s *registrations) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request map[string]string
+	json.NewDecoder(r.Body).Decode(&request)
+	s.lock.Lock()
+	s.paths = append(s.paths, r.Method+" "+r.URL.EscapedPath())
+	s.schemas = append(s.schemas, request["schema"])
+	id := len(s.schemas)
+	s.lock.Unlock()
+	w.Header().Set("Content-Type", registry.ContentType)
+	json.NewEncoder(w).Encode(map[string]interface{}{"id": id})
+}
+
+func (s *registrations) log() ([]string, []string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string(nil), s.paths...), append([]string(nil), s.schemas...)
+}
+
+func TestRegisterGeneratedRecord(t *testing.T) {
+	registered := &registrations{}
+	server := httptest.NewServer(registered)
+	defer server.Close()
+
+	client := registry.NewClient(server.URL)
+	id, err := client.RegisterRecord(&UUID{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int32(1), id)
+
+	// Registering again is served from the cache
+	id, err = client.RegisterRecord(&UUID{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int32(1), id)
+
+	paths, schemas := registered.log()
+	assert.Equal(t, []string{"POST /subjects/com.securityscorecard.collections.uuid/versions"}, paths)
+	assert.Equal(t, []string{(&UUID{}).Schema()}, schemas)
+}
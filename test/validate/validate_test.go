@@ -0,0 +1,102 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alanctgardner/gogen-avro/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func fixtureOrder() *Order {
+	return &Order{
+		ID:       1,
+		Customer: &Customer{Name: "Ada"},
+		Status:   SHIPPED,
+		Lines: []*Line{
+			{Sku: "a", Status: UnionNullStatus{UnionType: UnionNullStatusTypeEnumNull}},
+			{Sku: "b", Status: UnionNullStatus{Status: OPEN, UnionType: UnionNullStatusTypeEnumStatus}},
+		},
+		Notes:   map[string]UnionNullString{"gift": {String: "yes", UnionType: UnionNullStringTypeEnumString}},
+		Payment: UnionStringCard{Card: &Card{Number: "4111"}, UnionType: UnionStringCardTypeEnumCard},
+	}
+}
+
+func TestValidOrder(t *testing.T) {
+	order := fixtureOrder()
+	assert.Nil(t, order.Validate())
+	assert.Nil(t, order.ValidateAll())
+
+	var buf bytes.Buffer
+	assert.Nil(t, order.Serialize(&buf))
+	decoded, err := DeserializeOrder(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, order, decoded)
+}
+
+func TestValidateFirstViolation(t *testing.T) {
+	order := fixtureOrder()
+	order.Customer = nil
+	order.Status = 5
+
+	err := order.Validate()
+	assert.Equal(t, &ValidationError{Path: "customer", Message: "Nil value for record com.example.validate.Customer"}, err)
+	assert.EqualError(t, err, "customer: Nil value for record com.example.validate.Customer")
+}
+
+func TestValidateAllViolations(t *testing.T) {
+	order := fixtureOrder()
+	order.Customer = nil
+	order.Status = -1
+	order.Lines[1].Status.Status = 2
+	order.Lines = append(order.Lines, nil)
+	order.Notes["b"] = UnionNullString{UnionType: 9}
+	order.Notes["a"] = UnionNullString{UnionType: 9}
+	order.Payment = UnionStringCard{UnionType: UnionStringCardTypeEnumCard}
+
+	err := order.ValidateAll()
+	errs, ok := err.(ValidationErrors)
+	assert.True(t, ok)
+
+	var paths []string
+	for _, e := range errs {
+		paths = append(paths, e.Path)
+	}
+	assert.Equal(t, []string{"customer", "status", "lines[1].status", "lines[2]", `notes["a"]`, `notes["b"]`, "payment"}, paths)
+	assert.Equal(t, "Invalid value -1 for enum com.example.validate.Status", errs[1].Message)
+	assert.Equal(t, "Invalid UnionType 9 for UnionNullString", errs[4].Message)
+	assert.Contains(t, err.Error(), "lines[2]: Nil value for record com.example.validate.Line; notes")
+}
+
+func TestValidateNilRecord(t *testing.T) {
+	var order *Order
+	assert.EqualError(t, order.Validate(), "Nil value for record com.example.validate.Order")
+}
+
+func TestSerializeValidates(t *testing.T) {
+	order := fixtureOrder()
+	order.Lines[0] = nil
+
+	// Without validation, writing the nil record would panic
+	var buf bytes.Buffer
+	assert.EqualError(t, order.Serialize(&buf), "lines[0]: Nil value for record com.example.validate.Line")
+	assert.Equal(t, 0, buf.Len())
+}
+
+func TestValidateNamesRejected(t *testing.T) {
+	schemas := map[string]string{
+		`{"type": "record", "name": "Job", "fields": [{"name": "validate", "type": "boolean"}]}`:    `Field "validate" of record Job is generated as Validate, which is the name of a method of the record`,
+		`{"type": "record", "name": "Job", "fields": [{"name": "validateAll", "type": "boolean"}]}`: `Field "validateAll" of record Job is generated as ValidateAll, which is the name of a method of the record`,
+		`{"type": "record", "name": "ValidationError", "namespace": "com.example", "fields": []}`:   "com.example.ValidationError is generated as ValidationError, which is the name of a declaration in every generated package",
+		`{"type": "enum", "name": "ValidationErrors", "symbols": ["A"]}`:                            "ValidationErrors is generated as ValidationErrors, which is the name of a declaration in every generated package",
+	}
+	for schema, expected := range schemas {
+		namespace := types.NewNamespace()
+		if _, err := namespace.FieldDefinitionForSchema([]byte(schema)); err != nil {
+			t.Fatal(err)
+		}
+		assert.EqualError(t, namespace.CheckNames(), expected)
+	}
+}
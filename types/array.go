@@ -25,7 +25,7 @@ func %v(r %v, w io.Writer) error {
 const arrayDeserializerTemplate = `
 func %v(r io.Reader) (%v, error) {
 	var err error
-	var blkSize int64
+	var blkSize, total int64
 	var arr = make(%v, 0)
 	for {
 		blkSize, err = readLong(r)
@@ -42,10 +42,14 @@ func %v(r io.Reader) (%v, error) {
 				return nil, err
 			}
 		}
+		total += blkSize
+		if err := checkLength("array", total, MaxArrayLength); err != nil {
+			return nil, err
+		}
 		for i := int64(0); i < blkSize; i++ {
 			elem, err := %v(r)
 			if err != nil {
-				return nil, err
+				return nil, wrapDecodeError(err, fmt.Sprintf("[%%d]", len(arr)), %q)
 			}
 			arr = append(arr, elem)
 		}
@@ -87,7 +91,7 @@ func (s *arrayField) GoType() string {
 }
 
 func (s *arrayField) SerializerMethod() string {
-	return fmt.Sprintf("write%v", s.FieldType())
+	return "writeArray" + serializerSuffix(s.itemType)
 }
 
 func (s *arrayField) DeserializerMethod() string {
@@ -113,10 +117,14 @@ func (s *arrayField) AddSerializer(p *generator.Package) {
 func (s *arrayField) AddDeserializer(p *generator.Package) {
 	itemMethodName := s.itemType.DeserializerMethod()
 	methodName := s.DeserializerMethod()
-	arrayDeserializer := fmt.Sprintf(arrayDeserializerTemplate, methodName, s.GoType(), s.GoType(), itemMethodName)
+	arrayDeserializer := fmt.Sprintf(arrayDeserializerTemplate, methodName, s.GoType(), s.GoType(), itemMethodName, decodeTypeName(s.itemType))
 	s.itemType.AddDeserializer(p)
 	p.AddFunction(UTIL_FILE, "", methodName, arrayDeserializer)
 	p.AddFunction(UTIL_FILE, "", "readLong", readLongMethod)
+	p.AddImport(UTIL_FILE, "fmt")
+	addDecoder(p)
+	addLengthLimits(p)
+	addDecodeErrors(p)
 	p.AddImport(UTIL_FILE, "io")
 }
 
@@ -0,0 +1,655 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/alanctgardner/gogen-avro/generator"
+)
+
+/*
+  Generators for the JSON encoding defined by the Avro spec. Every type gets a writeJSON<FieldType>
+  function which appends the encoded value to a *bytes.Buffer, and a readJSON<FieldType> function
+  which converts a value decoded by encoding/json (with UseNumber) back to the generated Go type.
+*/
+
+const recordAvroJSONTemplate = `
+// MarshalAvroJSON encodes the record with the JSON encoding defined by the Avro spec
+func (r %v) MarshalAvroJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := %v(r, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalAvroJSON decodes the record from the JSON encoding defined by the Avro spec
+func (r %v) UnmarshalAvroJSON(data []byte) error {
+	value, err := decodeAvroJSON(data)
+	if err != nil {
+		return err
+	}
+	decoded, err := %v(value)
+	if err != nil {
+		return err
+	}
+	*r = *decoded
+	return nil
+}
+`
+
+const decodeAvroJSONDef = `
+func decodeAvroJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+`
+
+const writeJSONNullDef = `
+func writeJSONNull(_ interface{}, w *bytes.Buffer) error {
+	w.WriteString("null")
+	return nil
+}
+`
+
+const readJSONNullDef = `
+func readJSONNull(v interface{}) (interface{}, error) {
+	if v != nil {
+		return nil, fmt.Errorf("Expected JSON null, got %T", v)
+	}
+	return nil, nil
+}
+`
+
+const writeJSONBoolDef = `
+func writeJSONBool(r bool, w *bytes.Buffer) error {
+	w.WriteString(strconv.FormatBool(r))
+	return nil
+}
+`
+
+const readJSONBoolDef = `
+func readJSONBool(v interface{}) (bool, error) {
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("Expected a JSON boolean, got %T", v)
+	}
+	return b, nil
+}
+`
+
+const writeJSONIntDef = `
+func writeJSONInt(r int32, w *bytes.Buffer) error {
+	w.WriteString(strconv.FormatInt(int64(r), 10))
+	return nil
+}
+`
+
+const readJSONIntDef = `
+func readJSONInt(v interface{}) (int32, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("Expected a JSON number, got %T", v)
+	}
+	i, err := strconv.ParseInt(string(n), 10, 32)
+	return int32(i), err
+}
+`
+
+const writeJSONLongDef = `
+func writeJSONLong(r int64, w *bytes.Buffer) error {
+	w.WriteString(strconv.FormatInt(r, 10))
+	return nil
+}
+`
+
+const readJSONLongDef = `
+func readJSONLong(v interface{}) (int64, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("Expected a JSON number, got %T", v)
+	}
+	return strconv.ParseInt(string(n), 10, 64)
+}
+`
+
+const writeJSONFloatDef = `
+func writeJSONFloat(r float32, w *bytes.Buffer) error {
+	if math.IsNaN(float64(r)) || math.IsInf(float64(r), 0) {
+		return fmt.Errorf("Can't encode %v as a JSON number", r)
+	}
+	w.WriteString(strconv.FormatFloat(float64(r), 'g', -1, 32))
+	return nil
+}
+`
+
+const readJSONFloatDef = `
+func readJSONFloat(v interface{}) (float32, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("Expected a JSON number, got %T", v)
+	}
+	f, err := strconv.ParseFloat(string(n), 32)
+	return float32(f), err
+}
+`
+
+const writeJSONDoubleDef = `
+func writeJSONDouble(r float64, w *bytes.Buffer) error {
+	if math.IsNaN(r) || math.IsInf(r, 0) {
+		return fmt.Errorf("Can't encode %v as a JSON number", r)
+	}
+	w.WriteString(strconv.FormatFloat(r, 'g', -1, 64))
+	return nil
+}
+`
+
+const readJSONDoubleDef = `
+func readJSONDouble(v interface{}) (float64, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("Expected a JSON number, got %T", v)
+	}
+	return strconv.ParseFloat(string(n), 64)
+}
+`
+
+const writeJSONStringDef = `
+func writeJSONString(r string, w *bytes.Buffer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(r); err != nil {
+		return err
+	}
+	// Encode always appends a newline
+	w.Truncate(w.Len() - 1)
+	return nil
+}
+`
+
+const readJSONStringDef = `
+func readJSONString(v interface{}) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("Expected a JSON string, got %T", v)
+	}
+	return s, nil
+}
+`
+
+const writeJSONBytesDef = `
+func writeJSONBytes(r []byte, w *bytes.Buffer) error {
+	// Each byte is encoded as the code point with the same value, as in ISO-8859-1
+	runes := make([]rune, len(r))
+	for i, b := range r {
+		runes[i] = rune(b)
+	}
+	return writeJSONString(string(runes), w)
+}
+`
+
+const readJSONBytesDef = `
+func readJSONBytes(v interface{}) ([]byte, error) {
+	s, err := readJSONString(v)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 0, len(s))
+	for _, c := range s {
+		if c > 0xff {
+			return nil, fmt.Errorf("Invalid character %q in bytes value", c)
+		}
+		b = append(b, byte(c))
+	}
+	return b, nil
+}
+`
+
+const writeJSONArrayTemplate = `
+func %v(r %v, w *bytes.Buffer) error {
+	w.WriteString("[")
+	for i, e := range r {
+		if i > 0 {
+			w.WriteString(",")
+		}
+		if err := %v(e, w); err != nil {
+			return err
+		}
+	}
+	w.WriteString("]")
+	return nil
+}
+`
+
+const readJSONArrayTemplate = `
+func %v(v interface{}) (%v, error) {
+	values, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Expected a JSON array, got %%T", v)
+	}
+	arr := make(%v, len(values))
+	for i, value := range values {
+		e, err := %v(value)
+		if err != nil {
+			return nil, err
+		}
+		arr[i] = e
+	}
+	return arr, nil
+}
+`
+
+const writeJSONMapTemplate = `
+func %v(r %v, w *bytes.Buffer) error {
+	// Keys are sorted so the output is deterministic
+	keys := make([]string, 0, len(r))
+	for k := range r {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w.WriteString("{")
+	for i, k := range keys {
+		if i > 0 {
+			w.WriteString(",")
+		}
+		if err := writeJSONString(k, w); err != nil {
+			return err
+		}
+		w.WriteString(":")
+		if err := %v(r[k], w); err != nil {
+			return err
+		}
+	}
+	w.WriteString("}")
+	return nil
+}
+`
+
+const readJSONMapTemplate = `
+func %v(v interface{}) (%v, error) {
+	values, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Expected a JSON object, got %%T", v)
+	}
+	m := make(%v)
+	for k, value := range values {
+		e, err := %v(value)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = e
+	}
+	return m, nil
+}
+`
+
+const writeJSONUnionTemplate = `
+func %v(r %v, w *bytes.Buffer) error {
+	%v
+%v
+	}
+	return fmt.Errorf("Invalid value for %v")
+}
+`
+
+const readJSONUnionTemplate = `
+func %v(v interface{}) (%v, error) {
+	var unionStr %v
+	if v == nil {
+%v
+	}
+	object, ok := v.(map[string]interface{})
+	if !ok || len(object) != 1 {
+		return unionStr, fmt.Errorf("Expected a JSON object with one key for %v, got %%v", v)
+	}
+	for name, value := range object {
+		switch name {
+%v
+		}
+		return unionStr, fmt.Errorf("Invalid branch %%q for %v", name)
+	}
+	return unionStr, nil
+}
+`
+
+const writeJSONEnumTemplate = `
+func %v(r %v, w *bytes.Buffer) error {
+	switch r {
+%v
+	}
+	return fmt.Errorf("Invalid value %%v for %v", int32(r))
+}
+`
+
+const readJSONEnumTemplate = `
+func %v(v interface{}) (%v, error) {
+	s, err := readJSONString(v)
+	if err != nil {
+		return 0, err
+	}
+	switch s {
+%v
+	}
+	return 0, fmt.Errorf("Invalid symbol %%q for %v", s)
+}
+`
+
+const writeJSONFixedTemplate = `
+func %v(r %v, w *bytes.Buffer) error {
+	return writeJSONBytes(r[:], w)
+}
+`
+
+const readJSONFixedTemplate = `
+func %v(v interface{}) (%v, error) {
+	var fixed %v
+	b, err := readJSONBytes(v)
+	if err != nil {
+		return fixed, err
+	}
+	if len(b) != %v {
+		return fixed, fmt.Errorf("Expected %v bytes for %v, got %%v", len(b))
+	}
+	copy(fixed[:], b)
+	return fixed, nil
+}
+`
+
+const writeJSONRecordTemplate = `
+func %v(r %v, w *bytes.Buffer) error {
+	if r == nil {
+		return fmt.Errorf("Nil value for record %v")
+	}
+	w.WriteString("{")
+%v
+	w.WriteString("}")
+	return nil
+}
+`
+
+const readJSONRecordTemplate = `
+func %v(v interface{}) (%v, error) {
+	%v, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Expected a JSON object for %v, got %%T", v)
+	}
+	var str = &%v{}
+%v
+	return str, nil
+}
+`
+
+const writeJSONImportedRecordTemplate = `
+func %v(r %v, w *bytes.Buffer) error {
+	if r == nil {
+		return fmt.Errorf("Nil value for record %v")
+	}
+	data, err := r.MarshalAvroJSON()
+	if err != nil {
+		return err
+	}
+	w.Write(data)
+	return nil
+}
+`
+
+const readJSONImportedRecordTemplate = `
+func %v(v interface{}) (%v, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	str := &%v{}
+	if err := str.UnmarshalAvroJSON(data); err != nil {
+		return nil, err
+	}
+	return str, nil
+}
+`
+
+func jsonWriterMethod(f Field) string {
+	return "writeJSON" + f.FieldType()
+}
+
+func jsonReaderMethod(f Field) string {
+	return "readJSON" + f.FieldType()
+}
+
+func definitionJSONWriterMethod(d Definition) string {
+	return "writeJSON" + d.FieldType()
+}
+
+func definitionJSONReaderMethod(d Definition) string {
+	return "readJSON" + d.FieldType()
+}
+
+/*
+  The name of a union branch in the JSON encoding, which is the name of its type.
+*/
+func jsonBranchName(f Field) string {
+	switch t := f.(type) {
+	case *arrayField:
+		return "array"
+	case *mapField:
+		return "map"
+	case *Reference:
+		return t.def.AvroName().String()
+	}
+	return primitiveTypeName(f)
+}
+
+/*
+  The default value of a field as JSON, in the encoding that readJSON<FieldType> expects.
+  Union defaults are for the first branch and aren't wrapped in the schema, so they're wrapped here.
+*/
+func jsonDefault(f Field) (string, error) {
+	def := f.Default()
+	switch t := f.(type) {
+	case *bytesField:
+		if b, ok := def.([]byte); ok {
+			def = string(b)
+		}
+	case *unionField:
+		if _, isNull := t.itemType[0].(*nullField); !isNull {
+			def = map[string]interface{}{jsonBranchName(t.itemType[0]): def}
+		}
+	}
+	b, err := json.Marshal(def)
+	return string(b), err
+}
+
+func addJSONFunction(p *generator.Package, name, def string, imports ...string) {
+	for _, i := range imports {
+		p.AddImport(UTIL_FILE, i)
+	}
+	p.AddFunction(UTIL_FILE, "", name, def)
+}
+
+/*
+  Add the JSON writer and reader for the field, and for every type beneath it.
+*/
+func addJSONField(p *generator.Package, f Field) {
+	if p.HasFunction(UTIL_FILE, "", jsonWriterMethod(f)) {
+		return
+	}
+	switch t := f.(type) {
+	case *nullField:
+		addJSONFunction(p, "writeJSONNull", writeJSONNullDef, "bytes")
+		addJSONFunction(p, "readJSONNull", readJSONNullDef, "fmt")
+	case *boolField:
+		addJSONFunction(p, "writeJSONBool", writeJSONBoolDef, "bytes", "strconv")
+		addJSONFunction(p, "readJSONBool", readJSONBoolDef, "fmt")
+	case *intField:
+		addJSONFunction(p, "writeJSONInt", writeJSONIntDef, "bytes", "strconv")
+		addJSONFunction(p, "readJSONInt", readJSONIntDef, "encoding/json", "fmt", "strconv")
+	case *longField:
+		addJSONFunction(p, "writeJSONLong", writeJSONLongDef, "bytes", "strconv")
+		addJSONFunction(p, "readJSONLong", readJSONLongDef, "encoding/json", "fmt", "strconv")
+	case *floatField:
+		addJSONFunction(p, "writeJSONFloat", writeJSONFloatDef, "bytes", "fmt", "math", "strconv")
+		addJSONFunction(p, "readJSONFloat", readJSONFloatDef, "encoding/json", "fmt", "strconv")
+	case *doubleField:
+		addJSONFunction(p, "writeJSONDouble", writeJSONDoubleDef, "bytes", "fmt", "math", "strconv")
+		addJSONFunction(p, "readJSONDouble", readJSONDoubleDef, "encoding/json", "fmt", "strconv")
+	case *stringField:
+		addJSONString(p)
+	case *bytesField:
+		addJSONBytes(p)
+	case *arrayField:
+		addJSONFunction(p, jsonWriterMethod(t), fmt.Sprintf(writeJSONArrayTemplate, jsonWriterMethod(t), t.GoType(), jsonWriterMethod(t.itemType)), "bytes")
+		addJSONFunction(p, jsonReaderMethod(t), fmt.Sprintf(readJSONArrayTemplate, jsonReaderMethod(t), t.GoType(), t.GoType(), jsonReaderMethod(t.itemType)), "fmt")
+		addJSONField(p, t.itemType)
+	case *mapField:
+		addJSONString(p)
+		addJSONFunction(p, jsonWriterMethod(t), fmt.Sprintf(writeJSONMapTemplate, jsonWriterMethod(t), t.GoType(), jsonWriterMethod(t.itemType)), "bytes", "sort")
+		addJSONFunction(p, jsonReaderMethod(t), fmt.Sprintf(readJSONMapTemplate, jsonReaderMethod(t), t.GoType(), t.GoType(), jsonReaderMethod(t.itemType)), "fmt")
+		addJSONField(p, t.itemType)
+	case *unionField:
+		addJSONUnion(p, t)
+	case *Reference:
+		addJSONDefinition(p, t.def)
+	}
+}
+
+func addJSONString(p *generator.Package) {
+	if !p.HasFunction(UTIL_FILE, "", "writeJSONString") {
+		addJSONFunction(p, "writeJSONString", writeJSONStringDef, "bytes", "encoding/json")
+		addJSONFunction(p, "readJSONString", readJSONStringDef, "fmt")
+	}
+}
+
+func addJSONBytes(p *generator.Package) {
+	addJSONString(p)
+	if !p.HasFunction(UTIL_FILE, "", "writeJSONBytes") {
+		addJSONFunction(p, "writeJSONBytes", writeJSONBytesDef, "bytes")
+		addJSONFunction(p, "readJSONBytes", readJSONBytesDef, "fmt")
+	}
+}
+
+func addJSONUnion(p *generator.Package, u *unionField) {
+	if u.optional {
+		addJSONOptional(p, u)
+		return
+	}
+	writeCases := ""
+	readCases := ""
+	nullCase := fmt.Sprintf("return unionStr, fmt.Errorf(\"Invalid null value for %v\")", u.GoType())
+	for _, item := range u.itemType {
+		if _, isNull := item.(*nullField); isNull {
+			writeCases += fmt.Sprintf("%v\nw.WriteString(\"null\")\nreturn nil\n", u.branchCase(item))
+			nullCase = fmt.Sprintf("return %v, nil", u.newBranch(item, ""))
+			continue
+		}
+		prefix, _ := json.Marshal(map[string]interface{}{jsonBranchName(item): nil})
+		// The encoded branch name, without the null value and closing brace
+		open := string(prefix[:len(prefix)-len("null}")])
+		writeCases += fmt.Sprintf("%v\nw.WriteString(%v)\nif err := %v(%v, w); err != nil {\nreturn err\n}\nw.WriteString(\"}\")\nreturn nil\n", u.branchCase(item), strconv.Quote(open), jsonWriterMethod(item), u.branchValue("r", item))
+		readCases += fmt.Sprintf("case %q:\nval, err := %v(value)\nif err != nil {\nreturn unionStr, err\n}\nreturn %v, nil\n", jsonBranchName(item), jsonReaderMethod(item), u.newBranch(item, "val"))
+	}
+	addJSONFunction(p, jsonWriterMethod(u), fmt.Sprintf(writeJSONUnionTemplate, jsonWriterMethod(u), u.GoType(), u.branchSwitch("r"), writeCases, u.GoType()), "bytes", "fmt")
+	addJSONFunction(p, jsonReaderMethod(u), fmt.Sprintf(readJSONUnionTemplate, jsonReaderMethod(u), u.GoType(), u.GoType(), nullCase, u.GoType(), readCases, u.GoType()), "fmt")
+	for _, item := range u.itemType {
+		addJSONField(p, item)
+	}
+}
+
+func addJSONOptional(p *generator.Package, u *unionField) {
+	item := u.optionalItem()
+	prefix, _ := json.Marshal(map[string]interface{}{jsonBranchName(item): nil})
+	open := string(prefix[:len(prefix)-len("null}")])
+	addJSONFunction(p, jsonWriterMethod(u), fmt.Sprintf(writeJSONOptionalTemplate, jsonWriterMethod(u), u.GoType(), strconv.Quote(open), jsonWriterMethod(item), u.optionalValue("r")), "bytes")
+	addJSONFunction(p, jsonReaderMethod(u), fmt.Sprintf(readJSONOptionalTemplate, jsonReaderMethod(u), u.GoType(), u.FieldType(), jsonBranchName(item), u.FieldType(), jsonReaderMethod(item), u.optionalNew("val")), "fmt")
+	addJSONField(p, item)
+}
+
+func addJSONDefinition(p *generator.Package, d Definition) {
+	if p.HasFunction(UTIL_FILE, "", definitionJSONWriterMethod(d)) {
+		return
+	}
+	writer := definitionJSONWriterMethod(d)
+	reader := definitionJSONReaderMethod(d)
+	switch t := d.(type) {
+	case *EnumDefinition:
+		addJSONString(p)
+		writeCases := ""
+		readCases := ""
+		for _, s := range t.symbols {
+			writeCases += fmt.Sprintf("case %v:\nreturn writeJSONString(%q, w)\n", t.symbolConstant(s), s)
+			readCases += fmt.Sprintf("case %q:\nreturn %v, nil\n", s, t.symbolConstant(s))
+		}
+		addJSONFunction(p, writer, fmt.Sprintf(writeJSONEnumTemplate, writer, t.GoType(), writeCases, t.GoType()), "bytes", "fmt")
+		addJSONFunction(p, reader, fmt.Sprintf(readJSONEnumTemplate, reader, t.GoType(), readCases, t.GoType()), "fmt")
+	case *FixedDefinition:
+		addJSONBytes(p)
+		addJSONFunction(p, writer, fmt.Sprintf(writeJSONFixedTemplate, writer, t.GoType()), "bytes")
+		addJSONFunction(p, reader, fmt.Sprintf(readJSONFixedTemplate, reader, t.GoType(), t.GoType(), t.sizeBytes, t.sizeBytes, t.GoType()), "fmt")
+	case *RecordDefinition:
+		addJSONRecord(p, t)
+	}
+}
+
+func addJSONRecord(p *generator.Package, r *RecordDefinition) {
+	writer := definitionJSONWriterMethod(r)
+	reader := definitionJSONReaderMethod(r)
+	if r.pkg != "" {
+		// Records imported from another package are encoded with their own methods
+		addJSONFunction(p, writer, fmt.Sprintf(writeJSONImportedRecordTemplate, writer, r.GoType(), r.name), "bytes", "fmt")
+		addJSONFunction(p, reader, fmt.Sprintf(readJSONImportedRecordTemplate, reader, r.GoType(), r.structType()), "encoding/json")
+		return
+	}
+
+	fieldWriters := ""
+	fieldReaders := ""
+	// Records without fields don't need to look inside the object
+	object := "_"
+	if len(r.fields) > 0 {
+		object = "object"
+		fieldReaders = "var value interface{}\nvar err error\n"
+	}
+	for i, f := range r.fields {
+		name, _ := json.Marshal(f.AvroName())
+		separator := ","
+		if i == 0 {
+			separator = ""
+		}
+		fieldWriters += fmt.Sprintf("w.WriteString(%v)\nif err := %v(r.%v, w); err != nil {\nreturn err\n}\n", strconv.Quote(separator+string(name)+":"), jsonWriterMethod(f), f.GoName())
+
+		missing := fmt.Sprintf("return nil, fmt.Errorf(\"Missing field %%q of %v\", %q)", r.name, f.AvroName())
+		if f.HasDefault() {
+			def, err := jsonDefault(f)
+			if err == nil {
+				missing = fmt.Sprintf("value, err = decodeAvroJSON([]byte(%v))\nif err != nil {\nreturn nil, err\n}", strconv.Quote(def))
+			}
+		}
+		fieldReaders += fmt.Sprintf("if v, ok := object[%q]; ok {\nvalue = v\n} else {\n%v\n}\nstr.%v, err = %v(value)\nif err != nil {\nreturn nil, err\n}\n", f.AvroName(), missing, f.GoName(), jsonReaderMethod(f))
+	}
+
+	// Add the functions before recursing, so recursive types terminate
+	addJSONFunction(p, writer, fmt.Sprintf(writeJSONRecordTemplate, writer, r.GoType(), r.name, fieldWriters), "bytes", "fmt")
+	addJSONFunction(p, reader, fmt.Sprintf(readJSONRecordTemplate, reader, r.GoType(), object, r.name, r.FieldType(), fieldReaders), "fmt")
+	for _, f := range r.fields {
+		addJSONField(p, f)
+	}
+}
+
+/*
+  AddAvroJSON adds the MarshalAvroJSON and UnmarshalAvroJSON methods to the record, along with
+  the JSON writers and readers for every type it contains.
+*/
+func (r *RecordDefinition) AddAvroJSON(p *generator.Package) {
+	// Import guard, to avoid circular dependencies
+	if p.HasFunction(r.filename(), r.GoType(), "MarshalAvroJSON") {
+		return
+	}
+	addJSONFunction(p, "decodeAvroJSON", decodeAvroJSONDef, "bytes", "encoding/json")
+	addJSONDefinition(p, r)
+
+	p.AddImport(r.filename(), "bytes")
+	p.AddFunction(r.filename(), r.GoType(), "MarshalAvroJSON", fmt.Sprintf(recordAvroJSONTemplate, r.GoType(), definitionJSONWriterMethod(r), r.GoType(), definitionJSONReaderMethod(r)))
+}
@@ -0,0 +1,443 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+/*
+  BinaryComparator orders datums encoded in the Avro binary format for a schema, without decoding them.
+  It follows the sort order in the Avro specification, including the "order" of record fields, so the
+  result matches comparing the decoded values: the generated Compare methods agree with it.
+  Maps can't be ordered, so the schema may only contain them in ignored record fields.
+*/
+type BinaryComparator struct {
+	field Field
+
+	// Limits which protect against corrupt or hostile input. A limit of 0 disables the check.
+	// The number of items in an array or a map, over all its blocks, reported as a *LengthError
+	MaxArrayLength int64
+	MaxMapLength   int64
+	// The number of records and arrays which may be nested inside each other, reported as a *DepthError
+	MaxDepth int
+}
+
+/*
+  Create a BinaryComparator for the schema, given as JSON.
+*/
+func NewBinaryComparator(schemaJson []byte) (*BinaryComparator, error) {
+	n := NewNamespace()
+	field, err := n.FieldDefinitionForSchema(schemaJson)
+	if err != nil {
+		return nil, err
+	}
+	if err := field.ResolveReferences(n); err != nil {
+		return nil, err
+	}
+	return NewBinaryComparatorForField(field)
+}
+
+/*
+  Create a BinaryComparator for a Field whose references have already been resolved.
+*/
+func NewBinaryComparatorForField(f Field) (*BinaryComparator, error) {
+	if !isComparable(f, make(map[QualifiedName]bool)) {
+		return nil, fmt.Errorf("Unable to compare %v: it contains a map outside of an ignored field", decodeTypeName(f))
+	}
+	return &BinaryComparator{
+		field:          f,
+		MaxArrayLength: DefaultMaxArrayLength,
+		MaxMapLength:   DefaultMaxMapLength,
+		MaxDepth:       DefaultMaxDepth,
+	}, nil
+}
+
+/*
+  Compare the datums at the start of a and b. It returns a negative number if a sorts first, a positive
+  number if b does and 0 if they're equal. Comparison stops at the first difference, so bytes after it
+  aren't checked, and bytes after the end of the datums are ignored.
+*/
+func (c *BinaryComparator) Compare(a, b []byte) (int, error) {
+	comparison := binaryComparison{
+		comparator: c,
+		a:          binaryCursor{data: a},
+		b:          binaryCursor{data: b},
+	}
+	return comparison.compare(c.field)
+}
+
+/*
+  A position in a binary datum.
+*/
+type binaryCursor struct {
+	data []byte
+	pos  int
+}
+
+func (r *binaryCursor) readLong() (int64, error) {
+	value, n := binary.Varint(r.data[r.pos:])
+	if n == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("Varint at offset %v overflows a long", r.pos)
+	}
+	r.pos += n
+	return value, nil
+}
+
+func (r *binaryCursor) next(n int64) ([]byte, error) {
+	if n > int64(len(r.data)-r.pos) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	start := r.pos
+	r.pos += int(n)
+	return r.data[start:r.pos], nil
+}
+
+func (r *binaryCursor) readBytes(typ string) ([]byte, error) {
+	size, err := r.readLong()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLength(typ, size, 0); err != nil {
+		return nil, err
+	}
+	return r.next(size)
+}
+
+/*
+  Read the count of the next block of an array or map, and the size of the block in bytes if the
+  writer provided it with a negative count. The size is -1 if it's unknown. The count is added to
+  total, the number of items in the previous blocks, which is checked against the limit.
+*/
+func (r *binaryCursor) readBlock(typ string, total *int64, limit int64) (int64, int64, error) {
+	count, err := r.readLong()
+	if err != nil {
+		return 0, 0, err
+	}
+	size := int64(-1)
+	if count < 0 {
+		count = -count
+		if size, err = r.readLong(); err != nil {
+			return 0, 0, err
+		}
+		if err := checkLength("block", size, 0); err != nil {
+			return 0, 0, err
+		}
+	}
+	// The smallest long is still negative after it's negated
+	if err := checkLength(typ, count, limit); err != nil {
+		return 0, 0, err
+	}
+	*total += count
+	if err := checkLength(typ, *total, limit); err != nil {
+		return 0, 0, err
+	}
+	return count, size, nil
+}
+
+/*
+  The state of a single call to Compare.
+*/
+type binaryComparison struct {
+	comparator *BinaryComparator
+	a, b       binaryCursor
+	depth      int
+}
+
+/*
+  Enter a record or array, checking the nesting limit. Every call is paired with a call to leave.
+*/
+func (c *binaryComparison) enter() error {
+	c.depth++
+	if c.comparator.MaxDepth > 0 && c.depth > c.comparator.MaxDepth {
+		return &DepthError{Limit: c.comparator.MaxDepth}
+	}
+	return nil
+}
+
+func (c *binaryComparison) leave() {
+	c.depth--
+}
+
+func (c *binaryComparison) readLongs() (int64, int64, error) {
+	a, err := c.a.readLong()
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := c.b.readLong()
+	return a, b, err
+}
+
+func (c *binaryComparison) nexts(n int64) ([]byte, []byte, error) {
+	a, err := c.a.next(n)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := c.b.next(n)
+	return a, b, err
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// NaN sorts after every other value, and equal to itself
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	case a == b:
+		return 0
+	case math.IsNaN(a) && math.IsNaN(b):
+		return 0
+	case math.IsNaN(a):
+		return 1
+	}
+	return -1
+}
+
+func (c *binaryComparison) compare(f Field) (int, error) {
+	switch t := f.(type) {
+	case *nullField:
+		return 0, nil
+	case *boolField:
+		a, b, err := c.nexts(1)
+		if err != nil {
+			return 0, err
+		}
+		return compareInt64(int64(a[0]), int64(b[0])), nil
+	case *intField, *longField:
+		a, b, err := c.readLongs()
+		return compareInt64(a, b), err
+	case *floatField:
+		a, b, err := c.nexts(4)
+		if err != nil {
+			return 0, err
+		}
+		return compareFloat64(float64(math.Float32frombits(binary.LittleEndian.Uint32(a))), float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))), nil
+	case *doubleField:
+		a, b, err := c.nexts(8)
+		if err != nil {
+			return 0, err
+		}
+		return compareFloat64(math.Float64frombits(binary.LittleEndian.Uint64(a)), math.Float64frombits(binary.LittleEndian.Uint64(b))), nil
+	case *bytesField, *stringField:
+		// Comparing UTF-8 bytes orders strings by code point
+		a, err := c.a.readBytes(jsonBranchName(f))
+		if err != nil {
+			return 0, err
+		}
+		b, err := c.b.readBytes(jsonBranchName(f))
+		if err != nil {
+			return 0, err
+		}
+		return bytes.Compare(a, b), nil
+	case *arrayField:
+		if err := c.enter(); err != nil {
+			return 0, err
+		}
+		defer c.leave()
+		return c.compareArray(t)
+	case *unionField:
+		a, b, err := c.readLongs()
+		if err != nil {
+			return 0, err
+		}
+		for _, index := range []int64{a, b} {
+			if index < 0 || index >= int64(len(t.itemType)) {
+				return 0, fmt.Errorf("Invalid branch %v for union field %q", index, t.AvroName())
+			}
+		}
+		if a != b {
+			return compareInt64(a, b), nil
+		}
+		return c.compare(t.itemType[a])
+	case *Reference:
+		return c.compareDefinition(t.def)
+	}
+	return 0, fmt.Errorf("Unable to compare field %q of type %T", f.AvroName(), f)
+}
+
+/*
+  Compare arrays item by item, with the shorter array first if one is a prefix of the other.
+  The arrays may be split into blocks differently.
+*/
+func (c *binaryComparison) compareArray(t *arrayField) (int, error) {
+	limit := c.comparator.MaxArrayLength
+	var remainingA, remainingB, totalA, totalB int64
+	var err error
+	for {
+		if remainingA == 0 {
+			if remainingA, _, err = c.a.readBlock("array", &totalA, limit); err != nil {
+				return 0, err
+			}
+		}
+		if remainingB == 0 {
+			if remainingB, _, err = c.b.readBlock("array", &totalB, limit); err != nil {
+				return 0, err
+			}
+		}
+		if remainingA == 0 || remainingB == 0 {
+			return compareInt64(remainingA, remainingB), nil
+		}
+		if result, err := c.compare(t.itemType); err != nil || result != 0 {
+			return result, err
+		}
+		remainingA--
+		remainingB--
+	}
+}
+
+func (c *binaryComparison) compareDefinition(def Definition) (int, error) {
+	switch t := def.(type) {
+	case *RecordDefinition:
+		if err := c.enter(); err != nil {
+			return 0, err
+		}
+		defer c.leave()
+		for _, f := range t.fields {
+			order := t.fieldOrder[f.AvroName()]
+			if order == Ignore {
+				if err := c.skip(&c.a, f); err != nil {
+					return 0, err
+				}
+				if err := c.skip(&c.b, f); err != nil {
+					return 0, err
+				}
+				continue
+			}
+			result, err := c.compare(f)
+			if err != nil {
+				return 0, err
+			}
+			if result != 0 {
+				if order == Descending {
+					return -result, nil
+				}
+				return result, nil
+			}
+		}
+		return 0, nil
+	case *EnumDefinition:
+		// Enums are ordered by the position of their symbols in the schema
+		a, b, err := c.readLongs()
+		return compareInt64(a, b), err
+	case *FixedDefinition:
+		a, b, err := c.nexts(int64(t.sizeBytes))
+		if err != nil {
+			return 0, err
+		}
+		return bytes.Compare(a, b), nil
+	}
+	return 0, fmt.Errorf("Unable to compare definition %v of type %T", def.AvroName(), def)
+}
+
+/*
+  Move the cursor past a value of the field's type. Blocks of arrays and maps are skipped whole when
+  the writer provided their size.
+*/
+func (c *binaryComparison) skip(r *binaryCursor, f Field) error {
+	var err error
+	switch t := f.(type) {
+	case *nullField:
+	case *boolField:
+		_, err = r.next(1)
+	case *intField, *longField:
+		_, err = r.readLong()
+	case *floatField:
+		_, err = r.next(4)
+	case *doubleField:
+		_, err = r.next(8)
+	case *bytesField, *stringField:
+		_, err = r.readBytes(jsonBranchName(f))
+	case *arrayField:
+		err = c.skipBlocks(r, nil, t.itemType)
+	case *mapField:
+		err = c.skipBlocks(r, mapKeyField, t.itemType)
+	case *unionField:
+		index, err := r.readLong()
+		if err != nil {
+			return err
+		}
+		if index < 0 || index >= int64(len(t.itemType)) {
+			return fmt.Errorf("Invalid branch %v for union field %q", index, t.AvroName())
+		}
+		return c.skip(r, t.itemType[index])
+	case *Reference:
+		switch def := t.def.(type) {
+		case *RecordDefinition:
+			if err := c.enter(); err != nil {
+				return err
+			}
+			defer c.leave()
+			for _, f := range def.fields {
+				if err := c.skip(r, f); err != nil {
+					return err
+				}
+			}
+		case *EnumDefinition:
+			_, err = r.readLong()
+		case *FixedDefinition:
+			_, err = r.next(int64(def.sizeBytes))
+		}
+	default:
+		err = fmt.Errorf("Unable to skip field %q of type %T", f.AvroName(), f)
+	}
+	return err
+}
+
+// The type of the keys of maps
+var mapKeyField Field = &stringField{}
+
+/*
+  Skip the blocks of an array, or of a map if key is the type of its keys.
+*/
+func (c *binaryComparison) skipBlocks(r *binaryCursor, key, item Field) error {
+	if err := c.enter(); err != nil {
+		return err
+	}
+	defer c.leave()
+	typ, limit := "array", c.comparator.MaxArrayLength
+	if key != nil {
+		typ, limit = "map", c.comparator.MaxMapLength
+	}
+	var total int64
+	for {
+		count, size, err := r.readBlock(typ, &total, limit)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if size >= 0 {
+			if _, err := r.next(size); err != nil {
+				return err
+			}
+			continue
+		}
+		for i := int64(0); i < count; i++ {
+			if key != nil {
+				if err := c.skip(r, key); err != nil {
+					return err
+				}
+			}
+			if err := c.skip(r, item); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -42,17 +42,12 @@ func writeBool(r bool, w io.Writer) error {
 
 const readBoolMethod = `
 func readBool(r io.Reader) (bool, error) {
-	var b byte
-	var err error
-	if br, ok := r.(ByteReader); ok {
-		b, err = br.ReadByte()
-	} else {
-		bs := make([]byte, 1)
-		_, err = io.ReadFull(r, bs)
-		if err != nil {
-			return false, err
-		}
-		b = bs[0]
+	b, err := decoderFor(r).ReadByte()
+	if err != nil {
+		return false, err
+	}
+	if b > 1 {
+		return false, fmt.Errorf("Invalid boolean value %v", b)
 	}
 	return b == 1, nil
 }
@@ -105,8 +100,9 @@ func (s *boolField) AddSerializer(p *generator.Package) {
 }
 
 func (s *boolField) AddDeserializer(p *generator.Package) {
-	p.AddStruct(UTIL_FILE, "ByteReader", byteReaderInterface)
 	p.AddFunction(UTIL_FILE, "", "readBool", readBoolMethod)
+	p.AddImport(UTIL_FILE, "fmt")
+	addDecoder(p)
 	p.AddImport(UTIL_FILE, "io")
 }
 
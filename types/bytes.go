@@ -21,9 +21,10 @@ func readBytes(r io.Reader) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	bb := make([]byte, size)
-	_, err = io.ReadFull(r, bb)
-	return bb, err
+	if err := checkLength("bytes", size, MaxBytesLength); err != nil {
+		return nil, err
+	}
+	return decoderFor(r).bytes(int(size))
 }
 `
 
@@ -77,7 +78,10 @@ func (s *bytesField) AddSerializer(p *generator.Package) {
 
 func (s *bytesField) AddDeserializer(p *generator.Package) {
 	p.AddFunction(UTIL_FILE, "", "readBytes", readBytesMethod)
+	addDecoder(p)
+	addLengthLimits(p)
 	p.AddFunction(UTIL_FILE, "", "readLong", readLongMethod)
+	p.AddImport(UTIL_FILE, "fmt")
 	p.AddImport(UTIL_FILE, "io")
 }
 
@@ -0,0 +1,191 @@
+package types
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+/*
+  The empty value of the CRC-64-AVRO fingerprint, which is also the polynomial
+  used to build the lookup table. See the "Schema Fingerprints" section of the Avro spec.
+*/
+const crc64Empty uint64 = 0xc15d213aa4d7a795
+
+var crc64Table = makeCRC64Table()
+
+func makeCRC64Table() [256]uint64 {
+	var table [256]uint64
+	for i := range table {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (crc64Empty & -(fp & 1))
+		}
+		table[i] = fp
+	}
+	return table
+}
+
+/*
+  Compute the 64-bit Rabin (CRC-64-AVRO) fingerprint of the given bytes, which
+  are usually the Parsing Canonical Form of a schema.
+*/
+func CRC64Fingerprint(data []byte) uint64 {
+	fp := crc64Empty
+	for _, b := range data {
+		fp = (fp >> 8) ^ crc64Table[byte(fp)^b]
+	}
+	return fp
+}
+
+/*
+  Compute the MD5 fingerprint of the given bytes, which are usually the Parsing Canonical Form of a schema.
+*/
+func MD5Fingerprint(data []byte) [16]byte {
+	return md5.Sum(data)
+}
+
+/*
+  Compute the SHA-256 fingerprint of the given bytes, which are usually the Parsing Canonical Form of a schema.
+*/
+func SHA256Fingerprint(data []byte) [32]byte {
+	return sha256.Sum256(data)
+}
+
+/*
+  Return the Parsing Canonical Form of the schema for a Field, as defined by the Avro spec.
+  All references beneath the field must already be resolved.
+*/
+func CanonicalForm(f Field) (string, error) {
+	var buf bytes.Buffer
+	if err := writeCanonicalField(&buf, f, make(map[QualifiedName]bool)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+/*
+  Return the Parsing Canonical Form of the schema for a record, enum or fixed Definition.
+  All references beneath the definition must already be resolved.
+*/
+func DefinitionCanonicalForm(d Definition) (string, error) {
+	var buf bytes.Buffer
+	if err := writeCanonicalDefinition(&buf, d, make(map[QualifiedName]bool)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func writeCanonicalField(buf *bytes.Buffer, f Field, names map[QualifiedName]bool) error {
+	switch t := f.(type) {
+	case *nullField, *boolField, *intField, *longField, *floatField, *doubleField, *bytesField, *stringField:
+		// Logical types and other attributes are stripped, leaving the primitive name
+		writeCanonicalString(buf, primitiveTypeName(t))
+	case *arrayField:
+		buf.WriteString(`{"type":"array","items":`)
+		if err := writeCanonicalField(buf, t.itemType, names); err != nil {
+			return err
+		}
+		buf.WriteString("}")
+	case *mapField:
+		buf.WriteString(`{"type":"map","values":`)
+		if err := writeCanonicalField(buf, t.itemType, names); err != nil {
+			return err
+		}
+		buf.WriteString("}")
+	case *unionField:
+		buf.WriteString("[")
+		for i, item := range t.itemType {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			if err := writeCanonicalField(buf, item, names); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+	case *Reference:
+		if t.def == nil {
+			return fmt.Errorf("Unable to compute canonical form - unresolved reference to type %v", t.typeName)
+		}
+		return writeCanonicalDefinition(buf, t.def, names)
+	default:
+		return fmt.Errorf("Unable to compute canonical form for field %q of type %T", f.AvroName(), f)
+	}
+	return nil
+}
+
+func writeCanonicalDefinition(buf *bytes.Buffer, d Definition, names map[QualifiedName]bool) error {
+	// Named types are only written out in full the first time they're seen
+	if names[d.AvroName()] {
+		writeCanonicalString(buf, d.AvroName().String())
+		return nil
+	}
+	names[d.AvroName()] = true
+
+	buf.WriteString(`{"name":`)
+	writeCanonicalString(buf, d.AvroName().String())
+	switch t := d.(type) {
+	case *RecordDefinition:
+		buf.WriteString(`,"type":"record","fields":[`)
+		for i, f := range t.fields {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			buf.WriteString(`{"name":`)
+			writeCanonicalString(buf, f.AvroName())
+			buf.WriteString(`,"type":`)
+			if err := writeCanonicalField(buf, f, names); err != nil {
+				return err
+			}
+			buf.WriteString("}")
+		}
+		buf.WriteString("]")
+	case *EnumDefinition:
+		buf.WriteString(`,"type":"enum","symbols":[`)
+		for i, s := range t.symbols {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			writeCanonicalString(buf, s)
+		}
+		buf.WriteString("]")
+	case *FixedDefinition:
+		fmt.Fprintf(buf, `,"type":"fixed","size":%d`, t.sizeBytes)
+	default:
+		return fmt.Errorf("Unable to compute canonical form for definition %v of type %T", d.AvroName(), d)
+	}
+	buf.WriteString("}")
+	return nil
+}
+
+func primitiveTypeName(f Field) string {
+	switch f.(type) {
+	case *nullField:
+		return "null"
+	case *boolField:
+		return "boolean"
+	case *intField:
+		return "int"
+	case *longField:
+		return "long"
+	case *floatField:
+		return "float"
+	case *doubleField:
+		return "double"
+	case *bytesField:
+		return "bytes"
+	}
+	return "string"
+}
+
+// writeCanonicalString writes a JSON string without the HTML escaping done by json.Marshal
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	// Encode always appends a newline, which isn't part of the canonical form
+	buf.Truncate(buf.Len() - 1)
+}
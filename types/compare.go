@@ -0,0 +1,350 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/alanctgardner/gogen-avro/generator"
+)
+
+/*
+  SortOrder is the "order" of a record field, which decides how it affects the ordering of records.
+*/
+type SortOrder int
+
+const (
+	Ascending SortOrder = iota
+	Descending
+	// The field is skipped when records are compared
+	Ignore
+)
+
+func ParseSortOrder(order string) (SortOrder, error) {
+	switch order {
+	case "ascending":
+		return Ascending, nil
+	case "descending":
+		return Descending, nil
+	case "ignore":
+		return Ignore, nil
+	}
+	return Ascending, fmt.Errorf("Unknown sort order %q, expected ascending, descending or ignore", order)
+}
+
+func (o SortOrder) String() string {
+	switch o {
+	case Descending:
+		return "descending"
+	case Ignore:
+		return "ignore"
+	}
+	return "ascending"
+}
+
+const compareDoubleMethod = `
+// NaN sorts after every other value, and equal to itself
+func compareDouble(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	case a == b:
+		return 0
+	case math.IsNaN(a) && math.IsNaN(b):
+		return 0
+	case math.IsNaN(a):
+		return 1
+	}
+	return -1
+}
+`
+
+const compareLongMethod = `
+func compareLong(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+`
+
+const compareBoolMethod = `
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	}
+	return 1
+}
+`
+
+const compareArrayTemplate = `
+func %v(a, b %v) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := %v(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return compareLong(int64(len(a)), int64(len(b)))
+}
+`
+
+const compareUnionTemplate = `
+func %v(a, b %v) int {
+	if c := compareLong(int64(%v), int64(%v)); c != 0 {
+		return c
+	}
+	%v
+%v
+	}
+	return 0
+}
+`
+
+const unionIndexTemplate = `
+func %v(r %v) int {
+	%v
+%v
+	}
+	return -1
+}
+`
+
+const compareOptionalTemplate = `
+func %v(a, b %v) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return %v
+	case b == nil:
+		return %v
+	}
+	return %v(%v, %v)
+}
+`
+
+const compareRecordTemplate = `
+func %v(a, b %v) int {
+	switch {
+	case a == b:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+%v	return 0
+}
+`
+
+const recordCompareTemplate = `
+// Compare orders the record against other following the Avro specification: fields are compared in
+// the order they're declared, reversed for descending fields and skipped for ignored ones.
+// It returns a negative number if r sorts first, a positive number if other does and 0 if they're equal.
+func (r %v) Compare(other %v) int {
+	return %v(r, other)
+}
+`
+
+func compareMethod(f Field) string {
+	return "compare" + f.FieldType()
+}
+
+func definitionCompareMethod(d Definition) string {
+	return "compare" + d.FieldType()
+}
+
+func unionIndexMethod(u *unionField) string {
+	return "index" + u.FieldType()
+}
+
+/*
+  Whether values of the field's type can be ordered. The specification doesn't define an order for maps,
+  so types containing them can't be compared unless the maps are in ignored record fields.
+*/
+func isComparable(f Field, seen map[QualifiedName]bool) bool {
+	switch t := f.(type) {
+	case *mapField:
+		return false
+	case *arrayField:
+		return isComparable(t.itemType, seen)
+	case *unionField:
+		for _, item := range t.itemType {
+			if !isComparable(item, seen) {
+				return false
+			}
+		}
+	case *Reference:
+		if def, ok := t.def.(*RecordDefinition); ok {
+			return def.isComparable(seen)
+		}
+	}
+	return true
+}
+
+func (r *RecordDefinition) isComparable(seen map[QualifiedName]bool) bool {
+	if seen[r.name] {
+		return true
+	}
+	seen[r.name] = true
+	for _, f := range r.fields {
+		if r.fieldOrder[f.AvroName()] != Ignore && !isComparable(f, seen) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+  Add the function returning the index of the branch held by a union in interface mode, or -1 if it's invalid.
+*/
+func addUnionIndex(p *generator.Package, u *unionField) {
+	indexCases := ""
+	for i, item := range u.itemType {
+		indexCases += fmt.Sprintf("%v\nreturn %v\n", u.branchCase(item), i)
+	}
+	p.AddFunction(UTIL_FILE, "", unionIndexMethod(u), fmt.Sprintf(unionIndexTemplate, unionIndexMethod(u), u.GoType(), u.branchTypeSwitch("r"), indexCases))
+}
+
+func addCompareFunction(p *generator.Package, name, def string) {
+	p.AddFunction(UTIL_FILE, "", name, def)
+}
+
+/*
+  Add the compare function for the field, and for every type beneath it.
+*/
+func addCompareField(p *generator.Package, f Field) {
+	if p.HasFunction(UTIL_FILE, "", compareMethod(f)) {
+		return
+	}
+	switch t := f.(type) {
+	case *nullField:
+		addCompareFunction(p, compareMethod(t), "\nfunc compareNull(a, b interface{}) int {\n\treturn 0\n}\n")
+	case *boolField:
+		addCompareFunction(p, compareMethod(t), compareBoolMethod)
+	case *intField:
+		addCompareFunction(p, "compareLong", compareLongMethod)
+		addCompareFunction(p, compareMethod(t), "\nfunc compareInt(a, b int32) int {\n\treturn compareLong(int64(a), int64(b))\n}\n")
+	case *longField:
+		addCompareFunction(p, compareMethod(t), compareLongMethod)
+	case *floatField:
+		p.AddImport(UTIL_FILE, "math")
+		addCompareFunction(p, "compareDouble", compareDoubleMethod)
+		addCompareFunction(p, compareMethod(t), "\nfunc compareFloat(a, b float32) int {\n\treturn compareDouble(float64(a), float64(b))\n}\n")
+	case *doubleField:
+		p.AddImport(UTIL_FILE, "math")
+		addCompareFunction(p, compareMethod(t), compareDoubleMethod)
+	case *stringField:
+		// Comparing UTF-8 bytes orders strings by code point
+		p.AddImport(UTIL_FILE, "strings")
+		addCompareFunction(p, compareMethod(t), "\nfunc compareString(a, b string) int {\n\treturn strings.Compare(a, b)\n}\n")
+	case *bytesField:
+		p.AddImport(UTIL_FILE, "bytes")
+		addCompareFunction(p, compareMethod(t), "\nfunc compareBytes(a, b []byte) int {\n\treturn bytes.Compare(a, b)\n}\n")
+	case *arrayField:
+		addCompareFunction(p, "compareLong", compareLongMethod)
+		addCompareFunction(p, compareMethod(t), fmt.Sprintf(compareArrayTemplate, compareMethod(t), t.GoType(), compareMethod(t.itemType)))
+		addCompareField(p, t.itemType)
+	case *unionField:
+		addCompareUnion(p, t)
+	case *Reference:
+		addCompareDefinition(p, t.def)
+	}
+}
+
+func addCompareUnion(p *generator.Package, u *unionField) {
+	if u.optional {
+		nullIndex, valueIndex := u.optionalBranches()
+		// Null sorts before the value if it's the first branch
+		nullOrder, valueOrder := -1, 1
+		if nullIndex > valueIndex {
+			nullOrder, valueOrder = 1, -1
+		}
+		item := u.optionalItem()
+		addCompareFunction(p, compareMethod(u), fmt.Sprintf(compareOptionalTemplate, compareMethod(u), u.GoType(), nullOrder, valueOrder, compareMethod(item), u.optionalValue("a"), u.optionalValue("b")))
+		addCompareField(p, item)
+		return
+	}
+
+	addCompareFunction(p, "compareLong", compareLongMethod)
+	aIndex, bIndex := "a.UnionType", "b.UnionType"
+	if u.mode == UnionModeInterface {
+		addUnionIndex(p, u)
+		aIndex, bIndex = unionIndexMethod(u)+"(a)", unionIndexMethod(u)+"(b)"
+	}
+
+	// Both values hold the same branch once their indexes are equal
+	cases := ""
+	for _, item := range u.itemType {
+		if _, isNull := item.(*nullField); isNull {
+			continue
+		}
+		other := u.branchValue("b", item)
+		if u.mode == UnionModeInterface {
+			other = fmt.Sprintf("%v.(%v).Value", u.branchOf("b"), u.branchType(item))
+		}
+		cases += fmt.Sprintf("%v\nreturn %v(%v, %v)\n", u.branchCase(item), compareMethod(item), u.branchValue("a", item), other)
+	}
+	switchStmt := u.branchSwitch("a")
+	if u.mode == UnionModeInterface && cases == "" {
+		switchStmt = u.branchTypeSwitch("a")
+	}
+	addCompareFunction(p, compareMethod(u), fmt.Sprintf(compareUnionTemplate, compareMethod(u), u.GoType(), aIndex, bIndex, switchStmt, cases))
+	for _, item := range u.itemType {
+		addCompareField(p, item)
+	}
+}
+
+func addCompareDefinition(p *generator.Package, d Definition) {
+	if p.HasFunction(UTIL_FILE, "", definitionCompareMethod(d)) {
+		return
+	}
+	switch t := d.(type) {
+	case *FixedDefinition:
+		p.AddImport(UTIL_FILE, "bytes")
+		addCompareFunction(p, definitionCompareMethod(t), fmt.Sprintf("\nfunc %v(a, b %v) int {\n\treturn bytes.Compare(a[:], b[:])\n}\n", definitionCompareMethod(t), t.GoType()))
+	case *EnumDefinition:
+		// Enums are ordered by the position of their symbols in the schema
+		addCompareFunction(p, "compareLong", compareLongMethod)
+		addCompareFunction(p, definitionCompareMethod(t), fmt.Sprintf("\nfunc %v(a, b %v) int {\n\treturn compareLong(int64(a), int64(b))\n}\n", definitionCompareMethod(t), t.GoType()))
+	case *RecordDefinition:
+		if t.pkg != "" {
+			addCompareFunction(p, definitionCompareMethod(t), fmt.Sprintf("\nfunc %v(a, b %v) int {\n\treturn a.Compare(b)\n}\n", definitionCompareMethod(t), t.GoType()))
+			return
+		}
+		fieldComparisons := ""
+		for _, f := range t.fields {
+			switch t.fieldOrder[f.AvroName()] {
+			case Ascending:
+				fieldComparisons += fmt.Sprintf("\tif c := %v(a.%v, b.%v); c != 0 {\n\t\treturn c\n\t}\n", compareMethod(f), f.GoName(), f.GoName())
+			case Descending:
+				fieldComparisons += fmt.Sprintf("\tif c := %v(a.%v, b.%v); c != 0 {\n\t\treturn -c\n\t}\n", compareMethod(f), f.GoName(), f.GoName())
+			}
+		}
+		addCompareFunction(p, definitionCompareMethod(t), fmt.Sprintf(compareRecordTemplate, definitionCompareMethod(t), t.GoType(), fieldComparisons))
+		for _, f := range t.fields {
+			if t.fieldOrder[f.AvroName()] != Ignore {
+				addCompareField(p, f)
+			}
+		}
+	}
+}
+
+/*
+  Add the Compare method, which orders records following the sort order in the Avro specification.
+  Records with maps outside of ignored fields can't be ordered, so they don't get one.
+*/
+func (r *RecordDefinition) AddCompare(p *generator.Package) {
+	// Import guard, to avoid circular dependencies
+	if p.HasFunction(r.filename(), r.GoType(), "Compare") || !r.isComparable(make(map[QualifiedName]bool)) {
+		return
+	}
+	addCompareDefinition(p, r)
+	p.AddFunction(r.filename(), r.GoType(), "Compare", fmt.Sprintf(recordCompareTemplate, r.GoType(), r.GoType(), definitionCompareMethod(r)))
+}
@@ -0,0 +1,85 @@
+package types
+
+import (
+	"github.com/alanctgardner/gogen-avro/generator"
+)
+
+const decodeErrorDef = `
+// DecodeError is returned by the generated decoders, with the path of the value which couldn't be read
+// (like Order.items[3].price), the Avro type expected there and the offset in the stream where reading stopped.
+// The Offset is -1 when it isn't known.
+type DecodeError struct {
+	Path   string
+	Type   string
+	Offset int64
+	Err    error
+}
+`
+
+const decodeErrorMethodDef = `
+func (e *DecodeError) Error() string {
+	if e.Offset < 0 {
+		return fmt.Sprintf("Error reading %v at %v: %v", e.Type, e.Path, e.Err)
+	}
+	return fmt.Sprintf("Error reading %v at %v (offset %v): %v", e.Type, e.Path, e.Offset, e.Err)
+}
+`
+
+const decodeErrorUnwrapMethodDef = `
+// Unwrap returns the underlying error, like io.ErrUnexpectedEOF or a *LengthError
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+`
+
+const wrapDecodeErrorMethod = `
+func wrapDecodeError(err error, segment, typ string) error {
+	if e, ok := err.(*DecodeError); ok {
+		if e.Path == "" || e.Path[0] == '[' {
+			e.Path = segment + e.Path
+		} else {
+			e.Path = segment + "." + e.Path
+		}
+		return e
+	}
+	return &DecodeError{Path: segment, Type: typ, Offset: -1, Err: err}
+}
+`
+
+const decodeErrorAtMethod = `
+// decodeErrorAt wraps an error from reading a record which started at start and stopped at offset. If the input
+// ended before any of the record was read, it returns io.EOF itself, so callers can tell the end of a stream apart.
+func decodeErrorAt(err error, segment, typ string, start, offset int64) error {
+	if offset == start {
+		if e, ok := err.(*DecodeError); (ok && e.Err == io.EOF) || err == io.EOF {
+			return io.EOF
+		}
+	}
+	e := wrapDecodeError(err, segment, typ).(*DecodeError)
+	e.Offset = offset
+	return e
+}
+`
+
+/*
+  The Avro type named in a DecodeError for a value of the field's type.
+*/
+func decodeTypeName(f Field) string {
+	if _, ok := f.(*unionField); ok {
+		return "union"
+	}
+	return jsonBranchName(f)
+}
+
+/*
+  Add the DecodeError type and the helpers which build up its path as the error is returned from nested readers.
+*/
+func addDecodeErrors(p *generator.Package) {
+	p.AddStruct(UTIL_FILE, "DecodeError", decodeErrorDef)
+	p.AddImport(UTIL_FILE, "fmt")
+	p.AddImport(UTIL_FILE, "io")
+	p.AddFunction(UTIL_FILE, "*DecodeError", "Error", decodeErrorMethodDef)
+	p.AddFunction(UTIL_FILE, "*DecodeError", "Unwrap", decodeErrorUnwrapMethodDef)
+	p.AddFunction(UTIL_FILE, "", "wrapDecodeError", wrapDecodeErrorMethod)
+	p.AddFunction(UTIL_FILE, "", "decodeErrorAt", decodeErrorAtMethod)
+}
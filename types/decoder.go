@@ -0,0 +1,255 @@
+package types
+
+import (
+	"github.com/alanctgardner/gogen-avro/generator"
+)
+
+const decoderDef = `
+// Decoder reads the Avro binary encoding from a stream through io.ByteReader, and keeps scratch space between
+// values so the generated readers don't allocate for primitives. Pass the same Decoder to Deserialize for every
+// record in a stream: it may buffer past the end of the record it's reading.
+type Decoder struct {
+	r       io.Reader
+	br      ByteReader
+	offset  int64
+	buf     [8]byte
+	scratch []byte
+	// When there's no reader, the Decoder reads from data, and offset is the index of the next byte
+	data []byte
+	// Whether bytes values are slices of data rather than copies
+	alias bool
+	// The number of records being read, nested inside each other
+	depth int
+}
+`
+
+const newDecoderMethod = `
+// NewDecoder returns a Decoder which reads from r, wrapping it in a bufio.Reader unless it implements io.ByteReader
+func NewDecoder(r io.Reader) *Decoder {
+	if _, ok := r.(ByteReader); !ok {
+		r = bufio.NewReader(r)
+	}
+	return newDecoder(r)
+}
+`
+
+const newDecoderUnbufferedMethod = `
+func newDecoder(r io.Reader) *Decoder {
+	d := &Decoder{r: r}
+	d.br, _ = r.(ByteReader)
+	return d
+}
+`
+
+const newSliceDecoderMethod = `
+func newSliceDecoder(data []byte, alias bool) *Decoder {
+	return &Decoder{data: data, alias: alias}
+}
+`
+
+const decoderForMethod = `
+// decoderFor returns r if it's already a Decoder, or an unbuffered Decoder which won't read past the value
+func decoderFor(r io.Reader) *Decoder {
+	if d, ok := r.(*Decoder); ok {
+		return d
+	}
+	return newDecoder(r)
+}
+`
+
+const decoderReadMethodDef = `
+func (d *Decoder) Read(p []byte) (int, error) {
+	if d.r == nil {
+		if len(p) > 0 && d.offset >= int64(len(d.data)) {
+			return 0, io.EOF
+		}
+		n := copy(p, d.data[d.offset:])
+		d.offset += int64(n)
+		return n, nil
+	}
+	n, err := d.r.Read(p)
+	d.offset += int64(n)
+	return n, err
+}
+`
+
+const decoderReadByteMethodDef = `
+func (d *Decoder) ReadByte() (byte, error) {
+	if d.r == nil {
+		if d.offset >= int64(len(d.data)) {
+			return 0, io.EOF
+		}
+		d.offset++
+		return d.data[d.offset-1], nil
+	}
+	if d.br == nil {
+		_, err := io.ReadFull(d, d.buf[:1])
+		return d.buf[0], err
+	}
+	b, err := d.br.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	d.offset++
+	return b, nil
+}
+`
+
+const decoderOffsetMethodDef = `
+// Offset returns the number of bytes read from the stream
+func (d *Decoder) Offset() int64 {
+	return d.offset
+}
+`
+
+const decoderNextMethodDef = `
+// next reads n bytes into the Decoder's scratch space. They're only valid until the next read.
+func (d *Decoder) next(n int) ([]byte, error) {
+	if d.r == nil {
+		return d.slice(n)
+	}
+	var buf []byte
+	switch {
+	case n <= len(d.buf):
+		buf = d.buf[:n]
+	case n <= cap(d.scratch):
+		buf = d.scratch[:n]
+	case n <= maxDecoderScratch:
+		d.scratch = make([]byte, n)
+		buf = d.scratch
+	default:
+		// Don't hold on to the space for the largest value in the stream
+		return d.readLarge(n)
+	}
+	if _, err := io.ReadFull(d, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+`
+
+const decoderSliceMethodDef = `
+// slice returns the next n bytes of the Decoder's data without copying them
+func (d *Decoder) slice(n int) ([]byte, error) {
+	remaining := int64(len(d.data)) - d.offset
+	if int64(n) > remaining {
+		d.offset = int64(len(d.data))
+		if remaining == 0 {
+			return nil, io.EOF
+		}
+		return nil, io.ErrUnexpectedEOF
+	}
+	start := d.offset
+	d.offset += int64(n)
+	// Cap the slice, so appending to a value can't overwrite the rest of the data
+	return d.data[start:d.offset:d.offset], nil
+}
+`
+
+const decoderReadLargeMethodDef = `
+// readLarge reads a value too large for the scratch space in chunks, so that a corrupt length can't make the
+// Decoder allocate much more than the input holds
+func (d *Decoder) readLarge(n int) ([]byte, error) {
+	var buf bytes.Buffer
+	copied, err := io.CopyN(&buf, d, int64(n))
+	if err == io.EOF && copied > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+`
+
+const decoderBytesMethodDef = `
+// bytes reads a bytes value of length n, which is only a slice of the input if the Decoder aliases it
+func (d *Decoder) bytes(n int) ([]byte, error) {
+	if d.r == nil {
+		// Check that the data holds the whole value before copying it
+		b, err := d.slice(n)
+		if err != nil || d.alias {
+			return b, err
+		}
+		bb := make([]byte, n)
+		copy(bb, b)
+		return bb, nil
+	}
+	if n > maxDecoderScratch {
+		return d.readLarge(n)
+	}
+	bb := make([]byte, n)
+	if _, err := io.ReadFull(d, bb); err != nil {
+		return nil, err
+	}
+	return bb, nil
+}
+`
+
+const decoderSkipMethodDef = `
+// skip discards the next n bytes, without allocating for them
+func (d *Decoder) skip(n int64) error {
+	if d.r == nil {
+		_, err := d.slice(int(n))
+		return err
+	}
+	discarder, _ := d.r.(interface {
+		Discard(int) (int, error)
+	})
+	for skipped := int64(0); skipped < n; {
+		chunk := n - skipped
+		if chunk > maxDecoderScratch {
+			chunk = maxDecoderScratch
+		}
+		var err error
+		if discarder != nil {
+			var discarded int
+			discarded, err = discarder.Discard(int(chunk))
+			d.offset += int64(discarded)
+			if err == io.EOF && skipped+int64(discarded) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+		} else {
+			_, err = d.next(int(chunk))
+			if err == io.EOF && skipped > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+		}
+		if err != nil {
+			return err
+		}
+		skipped += chunk
+	}
+	return nil
+}
+`
+
+const maxDecoderScratchDef = `
+// The largest value a Decoder keeps scratch space for
+const maxDecoderScratch = 64 << 10
+`
+
+/*
+  Add the Decoder, which every generated reader goes through.
+*/
+func addDecoder(p *generator.Package) {
+	p.AddImport(UTIL_FILE, "bufio")
+	p.AddImport(UTIL_FILE, "bytes")
+	p.AddImport(UTIL_FILE, "io")
+	p.AddStruct(UTIL_FILE, "ByteReader", byteReaderInterface)
+	p.AddStruct(UTIL_FILE, "Decoder", decoderDef)
+	p.AddStruct(UTIL_FILE, "maxDecoderScratch", maxDecoderScratchDef)
+	p.AddFunction(UTIL_FILE, "", "NewDecoder", newDecoderMethod)
+	p.AddFunction(UTIL_FILE, "", "newDecoder", newDecoderUnbufferedMethod)
+	p.AddFunction(UTIL_FILE, "", "newSliceDecoder", newSliceDecoderMethod)
+	p.AddFunction(UTIL_FILE, "", "decoderFor", decoderForMethod)
+	p.AddFunction(UTIL_FILE, "*Decoder", "Read", decoderReadMethodDef)
+	p.AddFunction(UTIL_FILE, "*Decoder", "ReadByte", decoderReadByteMethodDef)
+	p.AddFunction(UTIL_FILE, "*Decoder", "Offset", decoderOffsetMethodDef)
+	p.AddFunction(UTIL_FILE, "*Decoder", "next", decoderNextMethodDef)
+	p.AddFunction(UTIL_FILE, "*Decoder", "slice", decoderSliceMethodDef)
+	p.AddFunction(UTIL_FILE, "*Decoder", "readLarge", decoderReadLargeMethodDef)
+	p.AddFunction(UTIL_FILE, "*Decoder", "bytes", decoderBytesMethodDef)
+	p.AddFunction(UTIL_FILE, "*Decoder", "skip", decoderSkipMethodDef)
+	addDepthLimit(p)
+}
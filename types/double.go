@@ -14,8 +14,7 @@ func writeDouble(r float64, w io.Writer) error {
 
 const readDoubleMethod = `
 func readDouble(r io.Reader) (float64, error) {
-	buf := make([]byte, 8)
-	_, err := io.ReadFull(r, buf)
+	buf, err := decoderFor(r).next(8)
 	if err != nil {
 		return 0, err
 	}
@@ -76,6 +75,7 @@ func (s *doubleField) AddSerializer(p *generator.Package) {
 
 func (s *doubleField) AddDeserializer(p *generator.Package) {
 	p.AddFunction(UTIL_FILE, "", "readDouble", readDoubleMethod)
+	addDecoder(p)
 	p.AddImport(UTIL_FILE, "io")
 	p.AddImport(UTIL_FILE, "math")
 	p.AddImport(UTIL_FILE, "encoding/binary")
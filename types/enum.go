@@ -40,6 +40,8 @@ type EnumDefinition struct {
 	aliases  []QualifiedName
 	symbols  []string
 	metadata map[string]interface{}
+	// The name the package holding the Go type is imported as, if it's generated in another namespace's package
+	pkg string
 }
 
 func (e *EnumDefinition) AvroName() QualifiedName {
@@ -51,11 +53,19 @@ func (e *EnumDefinition) Aliases() []QualifiedName {
 }
 
 func (e *EnumDefinition) FieldType() string {
-	return generator.ToPublicName(e.name.Name)
+	return e.name.goName(e.pkg != "")
 }
 
 func (e *EnumDefinition) GoType() string {
-	return e.FieldType()
+	return qualifiedGoName(e.pkg, e.name)
+}
+
+// The name of the constant for a symbol, qualified with its package if the enum is imported
+func (e *EnumDefinition) symbolConstant(symbol string) string {
+	if e.pkg != "" {
+		return e.pkg + "." + generator.ToPublicName(symbol)
+	}
+	return generator.ToPublicName(symbol)
 }
 
 func (e *EnumDefinition) typeList() string {
@@ -83,7 +93,7 @@ func (e *EnumDefinition) stringerDef() string {
 }
 
 func (e *EnumDefinition) serializerMethodDef() string {
-	return fmt.Sprintf(enumSerializerDef, e.SerializerMethod(), e.FieldType())
+	return fmt.Sprintf(enumSerializerDef, e.SerializerMethod(), e.GoType())
 }
 
 func (e *EnumDefinition) SerializerMethod() string {
@@ -91,7 +101,7 @@ func (e *EnumDefinition) SerializerMethod() string {
 }
 
 func (e *EnumDefinition) deserializerMethodDef() string {
-	return fmt.Sprintf(enumDeserializerDef, e.DeserializerMethod(), e.FieldType(), e.FieldType())
+	return fmt.Sprintf(enumDeserializerDef, e.DeserializerMethod(), e.GoType(), e.GoType())
 }
 
 func (e *EnumDefinition) DeserializerMethod() string {
@@ -103,6 +113,10 @@ func (e *EnumDefinition) filename() string {
 }
 
 func (e *EnumDefinition) AddStruct(p *generator.Package) {
+	// The type and its symbols are in the package it's imported from
+	if e.pkg != "" {
+		return
+	}
 	p.AddStruct(e.filename(), e.GoType(), e.structDef())
 	p.AddFunction(e.filename(), e.GoType(), "String", e.stringerDef())
 }
@@ -117,6 +131,8 @@ func (e *EnumDefinition) AddSerializer(p *generator.Package) {
 
 func (e *EnumDefinition) AddDeserializer(p *generator.Package) {
 	p.AddFunction(UTIL_FILE, "", "readInt", readIntMethod)
+	p.AddImport(UTIL_FILE, "fmt")
+	addDecoder(p)
 	p.AddFunction(UTIL_FILE, "", e.DeserializerMethod(), e.deserializerMethodDef())
 	p.AddImport(UTIL_FILE, "io")
 }
@@ -0,0 +1,787 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alanctgardner/gogen-avro/generator"
+)
+
+const fieldDiffDef = `
+// FieldDiff is a value which differs between two records: its path, and its value in each of them.
+// Old or New is nil if the value is missing from that record, or it holds null.
+type FieldDiff struct {
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+`
+
+const equalDoubleMethod = `
+// Unlike ==, NaN is equal to itself
+func equalDouble(a, b float64) bool {
+	return a == b || (a != a && b != b)
+}
+`
+
+const equalArrayTemplate = `
+func %v(a, b %v) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !(%v) {
+			return false
+		}
+	}
+	return true
+}
+`
+
+const equalMapTemplate = `
+func %v(a, b %v) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, va := range a {
+		vb, ok := b[k]
+		if !ok || !(%v) {
+			return false
+		}
+	}
+	return true
+}
+`
+
+const equalMapKeysTemplate = `
+func %v(a, b %v) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+`
+
+const equalUnionTemplate = `
+func %v(a, b %v) bool {
+	if %v != %v {
+		return false
+	}
+	%v
+%v
+	}
+	return true
+}
+`
+
+const equalOptionalTemplate = `
+func %v(a, b %v) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return %v
+}
+`
+
+const equalRecordTemplate = `
+func %v(a, b %v) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return %v
+}
+`
+
+const cloneBytesMethod = `
+func cloneBytes(r []byte) []byte {
+	if r == nil {
+		return nil
+	}
+	c := make([]byte, len(r))
+	copy(c, r)
+	return c
+}
+`
+
+const cloneArrayTemplate = `
+func %v(r %v) %v {
+	if r == nil {
+		return nil
+	}
+	c := make(%v, len(r))
+	for i, v := range r {
+		c[i] = %v
+	}
+	return c
+}
+`
+
+const cloneMapTemplate = `
+func %v(r %v) %v {
+	if r == nil {
+		return nil
+	}
+	c := make(%v, len(r))
+	for k, v := range r {
+		c[k] = %v
+	}
+	return c
+}
+`
+
+const cloneUnionTemplate = `
+func %v(r %v) %v {
+	c := r
+%v	return c
+}
+`
+
+const cloneInterfaceUnionTemplate = `
+func %v(r %v) %v {
+	%v
+%v
+	}
+	return r
+}
+`
+
+const cloneOptionalTemplate = `
+func %v(r %v) %v {
+	if r == nil {
+		return nil
+	}
+	c := %v
+	return &c
+}
+`
+
+const cloneRecordTemplate = `
+func %v(r %v) %v {
+	if r == nil {
+		return nil
+	}
+	c := *r
+%v	return &c
+}
+`
+
+const diffArrayTemplate = `
+func %v(a, b %v, path string, diffs []FieldDiff) []FieldDiff {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		itemPath := fmt.Sprintf("%%v[%%v]", path, i)
+		switch {
+		case i >= len(b):
+			diffs = append(diffs, FieldDiff{Path: itemPath, Old: a[i]})
+		case i >= len(a):
+			diffs = append(diffs, FieldDiff{Path: itemPath, New: b[i]})
+		default:
+			%v
+		}
+	}
+	return diffs
+}
+`
+
+const diffMapTemplate = `
+func %v(a, b %v, path string, diffs []FieldDiff) []FieldDiff {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		itemPath := fmt.Sprintf("%%v[%%q]", path, k)
+		va, inA := a[k]
+		vb, inB := b[k]
+		switch {
+		case !inB:
+			diffs = append(diffs, FieldDiff{Path: itemPath, Old: va})
+		case !inA:
+			diffs = append(diffs, FieldDiff{Path: itemPath, New: vb})
+		default:
+			%v
+		}
+	}
+	return diffs
+}
+`
+
+const diffUnionTemplate = `
+func %v(a, b %v, path string, diffs []FieldDiff) []FieldDiff {
+	if %v != %v {
+		return append(diffs, FieldDiff{Path: path, Old: %v, New: %v})
+	}
+	%v
+%v
+	}
+	return diffs
+}
+`
+
+const unionValueOfTemplate = `
+func %v(r %v) interface{} {
+	%v
+%v
+	}
+	return nil
+}
+`
+
+const diffOptionalTemplate = `
+func %v(a, b %v, path string, diffs []FieldDiff) []FieldDiff {
+	if a == nil || b == nil {
+		if a == nil && b == nil {
+			return diffs
+		}
+		diff := FieldDiff{Path: path}
+		if a != nil {
+			diff.Old = %v
+		}
+		if b != nil {
+			diff.New = %v
+		}
+		return append(diffs, diff)
+	}
+	%v
+	return diffs
+}
+`
+
+const diffRecordTemplate = `
+func %v(a, b %v, path string, diffs []FieldDiff) []FieldDiff {
+	if a == nil || b == nil {
+		if a == nil && b == nil {
+			return diffs
+		}
+		diff := FieldDiff{Path: path}
+		if a != nil {
+			diff.Old = a
+		}
+		if b != nil {
+			diff.New = b
+		}
+		return append(diffs, diff)
+	}
+%v	return diffs
+}
+`
+
+const diffImportedRecordBody = `	for _, diff := range a.Diff(b) {
+		diffs = append(diffs, FieldDiff{Path: validationPath(path, diff.Path), Old: diff.Old, New: diff.New})
+	}
+`
+
+const recordEqualsTemplate = `
+// Equals returns whether other holds the same values as the record. Nil and empty slices and maps are equal,
+// since they have the same encoding, and so are NaNs.
+func (r %v) Equals(other %v) bool {
+	return %v(r, other)
+}
+`
+
+const recordCloneTemplate = `
+// Clone returns a deep copy of the record, which shares no slices, maps or nested records with it
+func (r %v) Clone() %v {
+	return %v(r)
+}
+`
+
+const recordDiffTemplate = `
+// Diff returns the values which differ between the record and other, with the path of each, such as
+// lines[2].status or notes["gift"]. Arrays are compared by index, and maps by key in sorted order.
+func (r %v) Diff(other %v) []FieldDiff {
+	return %v(r, other, "", nil)
+}
+`
+
+const unionEqualsTemplate = `
+// Equals returns whether other holds the same branch as the union, with an equal value
+func (u %v) Equals(other %v) bool {
+	return %v(u, other)
+}
+`
+
+const unionCloneTemplate = `
+// Clone returns a deep copy of the union, which shares no slices, maps or records with it
+func (u %v) Clone() %v {
+	return %v
+}
+`
+
+const unionDiffTemplate = `
+// Diff returns the values which differ between the union and other, with their paths inside the union's value
+func (u %v) Diff(other %v) []FieldDiff {
+	return %v(u, other, "", nil)
+}
+`
+
+func equalMethod(f Field) string {
+	return "equal" + f.FieldType()
+}
+
+func cloneMethod(f Field) string {
+	return "clone" + f.FieldType()
+}
+
+func diffMethod(f Field) string {
+	return "diff" + f.FieldType()
+}
+
+func definitionEqualMethod(d Definition) string {
+	return "equal" + d.FieldType()
+}
+
+func definitionCloneMethod(d Definition) string {
+	return "clone" + d.FieldType()
+}
+
+func definitionDiffMethod(d Definition) string {
+	return "diff" + d.FieldType()
+}
+
+func unionValueOfMethod(u *unionField) string {
+	return "valueOf" + u.FieldType()
+}
+
+/*
+  Whether values of the field's type can be compared with ==.
+*/
+func isEqualityComparable(f Field) bool {
+	switch t := f.(type) {
+	case *boolField, *intField, *longField, *stringField:
+		return true
+	case *Reference:
+		switch t.def.(type) {
+		case *EnumDefinition, *FixedDefinition:
+			return true
+		}
+	}
+	return false
+}
+
+/*
+  An expression for whether a and b, which hold values of the field's type, are equal.
+*/
+func equalExpr(f Field, a, b string) string {
+	switch f.(type) {
+	case *nullField:
+		return "true"
+	case *floatField:
+		return fmt.Sprintf("equalDouble(float64(%v), float64(%v))", a, b)
+	case *doubleField:
+		return fmt.Sprintf("equalDouble(%v, %v)", a, b)
+	case *bytesField:
+		return fmt.Sprintf("bytes.Equal(%v, %v)", a, b)
+	}
+	if isEqualityComparable(f) {
+		return fmt.Sprintf("%v == %v", a, b)
+	}
+	return fmt.Sprintf("%v(%v, %v)", equalMethod(f), a, b)
+}
+
+/*
+  Whether values of the field's type hold pointers, slices or maps, which a copy would share.
+*/
+func needsClone(f Field) bool {
+	switch t := f.(type) {
+	case *bytesField, *arrayField, *mapField:
+		return true
+	case *unionField:
+		if t.optional {
+			return true
+		}
+		for _, item := range t.itemType {
+			if needsClone(item) {
+				return true
+			}
+		}
+	case *Reference:
+		_, isRecord := t.def.(*RecordDefinition)
+		return isRecord
+	}
+	return false
+}
+
+func cloneExpr(f Field, expr string) string {
+	if needsClone(f) {
+		return fmt.Sprintf("%v(%v)", cloneMethod(f), expr)
+	}
+	return expr
+}
+
+/*
+  Statements which append the differences between a and b, values of the field's type, to diffs.
+*/
+func diffStatement(f Field, a, b, path string) string {
+	if _, isNull := f.(*nullField); isNull {
+		return ""
+	}
+	if isDiffLeaf(f) {
+		return fmt.Sprintf("if !(%v) {\ndiffs = append(diffs, FieldDiff{Path: %v, Old: %v, New: %v})\n}\n", equalExpr(f, a, b), path, a, b)
+	}
+	return fmt.Sprintf("diffs = %v(%v, %v, %v, diffs)\n", diffMethod(f), a, b, path)
+}
+
+/*
+  Whether values of the field's type are reported whole when they differ, rather than by the parts which differ.
+*/
+func isDiffLeaf(f Field) bool {
+	switch f.(type) {
+	case *nullField, *bytesField, *floatField, *doubleField:
+		return true
+	}
+	return isEqualityComparable(f)
+}
+
+func addEqualityFunction(p *generator.Package, name, def string, imports ...string) {
+	for _, i := range imports {
+		p.AddImport(UTIL_FILE, i)
+	}
+	p.AddFunction(UTIL_FILE, "", name, def)
+}
+
+/*
+  Add the functions used by equalExpr for the field, and for every type beneath it.
+*/
+func addEqualField(p *generator.Package, f Field) {
+	switch f.(type) {
+	case *floatField, *doubleField:
+		addEqualityFunction(p, "equalDouble", equalDoubleMethod)
+		return
+	case *bytesField:
+		p.AddImport(UTIL_FILE, "bytes")
+		return
+	}
+	if isEqualityComparable(f) || p.HasFunction(UTIL_FILE, "", equalMethod(f)) {
+		return
+	}
+	switch t := f.(type) {
+	case *arrayField:
+		if _, isNull := t.itemType.(*nullField); isNull {
+			// Only the lengths of arrays of nulls can differ
+			addEqualityFunction(p, equalMethod(t), fmt.Sprintf("\nfunc %v(a, b %v) bool {\n\treturn len(a) == len(b)\n}\n", equalMethod(t), t.GoType()))
+			return
+		}
+		addEqualityFunction(p, equalMethod(t), fmt.Sprintf(equalArrayTemplate, equalMethod(t), t.GoType(), equalExpr(t.itemType, "a[i]", "b[i]")))
+		addEqualField(p, t.itemType)
+	case *mapField:
+		if _, isNull := t.itemType.(*nullField); isNull {
+			// Only the keys of maps of nulls can differ
+			addEqualityFunction(p, equalMethod(t), fmt.Sprintf(equalMapKeysTemplate, equalMethod(t), t.GoType()))
+			return
+		}
+		addEqualityFunction(p, equalMethod(t), fmt.Sprintf(equalMapTemplate, equalMethod(t), t.GoType(), equalExpr(t.itemType, "va", "vb")))
+		addEqualField(p, t.itemType)
+	case *unionField:
+		addEqualUnion(p, t)
+	case *Reference:
+		addEqualDefinition(p, t.def)
+	}
+}
+
+func addEqualUnion(p *generator.Package, u *unionField) {
+	if u.optional {
+		item := u.optionalItem()
+		addEqualityFunction(p, equalMethod(u), fmt.Sprintf(equalOptionalTemplate, equalMethod(u), u.GoType(), equalExpr(item, u.optionalValue("a"), u.optionalValue("b"))))
+		addEqualField(p, item)
+		return
+	}
+
+	cases := ""
+	for _, item := range u.itemType {
+		if _, isNull := item.(*nullField); isNull {
+			continue
+		}
+		cases += fmt.Sprintf("%v\nreturn %v\n", u.branchCase(item), equalExpr(item, u.branchValue("a", item), u.otherBranchValue("b", item)))
+	}
+	aIndex, bIndex := u.branchIndexes(p)
+	addEqualityFunction(p, equalMethod(u), fmt.Sprintf(equalUnionTemplate, equalMethod(u), u.GoType(), aIndex, bIndex, u.comparisonSwitch(cases), cases))
+	for _, item := range u.itemType {
+		addEqualField(p, item)
+	}
+}
+
+/*
+  The expressions for the branch indexes of the unions a and b, which are equal when they hold the same branch.
+*/
+func (u *unionField) branchIndexes(p *generator.Package) (string, string) {
+	if u.mode == UnionModeInterface {
+		addUnionIndex(p, u)
+		return unionIndexMethod(u) + "(a)", unionIndexMethod(u) + "(b)"
+	}
+	return "a.UnionType", "b.UnionType"
+}
+
+/*
+  The value of the branch held by the union expr, which is known to hold the same branch as the one being switched on.
+*/
+func (u *unionField) otherBranchValue(expr string, item Field) string {
+	if u.mode == UnionModeInterface {
+		return fmt.Sprintf("%v.(%v).Value", u.branchOf(expr), u.branchType(item))
+	}
+	return u.branchValue(expr, item)
+}
+
+/*
+  The switch over the branches of the union a, for cases which use its value.
+*/
+func (u *unionField) comparisonSwitch(cases string) string {
+	if u.mode == UnionModeInterface && cases == "" {
+		return u.branchTypeSwitch("a")
+	}
+	return u.branchSwitch("a")
+}
+
+func addEqualDefinition(p *generator.Package, d Definition) {
+	if p.HasFunction(UTIL_FILE, "", definitionEqualMethod(d)) {
+		return
+	}
+	r, ok := d.(*RecordDefinition)
+	if !ok {
+		return
+	}
+	if r.pkg != "" {
+		addEqualityFunction(p, definitionEqualMethod(r), fmt.Sprintf(equalRecordTemplate, definitionEqualMethod(r), r.GoType(), "a.Equals(b)"))
+		return
+	}
+	fieldEquality := make([]string, 0, len(r.fields))
+	for _, f := range r.fields {
+		if _, isNull := f.(*nullField); !isNull {
+			fieldEquality = append(fieldEquality, equalExpr(f, "a."+f.GoName(), "b."+f.GoName()))
+		}
+	}
+	if len(fieldEquality) == 0 {
+		fieldEquality = append(fieldEquality, "true")
+	}
+	addEqualityFunction(p, definitionEqualMethod(r), fmt.Sprintf(equalRecordTemplate, definitionEqualMethod(r), r.GoType(), strings.Join(fieldEquality, " &&\n\t\t")))
+	for _, f := range r.fields {
+		addEqualField(p, f)
+	}
+}
+
+/*
+  Add the clone function for the field, and for every type beneath it which needs one.
+*/
+func addCloneField(p *generator.Package, f Field) {
+	if !needsClone(f) || p.HasFunction(UTIL_FILE, "", cloneMethod(f)) {
+		return
+	}
+	switch t := f.(type) {
+	case *bytesField:
+		addEqualityFunction(p, cloneMethod(t), cloneBytesMethod)
+	case *arrayField:
+		addEqualityFunction(p, cloneMethod(t), fmt.Sprintf(cloneArrayTemplate, cloneMethod(t), t.GoType(), t.GoType(), t.GoType(), cloneExpr(t.itemType, "v")))
+		addCloneField(p, t.itemType)
+	case *mapField:
+		addEqualityFunction(p, cloneMethod(t), fmt.Sprintf(cloneMapTemplate, cloneMethod(t), t.GoType(), t.GoType(), t.GoType(), cloneExpr(t.itemType, "v")))
+		addCloneField(p, t.itemType)
+	case *unionField:
+		addCloneUnion(p, t)
+	case *Reference:
+		addCloneDefinition(p, t.def)
+	}
+}
+
+func addCloneUnion(p *generator.Package, u *unionField) {
+	if u.optional {
+		item := u.optionalItem()
+		if u.optionalIsPointer() {
+			// The union is the record pointer itself
+			addEqualityFunction(p, cloneMethod(u), fmt.Sprintf("\nfunc %v(r %v) %v {\n\treturn %v(r)\n}\n", cloneMethod(u), u.GoType(), u.GoType(), cloneMethod(item)))
+		} else {
+			addEqualityFunction(p, cloneMethod(u), fmt.Sprintf(cloneOptionalTemplate, cloneMethod(u), u.GoType(), u.GoType(), cloneExpr(item, "*r")))
+		}
+		addCloneField(p, item)
+		return
+	}
+
+	var def string
+	if u.mode == UnionModeInterface {
+		cases := ""
+		for _, item := range u.itemType {
+			if needsClone(item) {
+				cases += fmt.Sprintf("case %v:\nreturn %v{Value: %v}\n", u.branchType(item), u.branchType(item), cloneExpr(item, "branch.Value"))
+			}
+		}
+		def = fmt.Sprintf(cloneInterfaceUnionTemplate, cloneMethod(u), u.GoType(), u.GoType(), u.branchSwitch("r"), cases)
+	} else {
+		// Every branch is copied, including the ones the union doesn't hold, so nothing is shared
+		branches := ""
+		for _, item := range u.itemType {
+			if needsClone(item) {
+				branches += fmt.Sprintf("\tc.%v = %v\n", item.FieldType(), cloneExpr(item, "r."+item.FieldType()))
+			}
+		}
+		def = fmt.Sprintf(cloneUnionTemplate, cloneMethod(u), u.GoType(), u.GoType(), branches)
+	}
+	addEqualityFunction(p, cloneMethod(u), def)
+	for _, item := range u.itemType {
+		addCloneField(p, item)
+	}
+}
+
+func addCloneDefinition(p *generator.Package, d Definition) {
+	if p.HasFunction(UTIL_FILE, "", definitionCloneMethod(d)) {
+		return
+	}
+	r, ok := d.(*RecordDefinition)
+	if !ok {
+		return
+	}
+	if r.pkg != "" {
+		addEqualityFunction(p, definitionCloneMethod(r), fmt.Sprintf("\nfunc %v(r %v) %v {\n\treturn r.Clone()\n}\n", definitionCloneMethod(r), r.GoType(), r.GoType()))
+		return
+	}
+	fieldClones := ""
+	for _, f := range r.fields {
+		if needsClone(f) {
+			fieldClones += fmt.Sprintf("\tc.%v = %v\n", f.GoName(), cloneExpr(f, "r."+f.GoName()))
+		}
+	}
+	addEqualityFunction(p, definitionCloneMethod(r), fmt.Sprintf(cloneRecordTemplate, definitionCloneMethod(r), r.GoType(), r.GoType(), fieldClones))
+	for _, f := range r.fields {
+		addCloneField(p, f)
+	}
+}
+
+/*
+  Add the diff function for the field, and for every type beneath it which needs one.
+  Primitives, enums and fixed values are compared inline by diffStatement.
+*/
+func addDiffField(p *generator.Package, f Field) {
+	addEqualField(p, f)
+	if isDiffLeaf(f) || p.HasFunction(UTIL_FILE, "", diffMethod(f)) {
+		return
+	}
+	switch t := f.(type) {
+	case *arrayField:
+		addEqualityFunction(p, diffMethod(t), fmt.Sprintf(diffArrayTemplate, diffMethod(t), t.GoType(), diffStatement(t.itemType, "a[i]", "b[i]", "itemPath")), "fmt")
+		addDiffField(p, t.itemType)
+	case *mapField:
+		addEqualityFunction(p, diffMethod(t), fmt.Sprintf(diffMapTemplate, diffMethod(t), t.GoType(), diffStatement(t.itemType, "va", "vb", "itemPath")), "fmt", "sort")
+		addDiffField(p, t.itemType)
+	case *unionField:
+		addDiffUnion(p, t)
+	case *Reference:
+		addDiffDefinition(p, t.def)
+	}
+}
+
+func addDiffUnion(p *generator.Package, u *unionField) {
+	if u.optional {
+		item := u.optionalItem()
+		addEqualityFunction(p, diffMethod(u), fmt.Sprintf(diffOptionalTemplate, diffMethod(u), u.GoType(), u.optionalValue("a"), u.optionalValue("b"), diffStatement(item, u.optionalValue("a"), u.optionalValue("b"), "path")))
+		addDiffField(p, item)
+		return
+	}
+
+	cases := ""
+	for _, item := range u.itemType {
+		if _, isNull := item.(*nullField); isNull {
+			continue
+		}
+		cases += fmt.Sprintf("%v\n%v", u.branchCase(item), diffStatement(item, u.branchValue("a", item), u.otherBranchValue("b", item), "path"))
+	}
+	aIndex, bIndex := u.branchIndexes(p)
+	// The struct's Value method isn't used, since it's left out when a branch is called Value
+	addUnionValueOf(p, u)
+	aValue, bValue := unionValueOfMethod(u)+"(a)", unionValueOfMethod(u)+"(b)"
+	addEqualityFunction(p, diffMethod(u), fmt.Sprintf(diffUnionTemplate, diffMethod(u), u.GoType(), aIndex, bIndex, aValue, bValue, u.comparisonSwitch(cases), cases))
+	for _, item := range u.itemType {
+		addDiffField(p, item)
+	}
+}
+
+/*
+  Add the function returning the value of the branch held by a union, or nil for null.
+*/
+func addUnionValueOf(p *generator.Package, u *unionField) {
+	cases := ""
+	for _, item := range u.itemType {
+		if _, isNull := item.(*nullField); !isNull {
+			cases += fmt.Sprintf("%v\nreturn %v\n", u.branchCase(item), u.branchValue("r", item))
+		}
+	}
+	switchStmt := u.branchSwitch("r")
+	if cases == "" && u.mode == UnionModeInterface {
+		switchStmt = u.branchTypeSwitch("r")
+	}
+	p.AddFunction(UTIL_FILE, "", unionValueOfMethod(u), fmt.Sprintf(unionValueOfTemplate, unionValueOfMethod(u), u.GoType(), switchStmt, cases))
+}
+
+func addDiffDefinition(p *generator.Package, d Definition) {
+	if p.HasFunction(UTIL_FILE, "", definitionDiffMethod(d)) {
+		return
+	}
+	r, ok := d.(*RecordDefinition)
+	if !ok {
+		return
+	}
+	if r.pkg != "" {
+		// The differences in a record imported from another package are converted, with the record's path in front
+		addEqualityFunction(p, definitionDiffMethod(r), fmt.Sprintf(diffRecordTemplate, definitionDiffMethod(r), r.GoType(), diffImportedRecordBody))
+		return
+	}
+	fieldDiffs := ""
+	for _, f := range r.fields {
+		fieldDiffs += diffStatement(f, "a."+f.GoName(), "b."+f.GoName(), fmt.Sprintf("validationPath(path, %q)", f.AvroName()))
+	}
+	addEqualityFunction(p, definitionDiffMethod(r), fmt.Sprintf(diffRecordTemplate, definitionDiffMethod(r), r.GoType(), fieldDiffs))
+	for _, f := range r.fields {
+		addDiffField(p, f)
+	}
+}
+
+/*
+  Add the Equals, Clone and Diff methods, which compare and copy records deeply, following their nested
+  records, unions, arrays and maps.
+*/
+func (r *RecordDefinition) AddEquality(p *generator.Package) {
+	// Import guard, to avoid circular dependencies
+	if p.HasFunction(r.filename(), r.GoType(), "Equals") {
+		return
+	}
+	addEqualDefinition(p, r)
+	addCloneDefinition(p, r)
+	p.AddStruct(UTIL_FILE, "FieldDiff", fieldDiffDef)
+	addEqualityFunction(p, "validationPath", validationPathDef)
+	addDiffDefinition(p, r)
+
+	p.AddFunction(r.filename(), r.GoType(), "Equals", fmt.Sprintf(recordEqualsTemplate, r.GoType(), r.GoType(), definitionEqualMethod(r)))
+	p.AddFunction(r.filename(), r.GoType(), "Clone", fmt.Sprintf(recordCloneTemplate, r.GoType(), r.GoType(), definitionCloneMethod(r)))
+	p.AddFunction(r.filename(), r.GoType(), "Diff", fmt.Sprintf(recordDiffTemplate, r.GoType(), r.GoType(), definitionDiffMethod(r)))
+}
+
+/*
+  Add the Equals, Clone and Diff methods to a union struct, unless a branch has taken the method's name.
+*/
+func (s *unionField) addEqualityMethods(p *generator.Package) {
+	if !s.hasStructField("Equals") {
+		addEqualField(p, s)
+		p.AddFunction(s.filename(), s.FieldType(), "Equals", fmt.Sprintf(unionEqualsTemplate, s.FieldType(), s.FieldType(), equalMethod(s)))
+	}
+	if !s.hasStructField("Clone") {
+		addCloneField(p, s)
+		clone := "u"
+		if needsClone(s) {
+			clone = cloneMethod(s) + "(u)"
+		}
+		p.AddFunction(s.filename(), s.FieldType(), "Clone", fmt.Sprintf(unionCloneTemplate, s.FieldType(), s.FieldType(), clone))
+	}
+	if !s.hasStructField("Diff") {
+		p.AddStruct(UTIL_FILE, "FieldDiff", fieldDiffDef)
+		addEqualityFunction(p, "validationPath", validationPathDef)
+		addDiffField(p, s)
+		p.AddFunction(s.filename(), s.FieldType(), "Diff", fmt.Sprintf(unionDiffTemplate, s.FieldType(), s.FieldType(), diffMethod(s)))
+	}
+}
@@ -16,8 +16,12 @@ func %v(r %v, w io.Writer) error {
 const readFixedMethod = `
 func %v(r io.Reader) (%v, error) {
 	var bb %v
-	_, err := io.ReadFull(r, bb[:])
-	return bb, err
+	buf, err := decoderFor(r).next(len(bb))
+	if err != nil {
+		return bb, err
+	}
+	copy(bb[:], buf)
+	return bb, nil
 }
 `
 
@@ -26,6 +30,8 @@ type FixedDefinition struct {
 	aliases   []QualifiedName
 	sizeBytes int
 	metadata  map[string]interface{}
+	// The name the package holding the Go type is imported as, if it's generated in another namespace's package
+	pkg string
 }
 
 func (s *FixedDefinition) AvroName() QualifiedName {
@@ -37,11 +43,11 @@ func (s *FixedDefinition) Aliases() []QualifiedName {
 }
 
 func (s *FixedDefinition) FieldType() string {
-	return s.GoType()
+	return s.name.goName(s.pkg != "")
 }
 
 func (s *FixedDefinition) GoType() string {
-	return generator.ToPublicName(s.name.Name)
+	return qualifiedGoName(s.pkg, s.name)
 }
 
 func (s *FixedDefinition) serializerMethodDef() string {
@@ -69,6 +75,10 @@ func (s *FixedDefinition) DeserializerMethod() string {
 }
 
 func (s *FixedDefinition) AddStruct(p *generator.Package) {
+	// The type is in the package it's imported from
+	if s.pkg != "" {
+		return
+	}
 	p.AddStruct(s.filename(), s.GoType(), s.typeDef())
 }
 
@@ -79,6 +89,7 @@ func (s *FixedDefinition) AddSerializer(p *generator.Package) {
 
 func (s *FixedDefinition) AddDeserializer(p *generator.Package) {
 	p.AddFunction(UTIL_FILE, "", s.DeserializerMethod(), s.deserializerMethodDef())
+	addDecoder(p)
 	p.AddImport(UTIL_FILE, "io")
 }
 
@@ -42,8 +42,7 @@ func encodeFloat(w io.Writer, byteCount int, bits uint64) error {
 `
 const readFloatMethod = `
 func readFloat(r io.Reader) (float32, error) {
-	buf := make([]byte, 4)
-	_, err := io.ReadFull(r, buf)
+	buf, err := decoderFor(r).next(4)
 	if err != nil {
 		return 0, err
 	}
@@ -105,6 +104,7 @@ func (e *floatField) AddSerializer(p *generator.Package) {
 
 func (e *floatField) AddDeserializer(p *generator.Package) {
 	p.AddFunction(UTIL_FILE, "", "readFloat", readFloatMethod)
+	addDecoder(p)
 	p.AddImport(UTIL_FILE, "math")
 	p.AddImport(UTIL_FILE, "encoding/binary")
 	p.AddImport(UTIL_FILE, "io")
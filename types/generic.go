@@ -0,0 +1,560 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+/*
+  GenericCodec encodes and decodes binary Avro for a schema at runtime, without generated code.
+  It interprets the same Field and Definition model the generator uses, so it produces the same
+  bytes as the generated Serialize methods. Values are represented as:
+
+    null            nil
+    boolean         bool
+    int, long       int32, int64
+    float, double   float32, float64
+    bytes, string   []byte, string
+    fixed           []byte of the fixed size
+    enum            string, the symbol
+    array           []interface{}
+    map             map[string]interface{}
+    record          map[string]interface{}, keyed by field name
+    union           nil for the null branch, otherwise a map[string]interface{} with a single key
+                    naming the branch type - the primitive name, "array", "map" or the full name of
+                    a named type - as in the Avro JSON encoding
+*/
+type GenericCodec struct {
+	field Field
+
+	// Limits applied by Decode, which protect against corrupt or hostile input. A limit of 0 disables the check.
+	// Lengths beyond the limits are reported as a *LengthError, and nesting beyond MaxDepth as a *DepthError.
+	MaxStringLength int64
+	MaxBytesLength  int64
+	MaxArrayLength  int64
+	MaxMapLength    int64
+	// The number of records, arrays and maps which may be nested inside each other
+	MaxDepth int
+}
+
+// The default limits for a GenericCodec, which match the defaults of the generated decoders
+const (
+	DefaultMaxStringLength = 128 << 20
+	DefaultMaxBytesLength  = 128 << 20
+	DefaultMaxArrayLength  = 16 << 20
+	DefaultMaxMapLength    = 16 << 20
+	DefaultMaxDepth        = 128
+)
+
+/*
+  LengthError is returned when a length read from the input is negative or exceeds its limit.
+*/
+type LengthError struct {
+	Type   string
+	Length int64
+	Limit  int64
+}
+
+func (e *LengthError) Error() string {
+	if e.Length < 0 {
+		return fmt.Sprintf("Invalid negative %v length %v", e.Type, e.Length)
+	}
+	return fmt.Sprintf("%v length %v exceeds the limit of %v", e.Type, e.Length, e.Limit)
+}
+
+/*
+  DepthError is returned when the input nests records, arrays and maps deeper than the limit.
+*/
+type DepthError struct {
+	Limit int
+}
+
+func (e *DepthError) Error() string {
+	return fmt.Sprintf("Nesting exceeds the limit of %v", e.Limit)
+}
+
+func checkLength(typ string, length, limit int64) error {
+	if length < 0 || (limit > 0 && length > limit) {
+		return &LengthError{Type: typ, Length: length, Limit: limit}
+	}
+	return nil
+}
+
+/*
+  Create a GenericCodec for the schema, given as JSON.
+*/
+func NewGenericCodec(schemaJson []byte) (*GenericCodec, error) {
+	n := NewNamespace()
+	field, err := n.FieldDefinitionForSchema(schemaJson)
+	if err != nil {
+		return nil, err
+	}
+	if err := field.ResolveReferences(n); err != nil {
+		return nil, err
+	}
+	return NewGenericCodecForField(field), nil
+}
+
+/*
+  Create a GenericCodec for a Field whose references have already been resolved.
+*/
+func NewGenericCodecForField(f Field) *GenericCodec {
+	return &GenericCodec{
+		field:           f,
+		MaxStringLength: DefaultMaxStringLength,
+		MaxBytesLength:  DefaultMaxBytesLength,
+		MaxArrayLength:  DefaultMaxArrayLength,
+		MaxMapLength:    DefaultMaxMapLength,
+		MaxDepth:        DefaultMaxDepth,
+	}
+}
+
+func (c *GenericCodec) Encode(w io.Writer, value interface{}) error {
+	var buf bytes.Buffer
+	if err := encodeGeneric(&buf, c.field, value); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (c *GenericCodec) Decode(r io.Reader) (interface{}, error) {
+	br, ok := r.(genericReader)
+	if !ok {
+		br = &byteReader{r}
+	}
+	return (&genericDecoder{codec: c, r: br}).decode(c.field)
+}
+
+func (c *GenericCodec) Marshal(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeGeneric(&buf, c.field, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *GenericCodec) Unmarshal(data []byte) (interface{}, error) {
+	return (&genericDecoder{codec: c, r: bytes.NewReader(data)}).decode(c.field)
+}
+
+type genericReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+/*
+  Adds ReadByte to a reader which doesn't provide it.
+*/
+type byteReader struct {
+	io.Reader
+}
+
+func (b *byteReader) ReadByte() (byte, error) {
+	var buf [1]byte
+	_, err := io.ReadFull(b.Reader, buf[:])
+	return buf[0], err
+}
+
+func wrongGenericType(f Field, expected string, value interface{}) error {
+	return fmt.Errorf("Expected %v for field %q, got %T", expected, f.AvroName(), value)
+}
+
+func encodeGenericLong(buf *bytes.Buffer, value int64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(b[:], value)
+	buf.Write(b[:n])
+}
+
+func encodeGenericBytes(buf *bytes.Buffer, value []byte) {
+	encodeGenericLong(buf, int64(len(value)))
+	buf.Write(value)
+}
+
+func encodeGeneric(buf *bytes.Buffer, f Field, value interface{}) error {
+	switch t := f.(type) {
+	case *nullField:
+		if value != nil {
+			return wrongGenericType(f, "nil", value)
+		}
+	case *boolField:
+		b, ok := value.(bool)
+		if !ok {
+			return wrongGenericType(f, "bool", value)
+		}
+		if b {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case *intField:
+		i, ok := value.(int32)
+		if !ok {
+			return wrongGenericType(f, "int32", value)
+		}
+		encodeGenericLong(buf, int64(i))
+	case *longField:
+		i, ok := value.(int64)
+		if !ok {
+			return wrongGenericType(f, "int64", value)
+		}
+		encodeGenericLong(buf, i)
+	case *floatField:
+		v, ok := value.(float32)
+		if !ok {
+			return wrongGenericType(f, "float32", value)
+		}
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], math.Float32bits(v))
+		buf.Write(b[:])
+	case *doubleField:
+		v, ok := value.(float64)
+		if !ok {
+			return wrongGenericType(f, "float64", value)
+		}
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+		buf.Write(b[:])
+	case *bytesField:
+		b, ok := value.([]byte)
+		if !ok {
+			return wrongGenericType(f, "[]byte", value)
+		}
+		encodeGenericBytes(buf, b)
+	case *stringField:
+		s, ok := value.(string)
+		if !ok {
+			return wrongGenericType(f, "string", value)
+		}
+		encodeGenericBytes(buf, []byte(s))
+	case *arrayField:
+		items, ok := value.([]interface{})
+		if !ok {
+			return wrongGenericType(f, "[]interface{}", value)
+		}
+		// Like the generated code, arrays are written as a single block
+		encodeGenericLong(buf, int64(len(items)))
+		if len(items) == 0 {
+			return nil
+		}
+		for _, item := range items {
+			if err := encodeGeneric(buf, t.itemType, item); err != nil {
+				return err
+			}
+		}
+		encodeGenericLong(buf, 0)
+	case *mapField:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return wrongGenericType(f, "map[string]interface{}", value)
+		}
+		encodeGenericLong(buf, int64(len(m)))
+		if len(m) == 0 {
+			return nil
+		}
+		// Keys are sorted so the output is deterministic
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			encodeGenericBytes(buf, []byte(k))
+			if err := encodeGeneric(buf, t.itemType, m[k]); err != nil {
+				return err
+			}
+		}
+		encodeGenericLong(buf, 0)
+	case *unionField:
+		return encodeGenericUnion(buf, t, value)
+	case *Reference:
+		return encodeGenericDefinition(buf, t.def, value)
+	default:
+		return fmt.Errorf("Unable to encode field %q of type %T", f.AvroName(), f)
+	}
+	return nil
+}
+
+func encodeGenericUnion(buf *bytes.Buffer, u *unionField, value interface{}) error {
+	if value == nil {
+		for i, item := range u.itemType {
+			if _, isNull := item.(*nullField); isNull {
+				encodeGenericLong(buf, int64(i))
+				return nil
+			}
+		}
+		return fmt.Errorf("Union field %q has no null branch", u.AvroName())
+	}
+
+	wrapper, ok := value.(map[string]interface{})
+	if !ok || len(wrapper) != 1 {
+		return fmt.Errorf("Expected a map with a single branch name for union field %q, got %v", u.AvroName(), value)
+	}
+	for branch, branchValue := range wrapper {
+		for i, item := range u.itemType {
+			if jsonBranchName(item) == branch {
+				encodeGenericLong(buf, int64(i))
+				return encodeGeneric(buf, item, branchValue)
+			}
+		}
+		return fmt.Errorf("Invalid branch %q for union field %q", branch, u.AvroName())
+	}
+	return nil
+}
+
+func encodeGenericDefinition(buf *bytes.Buffer, d Definition, value interface{}) error {
+	switch t := d.(type) {
+	case *RecordDefinition:
+		record, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("Expected map[string]interface{} for record %v, got %T", t.name, value)
+		}
+		for _, f := range t.fields {
+			fieldValue, ok := record[f.AvroName()]
+			if !ok {
+				return fmt.Errorf("Missing value for field %q of record %v", f.AvroName(), t.name)
+			}
+			if err := encodeGeneric(buf, f, fieldValue); err != nil {
+				return err
+			}
+		}
+	case *EnumDefinition:
+		symbol, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("Expected string for enum %v, got %T", t.name, value)
+		}
+		for i, s := range t.symbols {
+			if s == symbol {
+				encodeGenericLong(buf, int64(i))
+				return nil
+			}
+		}
+		return fmt.Errorf("Invalid symbol %q for enum %v", symbol, t.name)
+	case *FixedDefinition:
+		b, ok := value.([]byte)
+		if !ok {
+			return fmt.Errorf("Expected []byte for fixed %v, got %T", t.name, value)
+		}
+		if len(b) != t.sizeBytes {
+			return fmt.Errorf("Expected %v bytes for fixed %v, got %v", t.sizeBytes, t.name, len(b))
+		}
+		buf.Write(b)
+	default:
+		return fmt.Errorf("Unable to encode definition %v of type %T", d.AvroName(), d)
+	}
+	return nil
+}
+
+/*
+  The state of a single call to Decode.
+*/
+type genericDecoder struct {
+	codec *GenericCodec
+	r     genericReader
+	depth int
+}
+
+func (d *genericDecoder) readLong() (int64, error) {
+	return binary.ReadVarint(d.r)
+}
+
+func (d *genericDecoder) readBytes(typ string, limit int64) ([]byte, error) {
+	size, err := d.readLong()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLength(typ, size, limit); err != nil {
+		return nil, err
+	}
+	b := make([]byte, size)
+	_, err = io.ReadFull(d.r, b)
+	return b, err
+}
+
+/*
+  Enter a record, array or map, checking the nesting limit. Every call is paired with a call to leave.
+*/
+func (d *genericDecoder) enter() error {
+	d.depth++
+	if d.codec.MaxDepth > 0 && d.depth > d.codec.MaxDepth {
+		return &DepthError{Limit: d.codec.MaxDepth}
+	}
+	return nil
+}
+
+func (d *genericDecoder) leave() {
+	d.depth--
+}
+
+/*
+  Read the count of the next block of an array or map, and check the running total against the limit.
+  Negative counts are followed by the size of the block in bytes.
+*/
+func (d *genericDecoder) readBlockCount(typ string, total *int64, limit int64) (int64, error) {
+	count, err := d.readLong()
+	if err != nil {
+		return 0, err
+	}
+	if count < 0 {
+		count = -count
+		if _, err := d.readLong(); err != nil {
+			return 0, err
+		}
+	}
+	*total += count
+	if err := checkLength(typ, *total, limit); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (d *genericDecoder) decode(f Field) (interface{}, error) {
+	switch t := f.(type) {
+	case *nullField:
+		return nil, nil
+	case *boolField:
+		b, err := d.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b > 1 {
+			return nil, fmt.Errorf("Invalid value %v for boolean field %q", b, t.AvroName())
+		}
+		return b == 1, nil
+	case *intField:
+		i, err := d.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if i < math.MinInt32 || i > math.MaxInt32 {
+			return nil, fmt.Errorf("Value %v of int field %q overflows an int", i, t.AvroName())
+		}
+		return int32(i), nil
+	case *longField:
+		return d.readLong()
+	case *floatField:
+		var b [4]byte
+		if _, err := io.ReadFull(d.r, b[:]); err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b[:])), nil
+	case *doubleField:
+		var b [8]byte
+		if _, err := io.ReadFull(d.r, b[:]); err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b[:])), nil
+	case *bytesField:
+		return d.readBytes("bytes", d.codec.MaxBytesLength)
+	case *stringField:
+		b, err := d.readBytes("string", d.codec.MaxStringLength)
+		return string(b), err
+	case *arrayField:
+		if err := d.enter(); err != nil {
+			return nil, err
+		}
+		defer d.leave()
+		items := make([]interface{}, 0)
+		var total int64
+		for {
+			count, err := d.readBlockCount("array", &total, d.codec.MaxArrayLength)
+			if err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				return items, nil
+			}
+			for i := int64(0); i < count; i++ {
+				item, err := d.decode(t.itemType)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+			}
+		}
+	case *mapField:
+		if err := d.enter(); err != nil {
+			return nil, err
+		}
+		defer d.leave()
+		m := make(map[string]interface{})
+		var total int64
+		for {
+			count, err := d.readBlockCount("map", &total, d.codec.MaxMapLength)
+			if err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				return m, nil
+			}
+			for i := int64(0); i < count; i++ {
+				key, err := d.readBytes("string", d.codec.MaxStringLength)
+				if err != nil {
+					return nil, err
+				}
+				value, err := d.decode(t.itemType)
+				if err != nil {
+					return nil, err
+				}
+				m[string(key)] = value
+			}
+		}
+	case *unionField:
+		index, err := d.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 || index >= int64(len(t.itemType)) {
+			return nil, fmt.Errorf("Invalid branch %v for union field %q", index, t.AvroName())
+		}
+		item := t.itemType[index]
+		if _, isNull := item.(*nullField); isNull {
+			return nil, nil
+		}
+		value, err := d.decode(item)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{jsonBranchName(item): value}, nil
+	case *Reference:
+		return d.decodeDefinition(t.def)
+	}
+	return nil, fmt.Errorf("Unable to decode field %q of type %T", f.AvroName(), f)
+}
+
+func (d *genericDecoder) decodeDefinition(def Definition) (interface{}, error) {
+	switch t := def.(type) {
+	case *RecordDefinition:
+		if err := d.enter(); err != nil {
+			return nil, err
+		}
+		defer d.leave()
+		record := make(map[string]interface{})
+		for _, f := range t.fields {
+			value, err := d.decode(f)
+			if err != nil {
+				return nil, err
+			}
+			record[f.AvroName()] = value
+		}
+		return record, nil
+	case *EnumDefinition:
+		index, err := d.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 || index >= int64(len(t.symbols)) {
+			return nil, fmt.Errorf("Invalid symbol index %v for enum %v", index, t.name)
+		}
+		return t.symbols[index], nil
+	case *FixedDefinition:
+		b := make([]byte, t.sizeBytes)
+		_, err := io.ReadFull(d.r, b)
+		return b, err
+	}
+	return nil, fmt.Errorf("Unable to decode definition %v of type %T", def.AvroName(), def)
+}
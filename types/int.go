@@ -63,14 +63,18 @@ func encodeInt(w io.Writer, byteCount int, encoded uint64) error {
 
 const readIntMethod = `
 func readInt(r io.Reader) (int32, error) {
-	var v int
-	buf := make([]byte, 1)
+	d := decoderFor(r)
+	var v uint32
 	for shift := uint(0); ; shift += 7 {
-		if _, err := io.ReadFull(r, buf); err != nil {
+		b, err := d.ReadByte()
+		if err != nil {
 			return 0, err
 		}
-		b := buf[0]
-		v |= int(b&127) << shift
+		// The fifth byte holds the top 4 bits, and must be the last
+		if shift == 28 && b > 15 {
+			return 0, fmt.Errorf("Varint overflows an int")
+		}
+		v |= uint32(b&127) << shift
 		if b&128 == 0 {
 			break
 		}
@@ -130,6 +134,8 @@ func (s *intField) AddSerializer(p *generator.Package) {
 
 func (s *intField) AddDeserializer(p *generator.Package) {
 	p.AddFunction(UTIL_FILE, "", "readInt", readIntMethod)
+	p.AddImport(UTIL_FILE, "fmt")
+	addDecoder(p)
 	p.AddImport(UTIL_FILE, "io")
 }
 
@@ -0,0 +1,297 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alanctgardner/gogen-avro/generator"
+)
+
+const readBytesIntoMethod = `
+func readBytesInto(r io.Reader, dst *[]byte) error {
+	size, err := readLong(r)
+	if err != nil {
+		return err
+	}
+	if err := checkLength("bytes", size, MaxBytesLength); err != nil {
+		return err
+	}
+	bb := *dst
+	if bb == nil || int64(cap(bb)) < size {
+		// The Decoder only allocates as much as the input holds, in case the length is corrupt
+		bb, err = decoderFor(r).bytes(int(size))
+		if err != nil {
+			return err
+		}
+		*dst = bb
+		return nil
+	}
+	bb = bb[:size]
+	if _, err := io.ReadFull(decoderFor(r), bb); err != nil {
+		return err
+	}
+	*dst = bb
+	return nil
+}
+`
+
+const readArrayIntoTemplate = `
+func %v(r io.Reader, dst *%v) error {
+	var err error
+	var blkSize, total int64
+	arr := (*dst)[:0]
+	if arr == nil {
+		arr = make(%v, 0)
+	}
+	for {
+		blkSize, err = readLong(r)
+		if err != nil {
+			return err
+		}
+		if blkSize == 0 {
+			break
+		}
+		if blkSize < 0 {
+			blkSize = -blkSize
+			_, err = readLong(r)
+			if err != nil {
+				return err
+			}
+		}
+		total += blkSize
+		if err := checkLength("array", total, MaxArrayLength); err != nil {
+			return err
+		}
+		for i := int64(0); i < blkSize; i++ {
+			n := len(arr)
+			if n < cap(arr) {
+				// Reuse the element left in the array's capacity
+				arr = arr[:n+1]
+			} else {
+				var zero %v
+				arr = append(arr, zero)
+			}
+			%v
+			if err != nil {
+				return wrapDecodeError(err, fmt.Sprintf("[%%d]", n), %q)
+			}
+		}
+	}
+	*dst = arr
+	return nil
+}
+`
+
+const readMapIntoTemplate = `
+func %v(r io.Reader, dst *%v) error {
+	m := *dst
+	if m == nil {
+		m = make(%v)
+	}
+	for k := range m {
+		delete(m, k)
+	}
+	var total int64
+	for {
+		blkSize, err := readLong(r)
+		if err != nil {
+			return err
+		}
+		if blkSize == 0 {
+			break
+		}
+		if blkSize < 0 {
+			blkSize = -blkSize
+			_, err := readLong(r)
+			if err != nil {
+				return err
+			}
+		}
+		total += blkSize
+		if err := checkLength("map", total, MaxMapLength); err != nil {
+			return err
+		}
+		for i := int64(0); i < blkSize; i++ {
+			key, err := readString(r)
+			if err != nil {
+				return err
+			}
+			val, err := %v(r)
+			if err != nil {
+				return wrapDecodeError(err, fmt.Sprintf("[%%q]", key), %q)
+			}
+			m[key] = val
+		}
+	}
+	*dst = m
+	return nil
+}
+`
+
+const readRecordIntoTemplate = `
+func %v(r io.Reader, dst %v) error {
+	if err := enterRecord(r); err != nil {
+		return err
+	}
+	defer leaveRecord(r)
+%v	return nil
+}
+`
+
+const readImportedRecordIntoTemplate = `
+func %v(r io.Reader, dst %v) error {
+	return %v(r, dst)
+}
+`
+
+const recordDeserializeIntoTemplate = `
+// %v decodes a %v from r into dst, reusing its nested records and the storage of its slices and maps
+// instead of allocating new ones. Values which dst held before are overwritten.
+func %v(r io.Reader, dst %v) error {
+	d := decoderFor(r)
+	start := d.Offset()
+	if err := %v(d, dst); err != nil {
+		return decodeErrorAt(err, %q, %q, start, d.Offset())
+	}
+	return nil
+}
+`
+
+const recordResetTemplate = `
+// Reset clears the record, but keeps its nested records and the storage of its slices and maps for DeserializeInto to reuse.
+// Records can be pooled with sync.Pool by resetting them before they're put back.
+func (r %v) Reset() {
+%v	*r = %v{%v}
+}
+`
+
+func intoMethod(f Field) string {
+	return "read" + f.FieldType() + "Into"
+}
+
+func definitionIntoMethod(d Definition) string {
+	return "read" + d.FieldType() + "Into"
+}
+
+/*
+  The record a field refers to, or nil if it isn't a reference to a record.
+*/
+func intoRecord(f Field) *RecordDefinition {
+	if ref, ok := f.(*Reference); ok {
+		if def, ok := ref.def.(*RecordDefinition); ok {
+			return def
+		}
+	}
+	return nil
+}
+
+/*
+  Whether values of the field's type are read in place, reusing the storage in the destination.
+  Other types are read with their deserializer and assigned.
+*/
+func hasInto(f Field) bool {
+	switch f.(type) {
+	case *bytesField, *arrayField, *mapField:
+		return true
+	}
+	return intoRecord(f) != nil
+}
+
+/*
+  Statements which read a value of the field's type into the addressable expression target, and set err.
+*/
+func intoStatement(f Field, target string) string {
+	if def := intoRecord(f); def != nil {
+		return fmt.Sprintf("if %v == nil {\n%v = &%v{}\n}\nerr = %v(r, %v)", target, target, def.structType(), definitionIntoMethod(def), target)
+	}
+	if hasInto(f) {
+		return fmt.Sprintf("err = %v(r, &%v)", intoMethod(f), target)
+	}
+	return fmt.Sprintf("%v, err = %v(r)", target, f.DeserializerMethod())
+}
+
+/*
+  Add the function reading a value of the field's type in place, and the functions for every type beneath it.
+*/
+func addIntoField(p *generator.Package, f Field) {
+	if !hasInto(f) || p.HasFunction(UTIL_FILE, "", intoMethod(f)) {
+		return
+	}
+	switch t := f.(type) {
+	case *bytesField:
+		p.AddFunction(UTIL_FILE, "", intoMethod(t), readBytesIntoMethod)
+	case *arrayField:
+		def := fmt.Sprintf(readArrayIntoTemplate, intoMethod(t), t.GoType(), t.GoType(), t.itemType.GoType(), intoStatement(t.itemType, "arr[n]"), decodeTypeName(t.itemType))
+		p.AddFunction(UTIL_FILE, "", intoMethod(t), def)
+		p.AddImport(UTIL_FILE, "fmt")
+		addIntoField(p, t.itemType)
+	case *mapField:
+		def := fmt.Sprintf(readMapIntoTemplate, intoMethod(t), t.GoType(), t.GoType(), t.itemType.DeserializerMethod(), decodeTypeName(t.itemType))
+		p.AddFunction(UTIL_FILE, "", intoMethod(t), def)
+		p.AddImport(UTIL_FILE, "fmt")
+	case *Reference:
+		intoRecord(t).addIntoRecord(p)
+	}
+}
+
+func (r *RecordDefinition) addIntoRecord(p *generator.Package) {
+	if p.HasFunction(UTIL_FILE, "", definitionIntoMethod(r)) {
+		return
+	}
+	if r.pkg != "" {
+		p.AddFunction(UTIL_FILE, "", definitionIntoMethod(r), fmt.Sprintf(readImportedRecordIntoTemplate, definitionIntoMethod(r), r.GoType(), r.pkg+".Deserialize"+r.name.goName(false)+"Into"))
+		return
+	}
+	fieldReaders := ""
+	if len(r.fields) > 0 {
+		fieldReaders = "var err error\n"
+	}
+	for _, f := range r.fields {
+		fieldReaders += fmt.Sprintf("%v\nif err != nil {\nreturn wrapDecodeError(err, %q, %q)\n}\n", intoStatement(f, "dst."+f.GoName()), f.AvroName(), decodeTypeName(f))
+	}
+	p.AddFunction(UTIL_FILE, "", definitionIntoMethod(r), fmt.Sprintf(readRecordIntoTemplate, definitionIntoMethod(r), r.GoType(), fieldReaders))
+	for _, f := range r.fields {
+		addIntoField(p, f)
+	}
+}
+
+/*
+  The Reset method keeps the storage of bytes, arrays, maps and nested records, and zeroes everything else.
+*/
+func (r *RecordDefinition) resetMethodDef() string {
+	clear := ""
+	var kept []string
+	for _, f := range r.fields {
+		switch f.(type) {
+		case *bytesField, *arrayField:
+			kept = append(kept, fmt.Sprintf("%v: r.%v[:0]", f.GoName(), f.GoName()))
+		case *mapField:
+			clear += fmt.Sprintf("\tfor k := range r.%v {\n\t\tdelete(r.%v, k)\n\t}\n", f.GoName(), f.GoName())
+			kept = append(kept, fmt.Sprintf("%v: r.%v", f.GoName(), f.GoName()))
+		case *Reference:
+			if intoRecord(f) != nil {
+				clear += fmt.Sprintf("\tif r.%v != nil {\n\t\tr.%v.Reset()\n\t}\n", f.GoName(), f.GoName())
+				kept = append(kept, fmt.Sprintf("%v: r.%v", f.GoName(), f.GoName()))
+			}
+		}
+	}
+	return fmt.Sprintf(recordResetTemplate, r.GoType(), clear, r.FieldType(), strings.Join(kept, ", "))
+}
+
+func (r *RecordDefinition) publicDeserializeIntoMethod() string {
+	return fmt.Sprintf("Deserialize%vInto", r.FieldType())
+}
+
+/*
+  Add Deserialize<Record>Into, which decodes into an existing record, and Reset, which clears a record for reuse.
+*/
+func (r *RecordDefinition) AddDeserializeInto(p *generator.Package) {
+	// Import guard, to avoid circular dependencies
+	if p.HasFunction(r.filename(), "", r.publicDeserializeIntoMethod()) {
+		return
+	}
+	r.addIntoRecord(p)
+	def := fmt.Sprintf(recordDeserializeIntoTemplate, r.publicDeserializeIntoMethod(), r.FieldType(), r.publicDeserializeIntoMethod(), r.GoType(), definitionIntoMethod(r), r.name.Name, r.name.String())
+	p.AddFunction(r.filename(), "", r.publicDeserializeIntoMethod(), def)
+	p.AddFunction(r.filename(), r.GoType(), "Reset", r.resetMethodDef())
+}
@@ -0,0 +1,110 @@
+package types
+
+import (
+	"github.com/alanctgardner/gogen-avro/generator"
+)
+
+const lengthLimitsDef = `
+// Limits on the lengths read by the generated decoders, which protect against corrupt or hostile input.
+// A limit of 0 disables the check.
+var (
+	MaxStringLength int64 = 128 << 20
+	MaxBytesLength  int64 = 128 << 20
+	MaxArrayLength  int64 = 16 << 20
+	MaxMapLength    int64 = 16 << 20
+)
+`
+
+const lengthErrorDef = `
+// LengthError is returned by the generated decoders when a length read from the input is negative or exceeds its limit
+type LengthError struct {
+	Type   string
+	Length int64
+	Limit  int64
+}
+`
+
+const lengthErrorMethodDef = `
+func (e *LengthError) Error() string {
+	if e.Length < 0 {
+		return fmt.Sprintf("Invalid negative %v length %v", e.Type, e.Length)
+	}
+	return fmt.Sprintf("%v length %v exceeds the limit of %v", e.Type, e.Length, e.Limit)
+}
+`
+
+const checkLengthMethod = `
+func checkLength(typ string, length, limit int64) error {
+	if length < 0 || (limit > 0 && length > limit) {
+		return &LengthError{Type: typ, Length: length, Limit: limit}
+	}
+	return nil
+}
+`
+
+const depthLimitDef = `
+// The number of records the generated decoders allow to be nested inside each other. It protects against
+// running out of stack on corrupt or hostile input for recursive schemas. A limit of 0 disables the check.
+var MaxDepth = 128
+`
+
+const depthErrorDef = `
+// DepthError is returned by the generated decoders when records are nested deeper than MaxDepth
+type DepthError struct {
+	Limit int
+}
+`
+
+const depthErrorMethodDef = `
+func (e *DepthError) Error() string {
+	return fmt.Sprintf("Nesting exceeds the limit of %v", e.Limit)
+}
+`
+
+const enterRecordMethod = `
+// enterRecord counts a record being read from r, and returns a *DepthError if it's nested deeper than MaxDepth.
+// Every call which succeeds has to be followed by leaveRecord.
+func enterRecord(r io.Reader) error {
+	d, ok := r.(*Decoder)
+	if !ok {
+		return nil
+	}
+	if MaxDepth > 0 && d.depth >= MaxDepth {
+		return &DepthError{Limit: MaxDepth}
+	}
+	d.depth++
+	return nil
+}
+`
+
+const leaveRecordMethod = `
+func leaveRecord(r io.Reader) {
+	if d, ok := r.(*Decoder); ok {
+		d.depth--
+	}
+}
+`
+
+/*
+  Add the depth limit and the DepthError type, used by the decoders for records.
+*/
+func addDepthLimit(p *generator.Package) {
+	p.AddStruct(UTIL_FILE, "MaxDepth", depthLimitDef)
+	p.AddStruct(UTIL_FILE, "DepthError", depthErrorDef)
+	p.AddImport(UTIL_FILE, "fmt")
+	p.AddImport(UTIL_FILE, "io")
+	p.AddFunction(UTIL_FILE, "*DepthError", "Error", depthErrorMethodDef)
+	p.AddFunction(UTIL_FILE, "", "enterRecord", enterRecordMethod)
+	p.AddFunction(UTIL_FILE, "", "leaveRecord", leaveRecordMethod)
+}
+
+/*
+  Add the length limits and the LengthError type, used by the decoders for strings, bytes, arrays and maps.
+*/
+func addLengthLimits(p *generator.Package) {
+	p.AddStruct(UTIL_FILE, "lengthLimits", lengthLimitsDef)
+	p.AddStruct(UTIL_FILE, "LengthError", lengthErrorDef)
+	p.AddImport(UTIL_FILE, "fmt")
+	p.AddFunction(UTIL_FILE, "*LengthError", "Error", lengthErrorMethodDef)
+	p.AddFunction(UTIL_FILE, "", "checkLength", checkLengthMethod)
+}
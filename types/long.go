@@ -15,13 +15,17 @@ func writeLong(r int64, w io.Writer) error {
 
 const readLongMethod = `
 func readLong(r io.Reader) (int64, error) {
+	d := decoderFor(r)
 	var v uint64
-	buf := make([]byte, 1)
 	for shift := uint(0); ; shift += 7 {
-		if _, err := io.ReadFull(r, buf); err != nil {
+		b, err := d.ReadByte()
+		if err != nil {
 			return 0, err
 		}
-		b := buf[0]
+		// The tenth byte holds the top bit, and must be the last
+		if shift == 63 && b > 1 {
+			return 0, fmt.Errorf("Varint overflows a long")
+		}
 		v |= uint64(b&127) << shift
 		if b&128 == 0 {
 			break
@@ -82,6 +86,8 @@ func (s *longField) AddSerializer(p *generator.Package) {
 
 func (s *longField) AddDeserializer(p *generator.Package) {
 	p.AddFunction(UTIL_FILE, "", "readLong", readLongMethod)
+	p.AddImport(UTIL_FILE, "fmt")
+	addDecoder(p)
 	p.AddImport(UTIL_FILE, "io")
 }
 
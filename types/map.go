@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/alanctgardner/gogen-avro/generator"
 )
@@ -26,9 +27,35 @@ func %v(r %v, w io.Writer) error {
 }
 `
 
+const sortedMapSerializerTemplate = `
+func %v(r %v, w io.Writer) error {
+	err := writeLong(int64(len(r)), w)
+	if err != nil || len(r) == 0 {
+		return err
+	}
+	keys := make([]string, 0, len(r))
+	for k := range r {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		err = writeString(k, w)
+		if err != nil {
+			return err
+		}
+		err = %v(r[k], w)
+		if err != nil {
+			return err
+		}
+	}
+	return writeLong(0, w)
+}
+`
+
 const mapDeserializerTemplate = `
 func %v(r io.Reader) (%v, error) {
 	m := make(%v)
+	var total int64
 	for {
 		blkSize, err := readLong(r)
 		if err != nil {
@@ -44,6 +71,10 @@ func %v(r io.Reader) (%v, error) {
 				return nil, err
 			}
 		}
+		total += blkSize
+		if err := checkLength("map", total, MaxMapLength); err != nil {
+			return nil, err
+		}
 		for i := int64(0); i < blkSize; i++ {
 			key, err := readString(r)
 			if err != nil {
@@ -51,7 +82,7 @@ func %v(r io.Reader) (%v, error) {
 			}
 			val, err := %v(r)
 			if err != nil {
-				return nil, err
+				return nil, wrapDecodeError(err, fmt.Sprintf("[%%q]", key), %q)
 			}
 			m[key] = val
 		}
@@ -66,6 +97,8 @@ type mapField struct {
 	hasDefault   bool
 	defaultValue interface{}
 	metadata     map[string]interface{}
+	// Whether entries are serialized in sorted key order, so equal maps always have the same encoding
+	sortedKeys bool
 }
 
 func (s *mapField) HasDefault() bool {
@@ -93,7 +126,19 @@ func (s *mapField) GoType() string {
 }
 
 func (s *mapField) SerializerMethod() string {
-	return fmt.Sprintf("write%v", s.FieldType())
+	if s.sortedKeys {
+		return "writeSortedMap" + serializerSuffix(s.itemType)
+	}
+	return "writeMap" + serializerSuffix(s.itemType)
+}
+
+/*
+  The name of the serializer for f without its write prefix. Arrays, maps and unions name their serializers
+  after their items' serializers rather than their types, so one which holds a map with sorted keys gets its
+  own serializer instead of sharing one with the same type holding an unsorted map.
+*/
+func serializerSuffix(f Field) string {
+	return strings.TrimPrefix(f.SerializerMethod(), "write")
 }
 
 func (s *mapField) DeserializerMethod() string {
@@ -108,7 +153,12 @@ func (s *mapField) AddSerializer(p *generator.Package) {
 	s.itemType.AddSerializer(p)
 	itemMethodName := s.itemType.SerializerMethod()
 	methodName := s.SerializerMethod()
-	mapSerializer := fmt.Sprintf(mapSerializerTemplate, s.SerializerMethod(), s.GoType(), itemMethodName)
+	template := mapSerializerTemplate
+	if s.sortedKeys {
+		template = sortedMapSerializerTemplate
+		p.AddImport(UTIL_FILE, "sort")
+	}
+	mapSerializer := fmt.Sprintf(template, s.SerializerMethod(), s.GoType(), itemMethodName)
 
 	p.AddStruct(UTIL_FILE, "ByteWriter", byteWriterInterface)
 	p.AddStruct(UTIL_FILE, "StringWriter", stringWriterInterface)
@@ -123,11 +173,15 @@ func (s *mapField) AddDeserializer(p *generator.Package) {
 	s.itemType.AddDeserializer(p)
 	itemMethodName := s.itemType.DeserializerMethod()
 	methodName := s.DeserializerMethod()
-	mapDeserializer := fmt.Sprintf(mapDeserializerTemplate, s.DeserializerMethod(), s.GoType(), s.GoType(), itemMethodName)
+	mapDeserializer := fmt.Sprintf(mapDeserializerTemplate, s.DeserializerMethod(), s.GoType(), s.GoType(), itemMethodName, decodeTypeName(s.itemType))
 
 	p.AddFunction(UTIL_FILE, "", "readLong", readLongMethod)
+	p.AddImport(UTIL_FILE, "fmt")
 	p.AddFunction(UTIL_FILE, "", "readString", readStringMethod)
+	addDecoder(p)
 	p.AddFunction(UTIL_FILE, "", methodName, mapDeserializer)
+	addLengthLimits(p)
+	addDecodeErrors(p)
 	p.AddImport(UTIL_FILE, "io")
 }
 
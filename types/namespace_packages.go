@@ -0,0 +1,304 @@
+package types
+
+import (
+	"fmt"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/alanctgardner/gogen-avro/generator"
+)
+
+/*
+  The directory of the package for an Avro namespace, relative to the generated package.
+*/
+func namespaceDir(namespace string) string {
+	return path.Join(strings.Split(namespace, ".")...)
+}
+
+/*
+  The name of the package for an Avro namespace, which is its last part.
+*/
+func namespacePackageName(namespace string) string {
+	parts := strings.Split(namespace, ".")
+	name := strings.ToLower(parts[len(parts)-1])
+	if token.IsKeyword(name) {
+		return name + "_"
+	}
+	return name
+}
+
+/*
+  The name the package for an Avro namespace is imported as: the parts of the namespace joined by
+  underscores, like com_example_people, or the namespace followed by an underscore if it has one part.
+  The names the generated code gives its imports and variables have no underscores, so they can't
+  shadow it. The package for the empty namespace is the generated package itself.
+*/
+func namespaceImportName(namespace string) string {
+	if namespace == "" {
+		return "root_"
+	}
+	if !strings.Contains(namespace, ".") {
+		return namespace + "_"
+	}
+	return strings.Replace(namespace, ".", "_", -1)
+}
+
+func namespaceName(namespace string) string {
+	if namespace == "" {
+		return "the empty namespace"
+	}
+	return namespace
+}
+
+/*
+  A copy of a definition for use from another namespace's package, which refers to its Go type through
+  the package it's imported from. Functions which read and write it are generated in the package using
+  it, and they call the exported methods of records instead of the record's own functions.
+*/
+func importedDefinition(def Definition) Definition {
+	pkg := namespaceImportName(def.AvroName().Namespace)
+	switch t := def.(type) {
+	case *RecordDefinition:
+		imported := *t
+		imported.pkg = pkg
+		return &imported
+	case *EnumDefinition:
+		imported := *t
+		imported.pkg = pkg
+		return &imported
+	case *FixedDefinition:
+		imported := *t
+		imported.pkg = pkg
+		return &imported
+	}
+	return def
+}
+
+/*
+  The package generated for an Avro namespace below the generated package p.
+*/
+func namespacePackage(p *generator.Package, namespace string) *generator.Package {
+	if namespace == "" {
+		return p
+	}
+	return p.SubPackage(namespaceDir(namespace), namespacePackageName(namespace))
+}
+
+/*
+  The references in a field, and in the types beneath it up to the definitions they refer to.
+*/
+func fieldReferences(f Field) []*Reference {
+	switch t := f.(type) {
+	case *Reference:
+		return []*Reference{t}
+	case *arrayField:
+		return fieldReferences(t.itemType)
+	case *mapField:
+		return fieldReferences(t.itemType)
+	case *unionField:
+		var refs []*Reference
+		for _, item := range t.itemType {
+			refs = append(refs, fieldReferences(item)...)
+		}
+		return refs
+	}
+	return nil
+}
+
+/*
+  An import of one namespace's package by another, because of a reference to one of its definitions.
+*/
+type namespaceImport struct {
+	from, to string
+	// Why the package is imported, like "com.example.shop.Order refers to com.example.people.Customer"
+	reason string
+}
+
+/*
+  Add the imports of other namespaces' packages needed by the references in fields, which are generated
+  in the package for the namespace from. user is the name of what holds the fields, for the reason.
+*/
+func addNamespaceImports(imports map[string]map[string]namespaceImport, from, user string, fields ...Field) {
+	for _, f := range fields {
+		for _, ref := range fieldReferences(f) {
+			to := ref.def.AvroName().Namespace
+			if to == from {
+				continue
+			}
+			if imports[from] == nil {
+				imports[from] = make(map[string]namespaceImport)
+			}
+			if _, ok := imports[from][to]; !ok {
+				imports[from][to] = namespaceImport{from, to, fmt.Sprintf("%v refers to %v", user, ref.def.AvroName())}
+			}
+		}
+	}
+}
+
+/*
+  Return an error describing the first cycle of imports, if there is one. Go packages can't import each
+  other, so the namespaces in a cycle can't be generated as separate packages.
+*/
+func checkImportCycles(namespaces []string, imports map[string]map[string]namespaceImport) error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var stack []namespaceImport
+	var visit func(namespace string) error
+	visit = func(namespace string) error {
+		state[namespace] = visiting
+		targets := make([]string, 0, len(imports[namespace]))
+		for to := range imports[namespace] {
+			targets = append(targets, to)
+		}
+		sort.Strings(targets)
+		for _, to := range targets {
+			stack = append(stack, imports[namespace][to])
+			switch state[to] {
+			case visiting:
+				// The cycle is the imports on the stack since the one from the namespace being imported
+				start := len(stack) - 1
+				for stack[start].from != to {
+					start--
+				}
+				names := []string{namespaceName(to)}
+				reasons := make([]string, 0, len(stack)-start)
+				for _, i := range stack[start:] {
+					names = append(names, namespaceName(i.to))
+					reasons = append(reasons, i.reason)
+				}
+				return fmt.Errorf("The namespaces %v refer to each other in a cycle, so their packages would import each other (%v)", strings.Join(names, " -> "), strings.Join(reasons, ", "))
+			case unvisited:
+				if err := visit(to); err != nil {
+					return err
+				}
+			}
+			stack = stack[:len(stack)-1]
+		}
+		state[namespace] = visited
+		return nil
+	}
+	for _, namespace := range namespaces {
+		if state[namespace] == unvisited {
+			if err := visit(namespace); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/*
+  Generate each Avro namespace as its own package below the generated package p, in a subdirectory named
+  after the namespace, with its records, enums and fixed types under their short names. Definitions without
+  a namespace are generated in p. A package imports the packages of the other namespaces its definitions
+  refer to, so namespaces which refer to each other in a cycle are rejected. n.NamespacePackages is the
+  import path of p.
+*/
+func (n *Namespace) AddNamespacePackages(p *generator.Package) error {
+	for _, schema := range n.Schemas {
+		if err := schema.Root.ResolveReferences(n); err != nil {
+			return err
+		}
+	}
+	for _, protocol := range n.Protocols {
+		if err := protocol.ResolveReferences(n); err != nil {
+			return err
+		}
+	}
+
+	// Skip the entries for aliases
+	names := make([]QualifiedName, 0, len(n.Definitions))
+	for name, def := range n.Definitions {
+		if name == def.AvroName() {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i].String() < names[j].String()
+	})
+
+	imports := make(map[string]map[string]namespaceImport)
+	namespaces := make(map[string]bool)
+	for _, name := range names {
+		def := n.Definitions[name]
+		if err := def.ResolveReferences(n); err != nil {
+			return err
+		}
+		namespaces[name.Namespace] = true
+		if record, ok := def.(*RecordDefinition); ok {
+			addNamespaceImports(imports, name.Namespace, name.String(), record.fields...)
+		}
+	}
+	for _, protocol := range n.Protocols {
+		namespaces[protocol.name.Namespace] = true
+		for _, m := range protocol.messages {
+			addNamespaceImports(imports, protocol.name.Namespace, protocol.name.String(), m.response)
+			if m.errors != nil {
+				addNamespaceImports(imports, protocol.name.Namespace, protocol.name.String(), m.errors)
+			}
+		}
+	}
+	for _, schema := range n.Schemas {
+		// A schema which is a named type is generated with its definition
+		if _, ok := schema.Root.(*Reference); !ok {
+			namespaces[""] = true
+			addNamespaceImports(imports, "", "A schema", schema.Root)
+		}
+	}
+
+	sorted := make([]string, 0, len(namespaces))
+	for namespace := range namespaces {
+		sorted = append(sorted, namespace)
+	}
+	sort.Strings(sorted)
+	importNames := make(map[string]string)
+	for _, namespace := range sorted {
+		name := namespaceImportName(namespace)
+		if other, ok := importNames[name]; ok {
+			return fmt.Errorf("The packages for %v and %v would both be imported as %v", namespaceName(other), namespaceName(namespace), name)
+		}
+		importNames[name] = namespace
+	}
+	if err := checkImportCycles(sorted, imports); err != nil {
+		return err
+	}
+
+	for _, from := range sorted {
+		pkg := namespacePackage(p, from)
+		for to := range imports[from] {
+			toPath := n.NamespacePackages
+			if to != "" {
+				toPath = path.Join(n.NamespacePackages, namespaceDir(to))
+			}
+			pkg.AddPackageImport(namespaceImportName(to), toPath)
+		}
+	}
+
+	for _, name := range names {
+		def := n.Definitions[name]
+		pkg := namespacePackage(p, name.Namespace)
+		def.AddStruct(pkg)
+		def.AddSerializer(pkg)
+		def.AddDeserializer(pkg)
+	}
+	for _, protocol := range n.Protocols {
+		pkg := namespacePackage(p, protocol.name.Namespace)
+		protocol.AddStruct(pkg)
+		protocol.AddSerializer(pkg)
+		protocol.AddDeserializer(pkg)
+	}
+	for _, schema := range n.Schemas {
+		if _, ok := schema.Root.(*Reference); !ok {
+			schema.Root.AddStruct(p)
+			schema.Root.AddSerializer(p)
+			schema.Root.AddDeserializer(p)
+		}
+	}
+	return nil
+}
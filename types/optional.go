@@ -0,0 +1,149 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+const optionalSerializerTemplate = `
+func %v(r %v, w io.Writer) error {
+	if r == nil {
+		return writeLong(%v, w)
+	}
+	if err := writeLong(%v, w); err != nil {
+		return err
+	}
+	return %v(%v, w)
+}
+`
+
+const optionalDeserializerTemplate = `
+func %v(r io.Reader) (%v, error) {
+	field, err := readLong(r)
+	if err != nil {
+		return nil, err
+	}
+	switch field {
+	case %v:
+		return nil, nil
+	case %v:
+		val, err := %v(r)
+		if err != nil {
+			return nil, err
+		}
+		return %v, nil
+	}
+	return nil, fmt.Errorf("Invalid value for %v")
+}
+`
+
+const writeJSONOptionalTemplate = `
+func %v(r %v, w *bytes.Buffer) error {
+	if r == nil {
+		w.WriteString("null")
+		return nil
+	}
+	w.WriteString(%v)
+	if err := %v(%v, w); err != nil {
+		return err
+	}
+	w.WriteString("}")
+	return nil
+}
+`
+
+const readJSONOptionalTemplate = `
+func %v(v interface{}) (%v, error) {
+	if v == nil {
+		return nil, nil
+	}
+	object, ok := v.(map[string]interface{})
+	if !ok || len(object) != 1 {
+		return nil, fmt.Errorf("Expected a JSON object with one key for %v, got %%v", v)
+	}
+	value, ok := object[%q]
+	if !ok {
+		return nil, fmt.Errorf("Invalid branch for %v: %%v", v)
+	}
+	val, err := %v(value)
+	if err != nil {
+		return nil, err
+	}
+	return %v, nil
+}
+`
+
+/*
+  Whether a union can be represented as a pointer: it has exactly two branches, and one of them is null.
+*/
+func isOptionalUnion(items []Field) bool {
+	if len(items) != 2 {
+		return false
+	}
+	_, firstNull := items[0].(*nullField)
+	_, secondNull := items[1].(*nullField)
+	return firstNull != secondNull
+}
+
+/*
+  The index of the null branch and the non-null branch of an optional union.
+*/
+func (s *unionField) optionalBranches() (int, int) {
+	if _, isNull := s.itemType[0].(*nullField); isNull {
+		return 0, 1
+	}
+	return 1, 0
+}
+
+func (s *unionField) optionalItem() Field {
+	_, valueIndex := s.optionalBranches()
+	return s.itemType[valueIndex]
+}
+
+/*
+  Records are already pointers, so nil is enough to represent null. Other types need a pointer added.
+*/
+func (s *unionField) optionalIsPointer() bool {
+	return strings.HasPrefix(s.optionalItem().GoType(), "*")
+}
+
+func (s *unionField) optionalGoType() string {
+	if s.optionalIsPointer() {
+		return s.optionalItem().GoType()
+	}
+	return "*" + s.optionalItem().GoType()
+}
+
+/*
+  The expression for the value held by the optional union r, once it is known to be non-nil.
+*/
+func (s *unionField) optionalValue(expr string) string {
+	if s.optionalIsPointer() {
+		return expr
+	}
+	return "*" + expr
+}
+
+/*
+  The expression for an optional union holding the value in the variable val.
+*/
+func (s *unionField) optionalNew(val string) string {
+	if s.optionalIsPointer() {
+		return val
+	}
+	return "&" + val
+}
+
+func (s *unionField) optionalSerializer() string {
+	nullIndex, valueIndex := s.optionalBranches()
+	return fmt.Sprintf(optionalSerializerTemplate, s.SerializerMethod(), s.GoType(), nullIndex, valueIndex, s.optionalItem().SerializerMethod(), s.optionalValue("r"))
+}
+
+func (s *unionField) optionalDeserializer() string {
+	return s.optionalDeserializerWith(s.DeserializerMethod(), Field.DeserializerMethod)
+}
+
+func (s *unionField) optionalDeserializerWith(name string, reader func(Field) string) string {
+	nullIndex, valueIndex := s.optionalBranches()
+	return fmt.Sprintf(optionalDeserializerTemplate, name, s.GoType(), nullIndex, valueIndex, reader(s.optionalItem()), s.optionalNew("val"), s.FieldType())
+}
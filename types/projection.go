@@ -0,0 +1,421 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/alanctgardner/gogen-avro/generator"
+)
+
+const skipNullMethod = `
+func skipNull(r io.Reader) error {
+	return nil
+}
+`
+
+const skipIntMethod = `
+func skipInt(r io.Reader) error {
+	_, err := readInt(r)
+	return err
+}
+`
+
+const skipLongMethod = `
+func skipLong(r io.Reader) error {
+	_, err := readLong(r)
+	return err
+}
+`
+
+const skipFixedSizeTemplate = `
+func %v(r io.Reader) error {
+	return decoderFor(r).skip(%v)
+}
+`
+
+const skipLengthTemplate = `
+func %v(r io.Reader) error {
+	size, err := readLong(r)
+	if err != nil {
+		return err
+	}
+	if err := checkLength(%q, size, %v); err != nil {
+		return err
+	}
+	return decoderFor(r).skip(size)
+}
+`
+
+const skipEnumTemplate = `
+func %v(r io.Reader) error {
+	_, err := readInt(r)
+	return err
+}
+`
+
+const skipRecordTemplate = `
+func %v(r io.Reader) error {
+	if err := enterRecord(r); err != nil {
+		return err
+	}
+	defer leaveRecord(r)
+%v	return nil
+}
+`
+
+const skipBlocksTemplate = `
+func %v(r io.Reader) error {
+	d := decoderFor(r)
+	var total int64
+	for {
+		blkSize, err := readLong(d)
+		if err != nil {
+			return err
+		}
+		if blkSize == 0 {
+			return nil
+		}
+		if blkSize < 0 {
+			// The writer gave the size of the block in bytes, so its items don't have to be read one by one
+			byteSize, err := readLong(d)
+			if err != nil {
+				return err
+			}
+			if err := checkLength("block", byteSize, 0); err != nil {
+				return err
+			}
+			if err := checkLength(%q, total-blkSize, %v); err != nil {
+				return err
+			}
+			total -= blkSize
+			if err := d.skip(byteSize); err != nil {
+				return err
+			}
+			continue
+		}
+		total += blkSize
+		if err := checkLength(%q, total, %v); err != nil {
+			return err
+		}
+		for i := total - blkSize; i < total; i++ {
+%v
+		}
+	}
+}
+`
+
+const skipArrayItemTemplate = `			if err := %v(d); err != nil {
+				return wrapDecodeError(err, fmt.Sprintf("[%%d]", i), %q)
+			}`
+
+const skipMapItemTemplate = `			if err := skipString(d); err != nil {
+				return err
+			}
+			if err := %v(d); err != nil {
+				return err
+			}`
+
+const skipUnionTemplate = `
+func %v(r io.Reader) error {
+	index, err := readLong(r)
+	if err != nil {
+		return err
+	}
+	switch index {
+%v	}
+	return fmt.Errorf("Invalid value for %v")
+}
+`
+
+const recordDeserializeFromWriterTemplate = `
+// %v decodes a %v from data written with the writer schema the package was generated with.
+// Fields which are only in the writer schema are skipped without being decoded.
+func %v(r io.Reader) (%v, error) {
+	d := decoderFor(r)
+	start := d.Offset()
+	t, err := %v(d)
+	if err != nil {
+		return nil, decodeErrorAt(err, %q, %q, start, d.Offset())
+	}
+	return t, nil
+}
+`
+
+func skipMethod(f Field) string {
+	return "skip" + f.FieldType()
+}
+
+func definitionSkipMethod(d Definition) string {
+	return "skip" + d.FieldType()
+}
+
+func projectMethod(f Field) string {
+	return "project" + f.FieldType()
+}
+
+func definitionProjectMethod(d Definition) string {
+	return "project" + d.FieldType()
+}
+
+/*
+  Add the function which consumes a value of the writer's field type without decoding it, and the functions for
+  every type beneath it.
+*/
+func addSkipField(p *generator.Package, f Field) {
+	if p.HasFunction(UTIL_FILE, "", skipMethod(f)) {
+		return
+	}
+	switch t := f.(type) {
+	case *nullField:
+		p.AddFunction(UTIL_FILE, "", skipMethod(t), skipNullMethod)
+	case *boolField:
+		p.AddFunction(UTIL_FILE, "", skipMethod(t), fmt.Sprintf(skipFixedSizeTemplate, skipMethod(t), 1))
+	case *intField:
+		p.AddFunction(UTIL_FILE, "", "readInt", readIntMethod)
+		p.AddImport(UTIL_FILE, "fmt")
+		p.AddFunction(UTIL_FILE, "", skipMethod(t), skipIntMethod)
+	case *longField:
+		p.AddFunction(UTIL_FILE, "", skipMethod(t), skipLongMethod)
+	case *floatField:
+		p.AddFunction(UTIL_FILE, "", skipMethod(t), fmt.Sprintf(skipFixedSizeTemplate, skipMethod(t), 4))
+	case *doubleField:
+		p.AddFunction(UTIL_FILE, "", skipMethod(t), fmt.Sprintf(skipFixedSizeTemplate, skipMethod(t), 8))
+	case *stringField:
+		p.AddFunction(UTIL_FILE, "", skipMethod(t), fmt.Sprintf(skipLengthTemplate, skipMethod(t), "string", "MaxStringLength"))
+	case *bytesField:
+		p.AddFunction(UTIL_FILE, "", skipMethod(t), fmt.Sprintf(skipLengthTemplate, skipMethod(t), "bytes", "MaxBytesLength"))
+	case *arrayField:
+		item := fmt.Sprintf(skipArrayItemTemplate, skipMethod(t.itemType), decodeTypeName(t.itemType))
+		p.AddFunction(UTIL_FILE, "", skipMethod(t), fmt.Sprintf(skipBlocksTemplate, skipMethod(t), "array", "MaxArrayLength", "array", "MaxArrayLength", item))
+		addSkipField(p, t.itemType)
+	case *mapField:
+		item := fmt.Sprintf(skipMapItemTemplate, skipMethod(t.itemType))
+		p.AddFunction(UTIL_FILE, "", skipMethod(t), fmt.Sprintf(skipBlocksTemplate, skipMethod(t), "map", "MaxMapLength", "map", "MaxMapLength", item))
+		addSkipField(p, &stringField{})
+		addSkipField(p, t.itemType)
+	case *unionField:
+		cases := ""
+		for i, item := range t.itemType {
+			cases += fmt.Sprintf("\tcase %v:\n\t\treturn %v(r)\n", i, skipMethod(item))
+		}
+		p.AddFunction(UTIL_FILE, "", skipMethod(t), fmt.Sprintf(skipUnionTemplate, skipMethod(t), cases, t.FieldType()))
+		for _, item := range t.itemType {
+			addSkipField(p, item)
+		}
+	case *Reference:
+		addSkipDefinition(p, t.def)
+	}
+}
+
+func addSkipDefinition(p *generator.Package, d Definition) {
+	if p.HasFunction(UTIL_FILE, "", definitionSkipMethod(d)) {
+		return
+	}
+	switch t := d.(type) {
+	case *FixedDefinition:
+		p.AddFunction(UTIL_FILE, "", definitionSkipMethod(t), fmt.Sprintf(skipFixedSizeTemplate, definitionSkipMethod(t), t.sizeBytes))
+	case *EnumDefinition:
+		p.AddFunction(UTIL_FILE, "", "readInt", readIntMethod)
+		p.AddImport(UTIL_FILE, "fmt")
+		p.AddFunction(UTIL_FILE, "", definitionSkipMethod(t), fmt.Sprintf(skipEnumTemplate, definitionSkipMethod(t)))
+	case *RecordDefinition:
+		fieldSkips := ""
+		for _, f := range t.fields {
+			fieldSkips += fmt.Sprintf("\tif err := %v(r); err != nil {\n\t\treturn wrapDecodeError(err, %q, %q)\n\t}\n", skipMethod(f), f.AvroName(), decodeTypeName(f))
+		}
+		p.AddFunction(UTIL_FILE, "", definitionSkipMethod(t), fmt.Sprintf(skipRecordTemplate, definitionSkipMethod(t), fieldSkips))
+		for _, f := range t.fields {
+			addSkipField(p, f)
+		}
+	}
+}
+
+/*
+  Whether the field's type contains a record, which has to be projected onto the writer's record.
+*/
+func containsRecord(f Field) bool {
+	switch t := f.(type) {
+	case *arrayField:
+		return containsRecord(t.itemType)
+	case *mapField:
+		return containsRecord(t.itemType)
+	case *unionField:
+		for _, item := range t.itemType {
+			if containsRecord(item) {
+				return true
+			}
+		}
+	case *Reference:
+		_, isRecord := t.def.(*RecordDefinition)
+		return isRecord
+	}
+	return false
+}
+
+/*
+  Add the function reading a value of the reader's field type from data written with the writer's field type,
+  and return its name. Types without records are read with their deserializer, so they have to match the writer exactly.
+*/
+func addProjectedReader(p *generator.Package, reader, writer Field, path string) (string, error) {
+	if !containsRecord(reader) {
+		readerForm, err := CanonicalForm(reader)
+		if err != nil {
+			return "", err
+		}
+		writerForm, err := CanonicalForm(writer)
+		if err != nil {
+			return "", err
+		}
+		if readerForm != writerForm {
+			return "", fmt.Errorf("%v: reader type %v doesn't match writer type %v", path, readerForm, writerForm)
+		}
+		return reader.DeserializerMethod(), nil
+	}
+
+	mismatch := fmt.Errorf("%v: reader type %v doesn't match the writer type", path, reader.FieldType())
+	if p.HasFunction(UTIL_FILE, "", projectMethod(reader)) {
+		return projectMethod(reader), nil
+	}
+	switch t := reader.(type) {
+	case *arrayField:
+		w, ok := writer.(*arrayField)
+		if !ok {
+			return "", mismatch
+		}
+		item, err := addProjectedReader(p, t.itemType, w.itemType, path+"[]")
+		if err != nil {
+			return "", err
+		}
+		p.AddFunction(UTIL_FILE, "", projectMethod(t), fmt.Sprintf(arrayDeserializerTemplate, projectMethod(t), t.GoType(), t.GoType(), item, decodeTypeName(t.itemType)))
+	case *mapField:
+		w, ok := writer.(*mapField)
+		if !ok {
+			return "", mismatch
+		}
+		item, err := addProjectedReader(p, t.itemType, w.itemType, path+"[]")
+		if err != nil {
+			return "", err
+		}
+		p.AddFunction(UTIL_FILE, "", projectMethod(t), fmt.Sprintf(mapDeserializerTemplate, projectMethod(t), t.GoType(), t.GoType(), item, decodeTypeName(t.itemType)))
+	case *unionField:
+		w, ok := writer.(*unionField)
+		if !ok || len(w.itemType) != len(t.itemType) {
+			return "", mismatch
+		}
+		readers := make(map[Field]string)
+		for i, item := range t.itemType {
+			method, err := addProjectedReader(p, item, w.itemType[i], path)
+			if err != nil {
+				return "", err
+			}
+			readers[item] = method
+		}
+		branchReader := func(f Field) string {
+			return readers[f]
+		}
+		var def string
+		switch {
+		case t.optional:
+			def = t.optionalDeserializerWith(projectMethod(t), branchReader)
+		case t.mode == UnionModeInterface:
+			def = t.unionInterfaceDeserializerWith(projectMethod(t), branchReader)
+		default:
+			def = t.unionDeserializerWith(projectMethod(t), branchReader)
+		}
+		p.AddFunction(UTIL_FILE, "", projectMethod(t), def)
+	case *Reference:
+		w, ok := writer.(*Reference)
+		if !ok {
+			return "", mismatch
+		}
+		writerDef, ok := w.def.(*RecordDefinition)
+		if !ok || writerDef.name.Name != t.def.AvroName().Name {
+			return "", mismatch
+		}
+		return addProjectedRecord(p, t.def.(*RecordDefinition), writerDef, path)
+	}
+	return projectMethod(reader), nil
+}
+
+/*
+  Add the function reading the reader's record from the writer's record, which reads the fields in the writer's order
+  and skips the fields the reader doesn't have.
+*/
+func addProjectedRecord(p *generator.Package, reader, writer *RecordDefinition, path string) (string, error) {
+	name := definitionProjectMethod(reader)
+	if p.HasFunction(UTIL_FILE, "", name) {
+		return name, nil
+	}
+	// Register the name before walking the fields, so a recursive record finds it instead of projecting itself again
+	p.AddFunction(UTIL_FILE, "", name, "")
+
+	readerFields := make(map[string]Field)
+	for _, f := range reader.fields {
+		readerFields[f.AvroName()] = f
+	}
+	fieldReaders := ""
+	if len(writer.fields) > 0 {
+		fieldReaders = "var err error\n"
+	}
+	for _, wf := range writer.fields {
+		rf, ok := readerFields[wf.AvroName()]
+		if !ok {
+			addSkipField(p, wf)
+			fieldReaders += fmt.Sprintf("err = %v(r)\nif err != nil {return nil, wrapDecodeError(err, %q, %q)}\n", skipMethod(wf), wf.AvroName(), decodeTypeName(wf))
+			continue
+		}
+		delete(readerFields, wf.AvroName())
+		method, err := addProjectedReader(p, rf, wf, path+"."+wf.AvroName())
+		if err != nil {
+			return "", err
+		}
+		fieldReaders += fmt.Sprintf("str.%v, err = %v(r)\nif err != nil {return nil, wrapDecodeError(err, %q, %q)}\n", rf.GoName(), method, rf.AvroName(), decodeTypeName(rf))
+	}
+	for _, f := range reader.fields {
+		if _, missing := readerFields[f.AvroName()]; missing {
+			return "", fmt.Errorf("%v: field %v isn't in the writer schema", path, f.AvroName())
+		}
+	}
+	p.AddFunction(UTIL_FILE, "", name, fmt.Sprintf(recordStructDeserializerTemplate, name, reader.GoType(), reader.FieldType(), fieldReaders))
+	return name, nil
+}
+
+/*
+  Add Deserialize<Record>FromWriter for every record schema in the namespace, which reads data written with the
+  record of the same name in the writer namespace. The reader's records have to be a subset of the writer's.
+*/
+func (n *Namespace) AddProjections(writer *Namespace, p *generator.Package) error {
+	for _, schema := range writer.Schemas {
+		if err := schema.Root.ResolveReferences(writer); err != nil {
+			return err
+		}
+	}
+
+	for _, schema := range n.Schemas {
+		ref, ok := schema.Root.(*Reference)
+		if !ok {
+			continue
+		}
+		reader, ok := ref.def.(*RecordDefinition)
+		if !ok {
+			continue
+		}
+		writerRecord, ok := writer.Definitions[reader.name].(*RecordDefinition)
+		if !ok {
+			return fmt.Errorf("Record %v isn't in the writer schema", reader.name)
+		}
+
+		addDecoder(p)
+		addLengthLimits(p)
+		addDecodeErrors(p)
+		p.AddFunction(UTIL_FILE, "", "readLong", readLongMethod)
+		p.AddImport(UTIL_FILE, "fmt")
+		method, err := addProjectedRecord(p, reader, writerRecord, reader.name.Name)
+		if err != nil {
+			return err
+		}
+		public := fmt.Sprintf("Deserialize%vFromWriter", reader.FieldType())
+		def := fmt.Sprintf(recordDeserializeFromWriterTemplate, public, reader.FieldType(), public, reader.GoType(), method, reader.name.Name, reader.name.String())
+		p.AddFunction(reader.filename(), "", public, def)
+	}
+	return nil
+}
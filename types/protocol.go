@@ -0,0 +1,567 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alanctgardner/gogen-avro/generator"
+)
+
+const protocolInterfaceTemplate = `
+// %v is the interface for the Avro protocol %v%v
+type %v interface {
+%v
+}
+`
+
+const protocolServerTemplate = `
+// %v returns an rpc.Server which dispatches the messages of %v to impl
+func %v(impl %v) (*rpc.Server, error) {
+	server, err := rpc.NewServer(%v())
+	if err != nil {
+		return nil, err
+	}
+%v
+	return server, nil
+}
+`
+
+const protocolServerHandlerTemplate = `
+	server.Handle(%q, func(r io.Reader, w io.Writer) (bool, error) {
+		request, err := %v(r)
+		if err != nil {
+			return false, err
+		}
+%v
+	})
+`
+
+const protocolClientTemplate = `
+// %v calls the messages of %v on a remote server
+type %v struct {
+	client *rpc.Client
+}
+`
+
+const protocolClientConstructorTemplate = `
+// %v returns a %v which sends calls with the given rpc.Transceiver
+func %v(transceiver rpc.Transceiver) (*%v, error) {
+	client, err := rpc.NewClient(%v(), transceiver)
+	if err != nil {
+		return nil, err
+	}
+	return &%v{client: client}, nil
+}
+`
+
+const protocolClientMethodTemplate = `
+func (c *%v) %v {
+%v
+}
+`
+
+const protocolErrorReaderTemplate = `
+func %v(r io.Reader) error {
+	errors, err := %v(r)
+	if err != nil {
+		return err
+	}
+	%v
+%v
+	}
+	return fmt.Errorf("Invalid error union for %v")
+}
+`
+
+const protocolJSONTemplate = `
+// %v returns the JSON definition of the Avro protocol %v
+func %v() string {
+	return %v
+}
+`
+
+/*
+  A single message in an Avro protocol. The request parameters are modelled as a record
+  with one field per parameter, which has the same binary encoding as the parameter list.
+*/
+type Message struct {
+	name     string
+	doc      string
+	request  *RecordDefinition
+	response Field
+	errors   *unionField
+	oneWay   bool
+}
+
+func (m *Message) Name() string {
+	return m.name
+}
+
+/*
+  The name of the method in the generated protocol interface
+*/
+func (m *Message) GoName() string {
+	return generator.ToPublicName(m.name)
+}
+
+func (m *Message) Request() *RecordDefinition {
+	return m.request
+}
+
+func (m *Message) Response() Field {
+	return m.response
+}
+
+/*
+  The union of "string" and the declared error types, or nil for one-way messages.
+*/
+func (m *Message) Errors() Field {
+	if m.errors == nil {
+		return nil
+	}
+	return m.errors
+}
+
+func (m *Message) OneWay() bool {
+	return m.oneWay
+}
+
+/*
+  Whether the message returns a value, or only an error.
+*/
+func (m *Message) hasResponse() bool {
+	_, isNull := m.response.(*nullField)
+	return !m.oneWay && !isNull
+}
+
+func (m *Message) methodSignature() string {
+	request := fmt.Sprintf("request %v", m.request.GoType())
+	if m.hasResponse() {
+		return fmt.Sprintf("%v(%v) (%v, error)", m.GoName(), request, m.response.GoType())
+	}
+	return fmt.Sprintf("%v(%v) error", m.GoName(), request)
+}
+
+/*
+  The declared error types of the message, excluding the implicit string error.
+*/
+func (m *Message) declaredErrors() []Field {
+	if m.errors == nil {
+		return nil
+	}
+	return m.errors.itemType[1:]
+}
+
+/*
+  The name of the generated function which decodes the error union into a Go error.
+*/
+func (m *Message) errorReaderMethod() string {
+	return m.errors.DeserializerMethod() + "Error"
+}
+
+func (m *Message) errorReaderDef() string {
+	// The first branch is the string error, any others are the declared errors
+	cases := fmt.Sprintf("%v\nreturn &rpc.Error{Message: %v}\n", m.errors.branchCase(m.errors.itemType[0]), m.errors.branchValue("errors", m.errors.itemType[0]))
+	for _, e := range m.declaredErrors() {
+		cases += fmt.Sprintf("%v\nreturn %v\n", m.errors.branchCase(e), m.errors.branchValue("errors", e))
+	}
+	return fmt.Sprintf(protocolErrorReaderTemplate, m.errorReaderMethod(), m.errors.DeserializerMethod(), m.errors.branchSwitch("errors"), cases, m.errors.GoType())
+}
+
+/*
+  The body of the server handler, after the request has been decoded.
+*/
+func (m *Message) serverHandlerBody() string {
+	declared := m.declaredErrors()
+	if !m.hasResponse() && len(declared) == 0 {
+		return fmt.Sprintf("return false, impl.%v(request)", m.GoName())
+	}
+
+	body := ""
+	if m.hasResponse() {
+		body += fmt.Sprintf("response, err := impl.%v(request)\n", m.GoName())
+	} else {
+		body += fmt.Sprintf("err = impl.%v(request)\n", m.GoName())
+	}
+	if len(declared) > 0 {
+		body += "switch e := err.(type) {\n"
+		for _, e := range declared {
+			body += fmt.Sprintf("case %v:\nreturn true, %v(%v, w)\n", e.GoType(), m.errors.SerializerMethod(), m.errors.newBranch(e, "e"))
+		}
+		body += "}\n"
+	}
+	body += "if err != nil {\nreturn false, err\n}\n"
+	if m.hasResponse() {
+		body += fmt.Sprintf("return false, %v(response, w)", m.response.SerializerMethod())
+	} else {
+		body += "return false, nil"
+	}
+	return body
+}
+
+func (m *Message) serverHandler() string {
+	return fmt.Sprintf(protocolServerHandlerTemplate, m.name, m.request.DeserializerMethod(), m.serverHandlerBody())
+}
+
+/*
+  The body of the client method, which calls the message through the rpc.Client.
+*/
+func (m *Message) clientMethodBody() string {
+	if m.oneWay {
+		return fmt.Sprintf("return c.client.Call(%q, request, nil, nil)", m.name)
+	}
+	if !m.hasResponse() {
+		return fmt.Sprintf("return c.client.Call(%q, request, func(r io.Reader) error {\nreturn nil\n}, %v)", m.name, m.errorReaderMethod())
+	}
+	return fmt.Sprintf(`var response %v
+	err := c.client.Call(%q, request, func(r io.Reader) error {
+		var err error
+		response, err = %v(r)
+		return err
+	}, %v)
+	return response, err`, m.response.GoType(), m.name, m.response.DeserializerMethod(), m.errorReaderMethod())
+}
+
+/*
+  An Avro protocol, parsed from a .avpr file.
+*/
+type ProtocolDefinition struct {
+	name     QualifiedName
+	doc      string
+	messages []*Message
+	json     []byte
+}
+
+func (p *ProtocolDefinition) AvroName() QualifiedName {
+	return p.name
+}
+
+/*
+  The JSON definition of the protocol as it was supplied, with insignificant whitespace removed.
+*/
+func (p *ProtocolDefinition) JSON() []byte {
+	return p.json
+}
+
+/*
+  The messages of the protocol, sorted by name.
+*/
+func (p *ProtocolDefinition) Messages() []*Message {
+	return p.messages
+}
+
+func (p *ProtocolDefinition) GoType() string {
+	return generator.ToPublicName(p.name.Name)
+}
+
+func (p *ProtocolDefinition) filename() string {
+	return generator.ToSnake(p.GoType()) + ".go"
+}
+
+func (p *ProtocolDefinition) protocolJSONMethod() string {
+	return p.GoType() + "Protocol"
+}
+
+func (p *ProtocolDefinition) serverConstructor() string {
+	return "New" + p.GoType() + "Server"
+}
+
+func (p *ProtocolDefinition) clientType() string {
+	return p.GoType() + "Client"
+}
+
+func (p *ProtocolDefinition) serverDef() string {
+	handlers := ""
+	for _, m := range p.messages {
+		handlers += m.serverHandler()
+	}
+	return fmt.Sprintf(protocolServerTemplate, p.serverConstructor(), p.GoType(), p.serverConstructor(), p.GoType(), p.protocolJSONMethod(), handlers)
+}
+
+func (p *ProtocolDefinition) clientConstructorDef() string {
+	client := p.clientType()
+	return fmt.Sprintf(protocolClientConstructorTemplate, "New"+client, client, "New"+client, client, p.protocolJSONMethod(), client)
+}
+
+/*
+  Generate a server constructor and a client type which bind the protocol interface to the rpc package.
+*/
+func (p *ProtocolDefinition) AddRPC(pkg *generator.Package) {
+	file := p.filename()
+	pkg.AddImport(file, "io")
+	pkg.AddImport(file, "github.com/alanctgardner/gogen-avro/rpc")
+	pkg.AddFunction(file, "", p.serverConstructor(), p.serverDef())
+
+	client := p.clientType()
+	pkg.AddStruct(file, client, fmt.Sprintf(protocolClientTemplate, client, p.GoType(), client))
+	pkg.AddFunction(file, "", "New"+client, p.clientConstructorDef())
+	for _, m := range p.messages {
+		pkg.AddFunction(file, "*"+client, m.GoName(), fmt.Sprintf(protocolClientMethodTemplate, client, m.methodSignature(), m.clientMethodBody()))
+		if m.errors != nil && !pkg.HasFunction(file, "", m.errorReaderMethod()) {
+			pkg.AddImport(file, "fmt")
+			pkg.AddFunction(file, "", m.errorReaderMethod(), m.errorReaderDef())
+		}
+	}
+}
+
+func (p *ProtocolDefinition) interfaceDef() string {
+	methods := ""
+	for _, m := range p.messages {
+		methods += docComment(m.doc)
+		methods += m.methodSignature() + "\n"
+	}
+	doc := ""
+	if p.doc != "" {
+		doc = "\n" + strings.TrimSuffix(docComment(p.doc), "\n")
+	}
+	return fmt.Sprintf(protocolInterfaceTemplate, p.GoType(), p.name, doc, p.GoType(), methods)
+}
+
+/*
+  Format a doc string as a Go comment, with one line of comment per line of the doc.
+*/
+func docComment(doc string) string {
+	if doc == "" {
+		return ""
+	}
+	comment := ""
+	for _, line := range strings.Split(doc, "\n") {
+		comment += strings.TrimSpace("// "+line) + "\n"
+	}
+	return comment
+}
+
+func (p *ProtocolDefinition) ResolveReferences(n *Namespace) error {
+	for _, m := range p.messages {
+		err := m.request.ResolveReferences(n)
+		if err != nil {
+			return err
+		}
+		err = m.response.ResolveReferences(n)
+		if err != nil {
+			return err
+		}
+		if m.errors != nil {
+			err = m.errors.ResolveReferences(n)
+			if err != nil {
+				return err
+			}
+		}
+		for _, e := range m.declaredErrors() {
+			if !isErrorType(e) {
+				return NewSchemaError(m.name, fmt.Errorf("Declared error %v is not an error type", e.FieldType()))
+			}
+		}
+	}
+	return nil
+}
+
+func isErrorType(f Field) bool {
+	ref, ok := f.(*Reference)
+	if !ok {
+		return false
+	}
+	record, ok := ref.Definition().(*RecordDefinition)
+	return ok && record.isError
+}
+
+func (p *ProtocolDefinition) AddStruct(pkg *generator.Package) {
+	pkg.AddStruct(p.filename(), p.GoType(), p.interfaceDef())
+	pkg.AddFunction(p.filename(), "", p.protocolJSONMethod(), fmt.Sprintf(protocolJSONTemplate, p.protocolJSONMethod(), p.name, p.protocolJSONMethod(), strconv.Quote(string(p.json))))
+	for _, m := range p.messages {
+		m.request.AddStruct(pkg)
+		m.response.AddStruct(pkg)
+		if m.errors != nil {
+			m.errors.AddStruct(pkg)
+		}
+	}
+	p.AddRPC(pkg)
+}
+
+func (p *ProtocolDefinition) AddSerializer(pkg *generator.Package) {
+	for _, m := range p.messages {
+		m.request.AddSerializer(pkg)
+		m.response.AddSerializer(pkg)
+		if m.errors != nil {
+			m.errors.AddSerializer(pkg)
+		}
+	}
+}
+
+func (p *ProtocolDefinition) AddDeserializer(pkg *generator.Package) {
+	for _, m := range p.messages {
+		m.request.AddDeserializer(pkg)
+		m.response.AddDeserializer(pkg)
+		if m.errors != nil {
+			m.errors.AddDeserializer(pkg)
+		}
+	}
+}
+
+/*
+  Given an Avro protocol as a JSON string, decode it and return the ProtocolDefinition.
+
+  The named types declared by the protocol and its messages will also be added to this Namespace.
+*/
+func (n *Namespace) ProtocolDefinitionForSchema(protocolJson []byte) (*ProtocolDefinition, error) {
+	var protocolMap map[string]interface{}
+	if err := json.Unmarshal(protocolJson, &protocolMap); err != nil {
+		return nil, err
+	}
+
+	name, err := getMapString(protocolMap, "protocol")
+	if err != nil {
+		return nil, err
+	}
+
+	var namespace string
+	if _, ok := protocolMap["namespace"]; ok {
+		namespace, err = getMapString(protocolMap, "namespace")
+		if err != nil {
+			return nil, err
+		}
+	}
+	qualifiedName := ParseAvroName(namespace, name)
+	namespace = qualifiedName.Namespace
+
+	doc, _ := protocolMap["doc"].(string)
+
+	if typeList, ok := protocolMap["types"]; ok {
+		types, ok := typeList.([]interface{})
+		if !ok {
+			return nil, NewWrongMapValueTypeError("types", "array", typeList)
+		}
+		for _, t := range types {
+			if _, err := n.decodeFieldDefinitionType(namespace, "", t, nil, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var messages []*Message
+	if messageList, ok := protocolMap["messages"]; ok {
+		messageMap, ok := messageList.(map[string]interface{})
+		if !ok {
+			return nil, NewWrongMapValueTypeError("messages", "map", messageList)
+		}
+		for messageName, m := range messageMap {
+			definition, ok := m.(map[string]interface{})
+			if !ok {
+				return nil, NewSchemaError(messageName, NewWrongMapValueTypeError(messageName, "map", m))
+			}
+			message, err := n.decodeMessage(qualifiedName, messageName, definition)
+			if err != nil {
+				return nil, NewSchemaError(messageName, err)
+			}
+			messages = append(messages, message)
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].name < messages[j].name })
+
+	var compactJson bytes.Buffer
+	if err := json.Compact(&compactJson, protocolJson); err != nil {
+		return nil, err
+	}
+
+	protocol := &ProtocolDefinition{
+		name:     qualifiedName,
+		doc:      doc,
+		messages: messages,
+		json:     compactJson.Bytes(),
+	}
+	n.Protocols = append(n.Protocols, protocol)
+	return protocol, nil
+}
+
+func (n *Namespace) decodeMessage(protocol QualifiedName, name string, definition map[string]interface{}) (*Message, error) {
+	namespace := protocol.Namespace
+
+	params, err := getMapArray(definition, "request")
+	if err != nil {
+		return nil, err
+	}
+	requestFields := make([]Field, 0, len(params))
+	for _, p := range params {
+		param, ok := p.(map[string]interface{})
+		if !ok {
+			return nil, NewWrongMapValueTypeError("request", "map[]", p)
+		}
+		paramName, err := getMapString(param, "name")
+		if err != nil {
+			return nil, err
+		}
+		t, ok := param["type"]
+		if !ok {
+			return nil, NewSchemaError(paramName, NewRequiredMapKeyError("type"))
+		}
+		def, hasDef := param["default"]
+		field, err := n.decodeFieldDefinitionType(namespace, paramName, t, def, hasDef)
+		if err != nil {
+			return nil, NewSchemaError(paramName, err)
+		}
+		requestFields = append(requestFields, field)
+	}
+
+	requestName := generator.ToPublicName(protocol.Name) + generator.ToPublicName(name) + "Request"
+	request := &RecordDefinition{
+		name:     QualifiedName{namespace, requestName},
+		aliases:  make([]QualifiedName, 0),
+		fields:   requestFields,
+		metadata: make(map[string]interface{}),
+
+		validateOnSerialize: n.ValidateOnSerialize,
+	}
+	// The request record is generated like any other, so its name mustn't be taken by a type in the schemas
+	if err := n.RegisterDefinition(request); err != nil {
+		return nil, fmt.Errorf("The request record %v for the message conflicts with another definition", request.name)
+	}
+
+	responseType, ok := definition["response"]
+	if !ok {
+		return nil, NewRequiredMapKeyError("response")
+	}
+	response, err := n.decodeFieldDefinitionType(namespace, "response", responseType, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	oneWay, _ := definition["one-way"].(bool)
+	if _, isNull := response.(*nullField); oneWay && !isNull {
+		return nil, fmt.Errorf("One-way message must have a null response")
+	}
+
+	// Every two-way message can return a string error, in addition to the declared errors
+	var errors *unionField
+	if !oneWay {
+		errorTypes := []interface{}{"string"}
+		if declared, ok := definition["errors"]; ok {
+			declaredList, ok := declared.([]interface{})
+			if !ok {
+				return nil, NewWrongMapValueTypeError("errors", "array", declared)
+			}
+			errorTypes = append(errorTypes, declaredList...)
+		}
+		errorField, err := n.decodeUnionDefinition(namespace, "errors", nil, false, errorTypes)
+		if err != nil {
+			return nil, err
+		}
+		errors = errorField.(*unionField)
+	} else if _, ok := definition["errors"]; ok {
+		return nil, fmt.Errorf("One-way message must not declare errors")
+	}
+
+	doc, _ := definition["doc"].(string)
+
+	return &Message{
+		name:     name,
+		doc:      doc,
+		request:  request,
+		response: response,
+		errors:   errors,
+		oneWay:   oneWay,
+	}, nil
+}
@@ -28,16 +28,58 @@ func (r %v) Serialize(w io.Writer) error {
 }
 `
 
+const recordStructValidatingSerializerTemplate = `
+func (r %v) Serialize(w io.Writer) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	return %v(r, w)
+}
+`
+
 const recordStructDeserializerTemplate = `
 func %v(r io.Reader) (%v, error) {
+	if err := enterRecord(r); err != nil {
+		return nil, err
+	}
+	defer leaveRecord(r)
 	var str = &%v{}
-	var err error
 	%v
 	return str, nil
 }
 `
 
 const recordStructPublicDeserializerTemplate = `
+func %v(r io.Reader) (%v, error) {
+	d := decoderFor(r)
+	start := d.Offset()
+	t, err := %v(d)
+	if err != nil {
+		return nil, decodeErrorAt(err, %q, %q, start, d.Offset())
+	}
+	return t, nil
+}
+`
+
+const recordStructUnmarshalTemplate = `
+%v
+func %v(data []byte) (%v, int, error) {
+	d := newSliceDecoder(data, %v)
+	t, err := %v(d)
+	if err != nil {
+		return nil, 0, decodeErrorAt(err, %q, %q, 0, d.Offset())
+	}
+	return t, int(d.Offset()), nil
+}
+`
+
+const importedRecordSerializerTemplate = `
+func %v(r %v, w io.Writer) error {
+	return r.Serialize(w)
+}
+`
+
+const importedRecordDeserializerTemplate = `
 func %v(r io.Reader) (%v, error) {
 	return %v(r)
 }
@@ -45,10 +87,17 @@ func %v(r io.Reader) (%v, error) {
 
 type RecordDefinition struct {
 	name     QualifiedName
+	isError  bool
 	version  int
 	aliases  []QualifiedName
 	fields   []Field
 	metadata map[string]interface{}
+	// The sort order of each field, by name. Fields without one are ascending.
+	fieldOrder map[string]SortOrder
+	// Whether Serialize calls Validate before writing the record
+	validateOnSerialize bool
+	// The name the package holding the Go type is imported as, if it's generated in another namespace's package
+	pkg string
 }
 
 func (r *RecordDefinition) AvroName() QualifiedName {
@@ -60,11 +109,16 @@ func (r *RecordDefinition) Aliases() []QualifiedName {
 }
 
 func (r *RecordDefinition) GoType() string {
-	return fmt.Sprintf("*%v", r.FieldType())
+	return fmt.Sprintf("*%v", r.structType())
 }
 
 func (r *RecordDefinition) FieldType() string {
-	return generator.ToPublicName(r.name.Name)
+	return r.name.goName(r.pkg != "")
+}
+
+// The name of the struct type, qualified with its package if it's imported
+func (r *RecordDefinition) structType() string {
+	return qualifiedGoName(r.pkg, r.name)
 }
 
 func (r *RecordDefinition) structFields() string {
@@ -76,6 +130,10 @@ func (r *RecordDefinition) structFields() string {
 }
 
 func (r *RecordDefinition) fieldSerializers() string {
+	// Records without fields don't need an error variable
+	if len(r.fields) == 0 {
+		return ""
+	}
 	serializerMethods := "var err error\n"
 	for _, f := range r.fields {
 		serializerMethods += fmt.Sprintf("err = %v(r.%v, w)\nif err != nil {return err}\n", f.SerializerMethod(), f.GoName())
@@ -84,9 +142,12 @@ func (r *RecordDefinition) fieldSerializers() string {
 }
 
 func (r *RecordDefinition) fieldDeserializers() string {
-	deserializerMethods := ""
+	if len(r.fields) == 0 {
+		return ""
+	}
+	deserializerMethods := "var err error\n"
 	for _, f := range r.fields {
-		deserializerMethods += fmt.Sprintf("str.%v, err = %v(r)\nif err != nil {return nil, err}\n", f.GoName(), f.DeserializerMethod())
+		deserializerMethods += fmt.Sprintf("str.%v, err = %v(r)\nif err != nil {return nil, wrapDecodeError(err, %q, %q)}\n", f.GoName(), f.DeserializerMethod(), f.AvroName(), decodeTypeName(f))
 	}
 	return deserializerMethods
 }
@@ -116,11 +177,32 @@ func (r *RecordDefinition) publicDeserializerMethod() string {
 }
 
 func (r *RecordDefinition) publicSerializerMethodDef() string {
+	if r.validateOnSerialize {
+		return fmt.Sprintf(recordStructValidatingSerializerTemplate, r.GoType(), r.SerializerMethod())
+	}
 	return fmt.Sprintf(recordStructPublicSerializerTemplate, r.GoType(), r.SerializerMethod())
 }
 
 func (r *RecordDefinition) publicDeserializerMethodDef() string {
-	return fmt.Sprintf(recordStructPublicDeserializerTemplate, r.publicDeserializerMethod(), r.GoType(), r.DeserializerMethod())
+	return fmt.Sprintf(recordStructPublicDeserializerTemplate, r.publicDeserializerMethod(), r.GoType(), r.DeserializerMethod(), r.name.Name, r.name.String())
+}
+
+func (r *RecordDefinition) publicUnmarshalMethod() string {
+	return fmt.Sprintf("Unmarshal%v", r.FieldType())
+}
+
+func (r *RecordDefinition) publicUnmarshalNoCopyMethod() string {
+	return fmt.Sprintf("Unmarshal%vNoCopy", r.FieldType())
+}
+
+func (r *RecordDefinition) publicUnmarshalMethodDef() string {
+	doc := fmt.Sprintf("// %v decodes a %v from the start of data, and returns it with the number of bytes it took up.\n// The values of bytes fields are copied out of data.", r.publicUnmarshalMethod(), r.FieldType())
+	return fmt.Sprintf(recordStructUnmarshalTemplate, doc, r.publicUnmarshalMethod(), r.GoType(), false, r.DeserializerMethod(), r.name.Name, r.name.String())
+}
+
+func (r *RecordDefinition) publicUnmarshalNoCopyMethodDef() string {
+	doc := fmt.Sprintf("// %v is like %v, but the values of bytes fields are slices of data rather than copies.\n// It's unsafe to modify or reuse data while the record is in use.", r.publicUnmarshalNoCopyMethod(), r.publicUnmarshalMethod())
+	return fmt.Sprintf(recordStructUnmarshalTemplate, doc, r.publicUnmarshalNoCopyMethod(), r.GoType(), true, r.DeserializerMethod(), r.name.Name, r.name.String())
 }
 
 func (r *RecordDefinition) filename() string {
@@ -133,6 +215,10 @@ func (r *RecordDefinition) schemaMethod() string {
 }
 
 func (r *RecordDefinition) AddStruct(p *generator.Package) {
+	// The struct and its methods are in the package it's imported from
+	if r.pkg != "" {
+		return
+	}
 	// Import guard, to avoid circular dependencies
 	if !p.HasStruct(r.filename(), r.GoType()) {
 		p.AddStruct(r.filename(), r.GoType(), r.structDefinition())
@@ -144,16 +230,31 @@ func (r *RecordDefinition) AddStruct(p *generator.Package) {
 		// For Records we also want to add other utility methods.
 		r.AddGenerateID(p)
 		r.AddSchemaVersion(p)
+		r.AddSchemaFingerprint(p)
 		r.AddSendStats(p)
+		r.AddAvroJSON(p)
+		r.AddValidate(p)
+		r.AddCompare(p)
+		r.AddEquality(p)
+		if r.isError {
+			r.AddError(p)
+		}
 	}
 }
 
 func (r *RecordDefinition) AddSerializer(p *generator.Package) {
+	if r.pkg != "" {
+		p.AddImport(UTIL_FILE, "io")
+		p.AddFunction(UTIL_FILE, "", r.SerializerMethod(), fmt.Sprintf(importedRecordSerializerTemplate, r.SerializerMethod(), r.GoType()))
+		return
+	}
 	// Import guard, to avoid circular dependencies
 	if !p.HasFunction(UTIL_FILE, "", r.SerializerMethod()) {
 		p.AddImport(r.filename(), "io")
 		p.AddFunction(UTIL_FILE, "", r.SerializerMethod(), r.serializerMethodDef())
 		p.AddFunction(r.filename(), r.GoType(), "Serialize", r.publicSerializerMethodDef())
+		r.AddMarshalAppend(p)
+		r.AddStreamingSerializers(p)
 		for _, f := range r.fields {
 			f.AddSerializer(p)
 		}
@@ -161,11 +262,22 @@ func (r *RecordDefinition) AddSerializer(p *generator.Package) {
 }
 
 func (r *RecordDefinition) AddDeserializer(p *generator.Package) {
+	if r.pkg != "" {
+		p.AddImport(UTIL_FILE, "io")
+		p.AddFunction(UTIL_FILE, "", r.DeserializerMethod(), fmt.Sprintf(importedRecordDeserializerTemplate, r.DeserializerMethod(), r.GoType(), r.pkg+".Deserialize"+r.name.goName(false)))
+		return
+	}
 	// Import guard, to avoid circular dependencies
 	if !p.HasFunction(UTIL_FILE, "", r.DeserializerMethod()) {
 		p.AddImport(r.filename(), "io")
+		addDecoder(p)
+		addDecodeErrors(p)
 		p.AddFunction(UTIL_FILE, "", r.DeserializerMethod(), r.deserializerMethodDef())
 		p.AddFunction(r.filename(), "", r.publicDeserializerMethod(), r.publicDeserializerMethodDef())
+		p.AddFunction(r.filename(), "", r.publicUnmarshalMethod(), r.publicUnmarshalMethodDef())
+		p.AddFunction(r.filename(), "", r.publicUnmarshalNoCopyMethod(), r.publicUnmarshalNoCopyMethodDef())
+		r.AddDeserializeInto(p)
+		r.AddStreamingDeserializers(p)
 		for _, f := range r.fields {
 			f.AddDeserializer(p)
 		}
@@ -186,19 +298,10 @@ func (r *RecordDefinition) ResolveReferences(n *Namespace) error {
 func (r *RecordDefinition) Schema(names map[QualifiedName]interface{}) interface{} {
 	name := r.name.Name
 
-	// If name already seen
-	if v, ok := names[r.name]; ok {
-		// v is times name was seen
-		ts := v.(int)
-
-		// Add a suffix to the name to avoid name collisions
-		name += "_" + fmt.Sprintf("%d", ts)
-
-		// Update the times seen count
-		names[r.name] = ts + 1
+	// A record which has already been defined, including one which refers to itself, is referred to by name
+	if _, ok := names[r.name]; ok {
+		return r.name.String()
 	}
-
-	// mark name as seen
 	names[r.name] = 1
 
 	fields := make([]interface{}, 0, len(r.fields))
@@ -210,10 +313,17 @@ func (r *RecordDefinition) Schema(names map[QualifiedName]interface{}) interface
 		if f.HasDefault() {
 			fieldDef["default"] = f.Default()
 		}
+		if order, ok := r.fieldOrder[f.AvroName()]; ok {
+			fieldDef["order"] = order.String()
+		}
 		fields = append(fields, fieldDef)
 	}
+	typeStr := "record"
+	if r.isError {
+		typeStr = "error"
+	}
 	return mergeMaps(map[string]interface{}{
-		"type":   "record",
+		"type":   typeStr,
 		"name":   name, // Name field should be unqualified (not including namespace)
 		"fields": fields,
 	}, r.metadata)
@@ -261,6 +371,63 @@ func (r *RecordDefinition) AddSchemaVersion(p *generator.Package) {
 	p.AddFunction(r.filename(), r.GoType(), "SchemaVersion", fnDef)
 }
 
+// AddSchemaFingerprint adds methods which return the Parsing Canonical Form of
+// the schema and its CRC-64-AVRO, MD5 and SHA-256 fingerprints. The values are
+// computed at generation time, so they're constants in the generated code.
+func (r *RecordDefinition) AddSchemaFingerprint(p *generator.Package) {
+	// Import guard, to avoid circular dependencies
+	if p.HasFunction(r.filename(), r.GoType(), "SchemaFingerprint") {
+		return
+	}
+
+	canonical, err := DefinitionCanonicalForm(r)
+	if err != nil {
+		fmt.Printf("Error: failed to compute canonical form for %v: %s\n", r.name, err)
+		os.Exit(1)
+	}
+
+	p.AddFunction(r.filename(), r.GoType(), "CanonicalSchema", fmt.Sprintf(`
+		// CanonicalSchema returns the Parsing Canonical Form of the record's schema, which the fingerprints are computed from
+		func (r %v) CanonicalSchema() string {
+			return %v
+		}
+	`, r.GoType(), strconv.Quote(canonical)))
+
+	p.AddFunction(r.filename(), r.GoType(), "SchemaFingerprint", fmt.Sprintf(`
+		// SchemaFingerprint returns the CRC-64-AVRO fingerprint of the canonical schema
+		func (r %v) SchemaFingerprint() uint64 {
+			return %#016x
+		}
+	`, r.GoType(), CRC64Fingerprint([]byte(canonical))))
+
+	md5 := MD5Fingerprint([]byte(canonical))
+	p.AddFunction(r.filename(), r.GoType(), "SchemaFingerprintMD5", fmt.Sprintf(`
+		// SchemaFingerprintMD5 returns the MD5 fingerprint of the canonical schema
+		func (r %v) SchemaFingerprintMD5() [16]byte {
+			return [16]byte{%v}
+		}
+	`, r.GoType(), byteLiteralList(md5[:])))
+
+	sha := SHA256Fingerprint([]byte(canonical))
+	p.AddFunction(r.filename(), r.GoType(), "SchemaFingerprintSHA256", fmt.Sprintf(`
+		// SchemaFingerprintSHA256 returns the SHA-256 fingerprint of the canonical schema
+		func (r %v) SchemaFingerprintSHA256() [32]byte {
+			return [32]byte{%v}
+		}
+	`, r.GoType(), byteLiteralList(sha[:])))
+}
+
+// AddError adds an Error method to records declared with the "error" type,
+// so they can be returned as Go errors from protocol implementations.
+func (r *RecordDefinition) AddError(p *generator.Package) {
+	p.AddImport(r.filename(), "fmt")
+	p.AddFunction(r.filename(), r.GoType(), "Error", fmt.Sprintf(`
+		func (r %v) Error() string {
+			return fmt.Sprintf("%v: %%+v", *r)
+		}
+	`, r.GoType(), r.name))
+}
+
 func extractAvailableFields(f Field) map[string]string {
 	availableFields := map[string]string{}
 
@@ -272,7 +439,7 @@ func extractAvailableFields(f Field) map[string]string {
 
 	// union case
 	un, ok := f.(*unionField)
-	if ok {
+	if ok && un.mode == UnionModeStruct && !un.optional {
 		// The second type must be an allowed type
 		typ := un.itemType[1].GoType()
 		if _, ok := allowedFieldTypes[typ]; ok {
@@ -16,6 +16,8 @@ type Reference struct {
 	def          Definition
 	defaultValue interface{}
 	hasDefault   bool
+	// The enclosing namespace, which is the namespace of the definition the reference is in
+	namespace string
 }
 
 func (s *Reference) HasDefault() bool {
@@ -76,7 +78,13 @@ func (s *Reference) ResolveReferences(n *Namespace) error {
 		if s.def, ok = n.Definitions[s.typeName]; !ok {
 			return fmt.Errorf("Unable to resolve definition of type %v", s.typeName)
 		}
-		return s.def.ResolveReferences(n)
+		if err := s.def.ResolveReferences(n); err != nil {
+			return err
+		}
+		// A definition in another namespace's package is used through that package
+		if n.NamespacePackages != "" && s.def.AvroName().Namespace != s.namespace {
+			s.def = importedDefinition(s.def)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,100 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/alanctgardner/gogen-avro/generator"
+)
+
+/*
+  The names of methods generated for every record. A field with one of these Go names would clash
+  with the method, so the schema is rejected.
+*/
+var recordMethodNames = map[string]bool{
+	"Schema":                  true,
+	"Serialize":               true,
+	"GenerateID":              true,
+	"SchemaVersion":           true,
+	"SendStats":               true,
+	"CanonicalSchema":         true,
+	"SchemaFingerprint":       true,
+	"SchemaFingerprintMD5":    true,
+	"SchemaFingerprintSHA256": true,
+	"MarshalAvroJSON":         true,
+	"MarshalAppend":           true,
+	"EncodedSize":             true,
+	"Validate":                true,
+	"ValidateAll":             true,
+	"Equals":                  true,
+	"Clone":                   true,
+	"Diff":                    true,
+	"Compare":                 true,
+	"Reset":                   true,
+}
+
+/*
+  The names of the types, variables and functions added to every generated package, which the Go
+  names of definitions and enum symbols can't take. They're exported because they're part of the
+  generated API: callers pass a Decoder to Deserialize, set the limits and check for the error types.
+*/
+var packageNames = map[string]bool{
+	"ValidationError":  true,
+	"ValidationErrors": true,
+	"FieldDiff":        true,
+	"Decoder":          true,
+	"NewDecoder":       true,
+	"ByteReader":       true,
+	"ByteWriter":       true,
+	"StringWriter":     true,
+	"DecodeError":      true,
+	"LengthError":      true,
+	"MaxStringLength":  true,
+	"MaxBytesLength":   true,
+	"MaxArrayLength":   true,
+	"MaxMapLength":     true,
+	"MaxDepth":         true,
+	"DepthError":       true,
+	"StreamBlockBytes": true,
+	"FieldSeparator":   true,
+}
+
+/*
+  Check that the Go names generated for the definitions in the namespace don't clash with the
+  methods generated for records, or with the types added to every generated package.
+*/
+func (n *Namespace) CheckNames() error {
+	names := make([]QualifiedName, 0, len(n.Definitions))
+	for name, def := range n.Definitions {
+		// Skip the entries for aliases
+		if name == def.AvroName() {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i].String() < names[j].String() })
+
+	for _, name := range names {
+		def := n.Definitions[name]
+		if packageNames[def.FieldType()] {
+			return fmt.Errorf("%v is generated as %v, which is the name of a declaration in every generated package", name, def.FieldType())
+		}
+		if enum, ok := def.(*EnumDefinition); ok {
+			for _, symbol := range enum.symbols {
+				if packageNames[generator.ToPublicName(symbol)] {
+					return fmt.Errorf("Symbol %v of enum %v is generated as %v, which is the name of a declaration in every generated package", symbol, name, generator.ToPublicName(symbol))
+				}
+			}
+		}
+		record, ok := def.(*RecordDefinition)
+		if !ok {
+			continue
+		}
+		for _, f := range record.fields {
+			// Error records also implement the error interface
+			if recordMethodNames[f.GoName()] || (record.isError && f.GoName() == "Error") {
+				return fmt.Errorf("Field %q of record %v is generated as %v, which is the name of a method of the record", f.AvroName(), name, f.GoName())
+			}
+		}
+	}
+	return nil
+}
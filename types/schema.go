@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/alanctgardner/gogen-avro/generator"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -25,6 +26,32 @@ func (q QualifiedName) String() string {
 	return q.Namespace + "." + q.Name
 }
 
+/*
+  The name of the Go type for a definition with this name. If namespaced is set the namespace is
+  included, so the names of the functions generated for definitions with the same name in different
+  namespaces don't collide.
+*/
+func (q QualifiedName) goName(namespaced bool) string {
+	if !namespaced || q.Namespace == "" {
+		return generator.ToPublicName(q.Name)
+	}
+	name := ""
+	for _, part := range strings.Split(q.Namespace, ".") {
+		name += generator.ToPublicName(part)
+	}
+	return name + generator.ToPublicName(q.Name)
+}
+
+/*
+  The Go type for a definition with this name, qualified with the name its package is imported as unless it's empty.
+*/
+func qualifiedGoName(pkg string, q QualifiedName) string {
+	if pkg == "" {
+		return q.goName(false)
+	}
+	return pkg + "." + q.goName(false)
+}
+
 type Schema struct {
 	Root       Field
 	JSONSchema []byte
@@ -37,6 +64,17 @@ type Schema struct {
 type Namespace struct {
 	Definitions map[QualifiedName]Definition
 	Schemas     []Schema
+	Protocols   []*ProtocolDefinition
+	// The representation generated for unions decoded by this namespace
+	UnionMode UnionMode
+	// Whether unions of null and one other type are generated as pointers
+	OptionalPointers bool
+	// Whether the generated Serialize methods validate records before writing them
+	ValidateOnSerialize bool
+	// Whether maps are serialized in sorted key order, unless their schema sets sorted_keys
+	SortedMaps bool
+	// The import path of the generated package, if each namespace is generated as its own package below it
+	NamespacePackages string
 }
 
 func NewNamespace() *Namespace {
@@ -122,8 +160,8 @@ func (n *Namespace) decodeRecordDefinition(namespace string, schemaMap map[strin
 		return nil, err
 	}
 
-	if typeStr != "record" {
-		return nil, fmt.Errorf("Type of record must be 'record'")
+	if typeStr != "record" && typeStr != "error" {
+		return nil, fmt.Errorf("Type of record must be 'record' or 'error'")
 	}
 
 	name, err := getMapString(schemaMap, "name")
@@ -137,6 +175,9 @@ func (n *Namespace) decodeRecordDefinition(namespace string, schemaMap map[strin
 			return nil, err
 		}
 	}
+	// The namespace of a full name like com.example.User is the enclosing namespace of its fields
+	qualifiedName := ParseAvroName(namespace, name)
+	namespace = qualifiedName.Namespace
 
 	fieldList, err := getMapArray(schemaMap, "fields")
 	if err != nil {
@@ -144,6 +185,7 @@ func (n *Namespace) decodeRecordDefinition(namespace string, schemaMap map[strin
 	}
 
 	decodedFields := make([]Field, 0)
+	fieldOrder := make(map[string]SortOrder)
 	for _, f := range fieldList {
 		field, ok := f.(map[string]interface{})
 		if !ok {
@@ -162,6 +204,16 @@ func (n *Namespace) decodeRecordDefinition(namespace string, schemaMap map[strin
 		if err != nil {
 			return nil, err
 		}
+		if orderValue, ok := field["order"]; ok {
+			orderStr, ok := orderValue.(string)
+			if !ok {
+				return nil, NewSchemaError(fieldName, NewWrongMapValueTypeError("order", "string", orderValue))
+			}
+			fieldOrder[fieldName], err = ParseSortOrder(orderStr)
+			if err != nil {
+				return nil, NewSchemaError(fieldName, err)
+			}
+		}
 
 		decodedFields = append(decodedFields, fieldStruct)
 	}
@@ -181,11 +233,15 @@ func (n *Namespace) decodeRecordDefinition(namespace string, schemaMap map[strin
 	}
 
 	return &RecordDefinition{
-		name:     ParseAvroName(namespace, name),
+		name:     qualifiedName,
+		isError:  typeStr == "error",
 		version:  version,
 		aliases:  aliases,
 		fields:   decodedFields,
 		metadata: schemaMap,
+
+		fieldOrder:          fieldOrder,
+		validateOnSerialize: n.ValidateOnSerialize,
 	}, nil
 }
 
@@ -297,6 +353,8 @@ func (n *Namespace) decodeUnionDefinition(namespace, nameStr string, def interfa
 		hasDefault:   hasDef,
 		defaultValue: def,
 		itemType:     unionFields,
+		mode:         n.UnionMode,
+		optional:     n.OptionalPointers && isOptionalUnion(unionFields),
 	}, nil
 }
 
@@ -406,12 +464,20 @@ func (n *Namespace) decodeComplexDefinition(namespace, nameStr string, typeMap m
 		if err != nil {
 			return nil, NewSchemaError(nameStr, err)
 		}
+		sortedKeys := n.SortedMaps
+		if sortedValue, ok := typeMap["sorted_keys"]; ok {
+			sortedKeys, ok = sortedValue.(bool)
+			if !ok {
+				return nil, NewSchemaError(nameStr, NewWrongMapValueTypeError("sorted_keys", "bool", sortedValue))
+			}
+		}
 		return &mapField{
 			name:         nameStr,
 			itemType:     fieldType,
 			hasDefault:   hasDef,
 			defaultValue: def,
 			metadata:     typeMap,
+			sortedKeys:   sortedKeys,
 		}, nil
 	case "enum":
 		definition, err := n.decodeEnumDefinition(namespace, typeMap)
@@ -428,6 +494,7 @@ func (n *Namespace) decodeComplexDefinition(namespace, nameStr string, typeMap m
 			def:          nil,
 			defaultValue: def,
 			hasDefault:   hasDef,
+			namespace:    namespace,
 		}, nil
 	case "fixed":
 		definition, err := n.decodeFixedDefinition(namespace, typeMap)
@@ -444,8 +511,9 @@ func (n *Namespace) decodeComplexDefinition(namespace, nameStr string, typeMap m
 			def:          nil,
 			defaultValue: def,
 			hasDefault:   hasDef,
+			namespace:    namespace,
 		}, nil
-	case "record":
+	case "record", "error":
 		definition, err := n.decodeRecordDefinition(namespace, typeMap)
 		if err != nil {
 			return nil, NewSchemaError(nameStr, err)
@@ -460,7 +528,11 @@ func (n *Namespace) decodeComplexDefinition(namespace, nameStr string, typeMap m
 			def:          nil,
 			defaultValue: def,
 			hasDefault:   hasDef,
+			namespace:    namespace,
 		}, nil
+	case "boolean", "bytes", "null":
+		// Primitives with attributes, like a logical type annotation
+		return n.createFieldStruct(namespace, nameStr, typeStr, def, hasDef)
 	default:
 		return nil, NewSchemaError(nameStr, fmt.Errorf("Unknown type name %v", typeStr))
 	}
@@ -578,6 +650,7 @@ func (n *Namespace) createFieldStruct(namespace, nameStr, typeStr string, def in
 			def:          nil,
 			defaultValue: def,
 			hasDefault:   hasDef,
+			namespace:    namespace,
 		}, nil
 	}
 }
@@ -0,0 +1,307 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/alanctgardner/gogen-avro/generator"
+)
+
+const sizeLongMethod = `
+func sizeLong(r int64) int {
+	encoded := uint64((r << 1) ^ (r >> 63))
+	size := 1
+	for encoded >= 0x80 {
+		encoded >>= 7
+		size++
+	}
+	return size
+}
+`
+
+const sizeIntMethod = `
+func sizeInt(r int32) int {
+	return sizeLong(int64(r))
+}
+`
+
+const sizeStringMethod = `
+func sizeString(r string) int {
+	return sizeLong(int64(len(r))) + len(r)
+}
+`
+
+const sizeBytesMethod = `
+func sizeBytes(r []byte) int {
+	return sizeLong(int64(len(r))) + len(r)
+}
+`
+
+const sizeFixedTemplate = `
+func %v(r %v) int {
+	return %v
+}
+`
+
+const sizeEnumTemplate = `
+func %v(r %v) int {
+	return sizeInt(int32(r))
+}
+`
+
+const sizeRecordTemplate = `
+func %v(r %v) int {
+	size := 0
+%v	return size
+}
+`
+
+const sizeArrayTemplate = `
+func %v(r %v) int {
+	if len(r) == 0 {
+		return 1
+	}
+	size := sizeLong(int64(len(r))) + 1
+	for _, e := range r {
+		size += %v(e)
+	}
+	return size
+}
+`
+
+const sizeMapTemplate = `
+func %v(r %v) int {
+	if len(r) == 0 {
+		return 1
+	}
+	size := sizeLong(int64(len(r))) + 1
+	for k, e := range r {
+		size += sizeString(k) + %v(e)
+	}
+	return size
+}
+`
+
+const sizeUnionTemplate = `
+func %v(r %v) int {
+	%v
+%v
+	}
+	return 0
+}
+`
+
+const sizeOptionalTemplate = `
+func %v(r %v) int {
+	if r == nil {
+		return %v
+	}
+	return %v + %v(%v)
+}
+`
+
+const sliceWriterDef = `
+// sliceWriter appends everything written to it to a byte slice
+type sliceWriter []byte
+`
+
+const sliceWriterWriteMethodDef = `
+func (w *sliceWriter) Write(p []byte) (int, error) {
+	*w = append(*w, p...)
+	return len(p), nil
+}
+`
+
+const sliceWriterWriteByteMethodDef = `
+func (w *sliceWriter) WriteByte(b byte) error {
+	*w = append(*w, b)
+	return nil
+}
+`
+
+const sliceWriterWriteStringMethodDef = `
+func (w *sliceWriter) WriteString(s string) (int, error) {
+	*w = append(*w, s...)
+	return len(s), nil
+}
+`
+
+const sliceWriterGrowMethodDef = `
+// Grow does nothing: the writers ask for the largest size of a value, which would reallocate a slice that has exactly enough space
+func (w *sliceWriter) Grow(n int) {}
+`
+
+const recordMarshalAppendTemplate = `
+// MarshalAppend appends the binary encoding of the record to dst and returns the extended slice.
+// Use EncodedSize to allocate dst with enough space for the record.
+func (r %v) MarshalAppend(dst []byte) ([]byte, error) {
+%v	w := sliceWriter(dst)
+	if err := %v(r, &w); err != nil {
+		return dst, err
+	}
+	return w, nil
+}
+`
+
+const recordMarshalAppendValidateTemplate = `	if err := r.Validate(); err != nil {
+		return dst, err
+	}
+`
+
+const recordEncodedSizeTemplate = `
+// EncodedSize returns the length of the binary encoding of the record, without encoding it
+func (r %v) EncodedSize() int {
+	return %v(r)
+}
+`
+
+func sizeMethod(f Field) string {
+	return "size" + f.FieldType()
+}
+
+func definitionSizeMethod(d Definition) string {
+	return "size" + d.FieldType()
+}
+
+/*
+  The length of the varint encoding of i, for union indexes which are known when the code is generated.
+*/
+func longSize(i int64) int {
+	encoded := uint64((i << 1) ^ (i >> 63))
+	size := 1
+	for encoded >= 0x80 {
+		encoded >>= 7
+		size++
+	}
+	return size
+}
+
+func addSizeFunction(p *generator.Package, name, def string) {
+	p.AddFunction(UTIL_FILE, "", name, def)
+}
+
+/*
+  Add the size function for the field, and for every type beneath it.
+*/
+func addSizeField(p *generator.Package, f Field) {
+	if p.HasFunction(UTIL_FILE, "", sizeMethod(f)) {
+		return
+	}
+	switch t := f.(type) {
+	case *nullField:
+		addSizeFunction(p, sizeMethod(t), "\nfunc sizeNull(r interface{}) int {\n\treturn 0\n}\n")
+	case *boolField:
+		addSizeFunction(p, sizeMethod(t), "\nfunc sizeBool(r bool) int {\n\treturn 1\n}\n")
+	case *floatField:
+		addSizeFunction(p, sizeMethod(t), "\nfunc sizeFloat(r float32) int {\n\treturn 4\n}\n")
+	case *doubleField:
+		addSizeFunction(p, sizeMethod(t), "\nfunc sizeDouble(r float64) int {\n\treturn 8\n}\n")
+	case *intField:
+		addSizeFunction(p, "sizeLong", sizeLongMethod)
+		addSizeFunction(p, sizeMethod(t), sizeIntMethod)
+	case *longField:
+		addSizeFunction(p, sizeMethod(t), sizeLongMethod)
+	case *stringField:
+		addSizeFunction(p, "sizeLong", sizeLongMethod)
+		addSizeFunction(p, sizeMethod(t), sizeStringMethod)
+	case *bytesField:
+		addSizeFunction(p, "sizeLong", sizeLongMethod)
+		addSizeFunction(p, sizeMethod(t), sizeBytesMethod)
+	case *arrayField:
+		addSizeFunction(p, "sizeLong", sizeLongMethod)
+		addSizeFunction(p, sizeMethod(t), fmt.Sprintf(sizeArrayTemplate, sizeMethod(t), t.GoType(), sizeMethod(t.itemType)))
+		addSizeField(p, t.itemType)
+	case *mapField:
+		addSizeFunction(p, "sizeLong", sizeLongMethod)
+		addSizeFunction(p, "sizeString", sizeStringMethod)
+		addSizeFunction(p, sizeMethod(t), fmt.Sprintf(sizeMapTemplate, sizeMethod(t), t.GoType(), sizeMethod(t.itemType)))
+		addSizeField(p, t.itemType)
+	case *unionField:
+		addSizeUnion(p, t)
+	case *Reference:
+		addSizeDefinition(p, t.def)
+	}
+}
+
+func addSizeUnion(p *generator.Package, u *unionField) {
+	if u.optional {
+		nullIndex, valueIndex := u.optionalBranches()
+		item := u.optionalItem()
+		addSizeFunction(p, sizeMethod(u), fmt.Sprintf(sizeOptionalTemplate, sizeMethod(u), u.GoType(), longSize(int64(nullIndex)), longSize(int64(valueIndex)), sizeMethod(item), u.optionalValue("r")))
+		addSizeField(p, item)
+		return
+	}
+
+	cases := ""
+	usesBranch := false
+	for i, item := range u.itemType {
+		if _, isNull := item.(*nullField); isNull {
+			cases += fmt.Sprintf("%v\nreturn %v\n", u.branchCase(item), longSize(int64(i)))
+			continue
+		}
+		cases += fmt.Sprintf("%v\nreturn %v + %v(%v)\n", u.branchCase(item), longSize(int64(i)), sizeMethod(item), u.branchValue("r", item))
+		usesBranch = true
+	}
+	switchStmt := u.branchSwitch("r")
+	if u.mode == UnionModeInterface && !usesBranch {
+		switchStmt = u.branchTypeSwitch("r")
+	}
+	addSizeFunction(p, sizeMethod(u), fmt.Sprintf(sizeUnionTemplate, sizeMethod(u), u.GoType(), switchStmt, cases))
+	for _, item := range u.itemType {
+		addSizeField(p, item)
+	}
+}
+
+func addSizeDefinition(p *generator.Package, d Definition) {
+	if p.HasFunction(UTIL_FILE, "", definitionSizeMethod(d)) {
+		return
+	}
+	switch t := d.(type) {
+	case *FixedDefinition:
+		addSizeFunction(p, definitionSizeMethod(t), fmt.Sprintf(sizeFixedTemplate, definitionSizeMethod(t), t.GoType(), t.sizeBytes))
+	case *EnumDefinition:
+		addSizeFunction(p, "sizeLong", sizeLongMethod)
+		addSizeFunction(p, "sizeInt", sizeIntMethod)
+		addSizeFunction(p, definitionSizeMethod(t), fmt.Sprintf(sizeEnumTemplate, definitionSizeMethod(t), t.GoType()))
+	case *RecordDefinition:
+		if t.pkg != "" {
+			addSizeFunction(p, definitionSizeMethod(t), fmt.Sprintf("\nfunc %v(r %v) int {\n\treturn r.EncodedSize()\n}\n", definitionSizeMethod(t), t.GoType()))
+			return
+		}
+		fieldSizes := ""
+		for _, f := range t.fields {
+			fieldSizes += fmt.Sprintf("\tsize += %v(r.%v)\n", sizeMethod(f), f.GoName())
+		}
+		addSizeFunction(p, definitionSizeMethod(t), fmt.Sprintf(sizeRecordTemplate, definitionSizeMethod(t), t.GoType(), fieldSizes))
+		for _, f := range t.fields {
+			addSizeField(p, f)
+		}
+	}
+}
+
+func addSliceWriter(p *generator.Package) {
+	p.AddStruct(UTIL_FILE, "sliceWriter", sliceWriterDef)
+	p.AddFunction(UTIL_FILE, "*sliceWriter", "Write", sliceWriterWriteMethodDef)
+	p.AddFunction(UTIL_FILE, "*sliceWriter", "WriteByte", sliceWriterWriteByteMethodDef)
+	p.AddFunction(UTIL_FILE, "*sliceWriter", "WriteString", sliceWriterWriteStringMethodDef)
+	p.AddFunction(UTIL_FILE, "*sliceWriter", "Grow", sliceWriterGrowMethodDef)
+}
+
+/*
+  Add the MarshalAppend method, which encodes the record to the end of a byte slice, and EncodedSize,
+  which computes the length of its encoding.
+*/
+func (r *RecordDefinition) AddMarshalAppend(p *generator.Package) {
+	// Import guard, to avoid circular dependencies
+	if p.HasFunction(r.filename(), r.GoType(), "MarshalAppend") {
+		return
+	}
+	addSliceWriter(p)
+	addSizeDefinition(p, r)
+
+	validate := ""
+	if r.validateOnSerialize {
+		validate = recordMarshalAppendValidateTemplate
+	}
+	p.AddFunction(r.filename(), r.GoType(), "MarshalAppend", fmt.Sprintf(recordMarshalAppendTemplate, r.GoType(), validate, r.SerializerMethod()))
+	p.AddFunction(r.filename(), r.GoType(), "EncodedSize", fmt.Sprintf(recordEncodedSizeTemplate, r.GoType(), definitionSizeMethod(r)))
+}
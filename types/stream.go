@@ -0,0 +1,353 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/alanctgardner/gogen-avro/generator"
+)
+
+const streamBlockBytesDef = `
+// The number of encoded bytes the streaming encoders buffer before they write a block
+var StreamBlockBytes = 64 << 10
+`
+
+const blockEncoderDef = `
+// blockEncoder buffers the encoded items of an array or map, and writes them in blocks with their count and byte size
+type blockEncoder struct {
+	w     io.Writer
+	block sliceWriter
+	count int64
+}
+`
+
+const blockEncoderAddedMethodDef = `
+// added records an item which was appended to the block after n bytes, or drops what was appended if there was an error
+func (e *blockEncoder) added(n int, err error) error {
+	if err != nil {
+		e.block = e.block[:n]
+		return err
+	}
+	e.count++
+	if len(e.block) >= StreamBlockBytes {
+		return e.Flush()
+	}
+	return nil
+}
+`
+
+const blockEncoderFlushMethodDef = `
+// Flush writes the items added since the last block
+func (e *blockEncoder) Flush() error {
+	if e.count == 0 {
+		return nil
+	}
+	if err := writeLong(-e.count, e.w); err != nil {
+		return err
+	}
+	if err := writeLong(int64(len(e.block)), e.w); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(e.block); err != nil {
+		return err
+	}
+	e.block = e.block[:0]
+	e.count = 0
+	return nil
+}
+`
+
+const blockEncoderCloseMethodDef = `
+// close writes the last block and the end of the array or map
+func (e *blockEncoder) close() error {
+	if err := e.Flush(); err != nil {
+		return err
+	}
+	return writeLong(0, e.w)
+}
+`
+
+const arrayEncoderTemplate = `
+// %v writes the items of an array as they're added, in blocks of about StreamBlockBytes
+type %v struct {
+	blockEncoder
+}
+
+func new%v(w io.Writer) *%v {
+	return &%v{blockEncoder{w: w}}
+}
+
+// Encode adds an item to the array. It's written when its block fills up, or by Flush.
+func (e *%v) Encode(item %v) error {
+	return e.added(len(e.block), %v(item, &e.block))
+}
+`
+
+const mapEncoderTemplate = `
+// %v writes the entries of a map as they're added, in blocks of about StreamBlockBytes
+type %v struct {
+	blockEncoder
+}
+
+func new%v(w io.Writer) *%v {
+	return &%v{blockEncoder{w: w}}
+}
+
+// Encode adds an entry to the map. It's written when its block fills up, or by Flush.
+func (e *%v) Encode(key string, value %v) error {
+	n := len(e.block)
+	err := writeString(key, &e.block)
+	if err == nil {
+		err = %v(value, &e.block)
+	}
+	return e.added(n, err)
+}
+`
+
+const arrayStreamReaderTemplate = `
+func %v(r io.Reader, items func(%v) error) error {
+	var total int64
+	for {
+		blkSize, err := readLong(r)
+		if err != nil {
+			return err
+		}
+		if blkSize == 0 {
+			return nil
+		}
+		if blkSize < 0 {
+			blkSize = -blkSize
+			_, err = readLong(r)
+			if err != nil {
+				return err
+			}
+		}
+		for i := int64(0); i < blkSize; i++ {
+			elem, err := %v(r)
+			if err == nil {
+				err = items(elem)
+			}
+			if err != nil {
+				return wrapDecodeError(err, fmt.Sprintf("[%%d]", total+i), %q)
+			}
+		}
+		total += blkSize
+	}
+}
+`
+
+const mapStreamReaderTemplate = `
+func %v(r io.Reader, entries func(string, %v) error) error {
+	for {
+		blkSize, err := readLong(r)
+		if err != nil {
+			return err
+		}
+		if blkSize == 0 {
+			return nil
+		}
+		if blkSize < 0 {
+			blkSize = -blkSize
+			_, err = readLong(r)
+			if err != nil {
+				return err
+			}
+		}
+		for i := int64(0); i < blkSize; i++ {
+			key, err := readString(r)
+			if err != nil {
+				return err
+			}
+			val, err := %v(r)
+			if err == nil {
+				err = entries(key, val)
+			}
+			if err != nil {
+				return wrapDecodeError(err, fmt.Sprintf("[%%q]", key), %q)
+			}
+		}
+	}
+}
+`
+
+const recordStreamingSerializerTemplate = `
+// %v encodes the record like Serialize, except for %v: instead of the field's value, it writes what
+// %v adds to the encoder it's passed, in blocks as they're added.
+func (r %v) %v(w io.Writer, %v func(*%v) error) error {
+	var err error
+%v	return nil
+}
+`
+
+const recordStreamingDeserializerTemplate = `
+// %v decodes a %v like Deserialize%v, except for %v: it's left empty, and %v is called
+// with each of its %v as it's read. An error returned by %v stops decoding, and is returned in a *DecodeError.
+func %v(r io.Reader, %v %v) (%v, error) {
+	d := decoderFor(r)
+	start := d.Offset()
+	str, err := %v(d, %v)
+	if err != nil {
+		return nil, decodeErrorAt(err, %q, %q, start, d.Offset())
+	}
+	return str, nil
+}
+`
+
+const recordStreamingReaderTemplate = `
+func %v(r io.Reader, %v %v) (%v, error) {
+	var str = &%v{}
+	var err error
+%v	return str, nil
+}
+`
+
+func streamEncoderType(f Field) string {
+	return f.FieldType() + "Encoder"
+}
+
+func streamReaderMethod(f Field) string {
+	return "read" + f.FieldType() + "Stream"
+}
+
+/*
+  The name of the callback parameter of the streaming functions for the field, and its type.
+*/
+func streamCallback(f Field) (string, string) {
+	switch t := f.(type) {
+	case *arrayField:
+		return "items", fmt.Sprintf("func(%v) error", t.itemType.GoType())
+	case *mapField:
+		return "entries", fmt.Sprintf("func(string, %v) error", t.itemType.GoType())
+	}
+	return "", ""
+}
+
+func isStreamable(f Field) bool {
+	switch f.(type) {
+	case *arrayField, *mapField:
+		return true
+	}
+	return false
+}
+
+func addBlockEncoder(p *generator.Package) {
+	addSliceWriter(p)
+	p.AddImport(UTIL_FILE, "io")
+	p.AddStruct(UTIL_FILE, "StreamBlockBytes", streamBlockBytesDef)
+	p.AddStruct(UTIL_FILE, "blockEncoder", blockEncoderDef)
+	p.AddFunction(UTIL_FILE, "*blockEncoder", "added", blockEncoderAddedMethodDef)
+	p.AddFunction(UTIL_FILE, "*blockEncoder", "Flush", blockEncoderFlushMethodDef)
+	p.AddFunction(UTIL_FILE, "*blockEncoder", "close", blockEncoderCloseMethodDef)
+	p.AddFunction(UTIL_FILE, "", "writeLong", writeLongMethod)
+	p.AddFunction(UTIL_FILE, "", "encodeInt", encodeIntMethod)
+	p.AddStruct(UTIL_FILE, "ByteWriter", byteWriterInterface)
+}
+
+/*
+  Add the encoder type which writes the items of an array or map field in blocks.
+*/
+func addStreamEncoder(p *generator.Package, f Field) {
+	name := streamEncoderType(f)
+	if p.HasStruct(UTIL_FILE, name) {
+		return
+	}
+	addBlockEncoder(p)
+	switch t := f.(type) {
+	case *arrayField:
+		p.AddStruct(UTIL_FILE, name, fmt.Sprintf(arrayEncoderTemplate, name, name, name, name, name, name, t.itemType.GoType(), t.itemType.SerializerMethod()))
+	case *mapField:
+		p.AddStruct(UTIL_FILE, "StringWriter", stringWriterInterface)
+		p.AddFunction(UTIL_FILE, "", "writeString", writeStringMethod)
+		p.AddStruct(UTIL_FILE, name, fmt.Sprintf(mapEncoderTemplate, name, name, name, name, name, name, t.itemType.GoType(), t.itemType.SerializerMethod()))
+	}
+}
+
+/*
+  Add the function which reads an array or map field, passing each item to a callback instead of keeping it.
+*/
+func addStreamReader(p *generator.Package, f Field) {
+	name := streamReaderMethod(f)
+	if p.HasFunction(UTIL_FILE, "", name) {
+		return
+	}
+	switch t := f.(type) {
+	case *arrayField:
+		p.AddFunction(UTIL_FILE, "", name, fmt.Sprintf(arrayStreamReaderTemplate, name, t.itemType.GoType(), t.itemType.DeserializerMethod(), decodeTypeName(t.itemType)))
+	case *mapField:
+		p.AddFunction(UTIL_FILE, "", "readString", readStringMethod)
+		p.AddFunction(UTIL_FILE, "", name, fmt.Sprintf(mapStreamReaderTemplate, name, t.itemType.GoType(), t.itemType.DeserializerMethod(), decodeTypeName(t.itemType)))
+	}
+	p.AddFunction(UTIL_FILE, "", "readLong", readLongMethod)
+	p.AddImport(UTIL_FILE, "fmt")
+}
+
+func (r *RecordDefinition) streamingSerializerMethod(f Field) string {
+	return "SerializeStreaming" + f.GoName()
+}
+
+func (r *RecordDefinition) streamingDeserializerMethod(f Field) string {
+	return fmt.Sprintf("Deserialize%vStreaming%v", r.FieldType(), f.GoName())
+}
+
+func (r *RecordDefinition) streamingReaderMethod(f Field) string {
+	return fmt.Sprintf("read%vStreaming%v", r.FieldType(), f.GoName())
+}
+
+/*
+  Add SerializeStreaming<Field> for each array and map field in the record, which takes the field's
+  items from a callback and writes them in blocks as they arrive.
+*/
+func (r *RecordDefinition) AddStreamingSerializers(p *generator.Package) {
+	for _, streamed := range r.fields {
+		if !isStreamable(streamed) || p.HasFunction(r.filename(), r.GoType(), r.streamingSerializerMethod(streamed)) {
+			continue
+		}
+		addStreamEncoder(p, streamed)
+		callback, _ := streamCallback(streamed)
+		writers := ""
+		if r.validateOnSerialize {
+			writers += "\tif err = r.Validate(); err != nil {\n\t\treturn err\n\t}\n"
+		}
+		for _, f := range r.fields {
+			if f != streamed {
+				writers += fmt.Sprintf("\terr = %v(r.%v, w)\n\tif err != nil {\n\t\treturn err\n\t}\n", f.SerializerMethod(), f.GoName())
+				continue
+			}
+			writers += fmt.Sprintf("\tenc := new%v(w)\n\tif err = %v(enc); err != nil {\n\t\treturn err\n\t}\n\tif err = enc.close(); err != nil {\n\t\treturn err\n\t}\n", streamEncoderType(f), callback)
+		}
+		name := r.streamingSerializerMethod(streamed)
+		def := fmt.Sprintf(recordStreamingSerializerTemplate, name, streamed.GoName(), callback, r.GoType(), name, callback, streamEncoderType(streamed), writers)
+		p.AddFunction(r.filename(), r.GoType(), name, def)
+	}
+}
+
+/*
+  Add Deserialize<Record>Streaming<Field> for each array and map field in the record, which passes
+  the field's items to a callback as they're read rather than keeping them.
+*/
+func (r *RecordDefinition) AddStreamingDeserializers(p *generator.Package) {
+	for _, streamed := range r.fields {
+		if !isStreamable(streamed) || p.HasFunction(r.filename(), "", r.streamingDeserializerMethod(streamed)) {
+			continue
+		}
+		addStreamReader(p, streamed)
+		callback, callbackType := streamCallback(streamed)
+		readers := ""
+		for _, f := range r.fields {
+			read := fmt.Sprintf("str.%v, err = %v(r)", f.GoName(), f.DeserializerMethod())
+			if f == streamed {
+				read = fmt.Sprintf("err = %v(r, %v)", streamReaderMethod(f), callback)
+			}
+			readers += fmt.Sprintf("\t%v\n\tif err != nil {\n\t\treturn nil, wrapDecodeError(err, %q, %q)\n\t}\n", read, f.AvroName(), decodeTypeName(f))
+		}
+		reader := r.streamingReaderMethod(streamed)
+		p.AddFunction(UTIL_FILE, "", reader, fmt.Sprintf(recordStreamingReaderTemplate, reader, callback, callbackType, r.GoType(), r.FieldType(), readers))
+
+		name := r.streamingDeserializerMethod(streamed)
+		kind := "items"
+		if _, ok := streamed.(*mapField); ok {
+			kind = "entries"
+		}
+		def := fmt.Sprintf(recordStreamingDeserializerTemplate, name, r.FieldType(), r.FieldType(), streamed.GoName(), callback, kind, callback, name, callback, callbackType, r.GoType(), reader, callback, r.name.Name, r.name.String())
+		p.AddFunction(r.filename(), "", name, def)
+	}
+}
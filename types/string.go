@@ -29,8 +29,10 @@ func readString(r io.Reader) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	bb := make([]byte, len)
-	_, err = io.ReadFull(r, bb)
+	if err := checkLength("string", len, MaxStringLength); err != nil {
+		return "", err
+	}
+	bb, err := decoderFor(r).next(int(len))
 	if err != nil {
 		return "", err
 	}
@@ -90,7 +92,10 @@ func (s *stringField) AddSerializer(p *generator.Package) {
 
 func (s *stringField) AddDeserializer(p *generator.Package) {
 	p.AddFunction(UTIL_FILE, "", "readLong", readLongMethod)
+	p.AddImport(UTIL_FILE, "fmt")
 	p.AddFunction(UTIL_FILE, "", "readString", readStringMethod)
+	addDecoder(p)
+	addLengthLimits(p)
 	p.AddImport(UTIL_FILE, "io")
 }
 
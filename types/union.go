@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/alanctgardner/gogen-avro/generator"
 )
@@ -36,11 +37,88 @@ func %v(r io.Reader) (%v, error) {
 }
 `
 
+const unionInterfaceSerializerTemplate = `
+func %v(r %v, w io.Writer) error {
+	%v
+		%v
+	}
+	return fmt.Errorf("Invalid value for %v")
+}
+`
+
+const unionInterfaceDeserializerTemplate = `
+func %v(r io.Reader) (%v, error) {
+	field, err := readLong(r)
+	if err != nil {
+		return nil, err
+	}
+	switch field {
+		%v
+	}
+	return nil, fmt.Errorf("Invalid value for %v")
+}
+`
+
+const unionDerefTemplate = `
+// %v returns the union with a branch held by pointer replaced by its value, so both forms are handled the same way
+func %v(r %v) %v {
+	switch branch := r.(type) {
+%v
+	}
+	return r
+}
+`
+
+const unionValueTemplate = `
+// Value returns the value of the branch held by the union, or nil if it holds null or an invalid branch
+func (u %v) Value() interface{} {
+	switch u.UnionType {
+		%v
+	}
+	return nil
+}
+`
+
+const unionSetTemplate = `
+// Set stores v in the union, selecting the branch by its Go type
+func (u *%v) Set(v interface{}) error {
+	switch v := v.(type) {
+		%v
+	}
+	return fmt.Errorf("Invalid type %%T for %v", v)
+}
+`
+
+/*
+  UnionMode selects the Go representation generated for unions.
+*/
+type UnionMode int
+
+const (
+	// Each union is a struct with a field for every branch, and a UnionType enum selecting one of them
+	UnionModeStruct UnionMode = iota
+	// Each union is a sealed interface implemented by a wrapper type for every branch except null, which is nil
+	UnionModeInterface
+)
+
+func ParseUnionMode(mode string) (UnionMode, error) {
+	switch mode {
+	case "struct":
+		return UnionModeStruct, nil
+	case "interface":
+		return UnionModeInterface, nil
+	}
+	return UnionModeStruct, fmt.Errorf("Unknown union mode %q, expected struct or interface", mode)
+}
+
 type unionField struct {
 	name         string
 	hasDefault   bool
 	defaultValue interface{}
 	itemType     []Field
+	mode         UnionMode
+	// Two-branch unions with a null branch are generated as a pointer, with nil for null
+	optional bool
 }
 
 func (s *unionField) HasDefault() bool {
@@ -68,6 +146,9 @@ func (s *unionField) FieldType() string {
 }
 
 func (s *unionField) GoType() string {
+	if s.optional {
+		return s.optionalGoType()
+	}
 	return s.FieldType()
 }
 
@@ -101,11 +182,198 @@ func (s *unionField) unionSerializer() string {
 }
 
 func (s *unionField) unionDeserializer() string {
+	return s.unionDeserializerWith(s.DeserializerMethod(), Field.DeserializerMethod)
+}
+
+/*
+  The union deserializer named name, which reads each branch with the function named by reader.
+*/
+func (s *unionField) unionDeserializerWith(name string, reader func(Field) string) string {
 	switchCase := ""
 	for _, t := range s.itemType {
-		switchCase += fmt.Sprintf("case %v:\nval, err :=  %v(r)\nif err != nil {return unionStr, err}\nunionStr.%v = val\n", s.unionEnumType()+t.FieldType(), t.DeserializerMethod(), t.FieldType())
+		switchCase += fmt.Sprintf("case %v:\nval, err :=  %v(r)\nif err != nil {return unionStr, err}\nunionStr.%v = val\n", s.unionEnumType()+t.FieldType(), reader(t), t.FieldType())
+	}
+	return fmt.Sprintf(unionDeserializerTemplate, name, s.GoType(), s.GoType(), s.unionEnumType(), switchCase, s.GoType())
+}
+
+/*
+  Add the constructors, accessors and predicates for a union struct.
+*/
+func (s *unionField) addStructHelpers(p *generator.Package) {
+	valueCases := ""
+	setCases := ""
+	for _, item := range s.itemType {
+		enumValue := s.unionEnumType() + item.FieldType()
+		constructor := "New" + s.FieldType() + item.FieldType()
+		if _, isNull := item.(*nullField); isNull {
+			p.AddFunction(s.filename(), "", constructor, fmt.Sprintf("// %v returns a %v holding null\nfunc %v() %v {\nreturn %v\n}\n", constructor, s.FieldType(), constructor, s.FieldType(), s.newBranch(item, "")))
+			setCases += fmt.Sprintf("case nil:\n*u = %v\nreturn nil\n", s.newBranch(item, ""))
+		} else {
+			p.AddFunction(s.filename(), "", constructor, fmt.Sprintf("// %v returns a %v holding the %v branch\nfunc %v(v %v) %v {\nreturn %v\n}\n", constructor, s.FieldType(), item.FieldType(), constructor, item.GoType(), s.FieldType(), s.newBranch(item, "v")))
+			valueCases += fmt.Sprintf("case %v:\nreturn u.%v\n", enumValue, item.FieldType())
+			setCases += fmt.Sprintf("case %v:\n*u = %v\nreturn nil\n", item.GoType(), s.newBranch(item, "v"))
+		}
+		predicate := "Is" + item.FieldType()
+		if !s.hasStructField(predicate) {
+			p.AddFunction(s.filename(), s.FieldType(), predicate, fmt.Sprintf("// %v returns whether the union holds the %v branch\nfunc (u %v) %v() bool {\nreturn u.UnionType == %v\n}\n", predicate, item.FieldType(), s.FieldType(), predicate, enumValue))
+		}
+	}
+
+	// A union of null and one other type also gets a constructor named after the union, for the other type
+	if isOptionalUnion(s.itemType) {
+		item := s.optionalItem()
+		constructor := "New" + s.FieldType()
+		p.AddFunction(s.filename(), "", constructor, fmt.Sprintf("// %v returns a %v holding the %v branch\nfunc %v(v %v) %v {\nreturn %v\n}\n", constructor, s.FieldType(), item.FieldType(), constructor, item.GoType(), s.FieldType(), s.newBranch(item, "v")))
+	}
+
+	if !s.hasStructField("Value") {
+		p.AddFunction(s.filename(), s.FieldType(), "Value", fmt.Sprintf(unionValueTemplate, s.FieldType(), valueCases))
+	}
+	if !s.hasStructField("Set") {
+		p.AddImport(s.filename(), "fmt")
+		p.AddFunction(s.filename(), "*"+s.FieldType(), "Set", fmt.Sprintf(unionSetTemplate, s.FieldType(), setCases, s.FieldType()))
 	}
-	return fmt.Sprintf(unionDeserializerTemplate, s.DeserializerMethod(), s.GoType(), s.GoType(), s.unionEnumType(), switchCase, s.GoType())
+}
+
+/*
+  Whether the union struct has a field called name. The fields are named after the branches, so a
+  branch like a record called Value takes the name of a helper method, which is then left out.
+*/
+func (s *unionField) hasStructField(name string) bool {
+	if name == "UnionType" {
+		return true
+	}
+	for _, item := range s.itemType {
+		if item.FieldType() == name {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+  The wrapper type for a branch of the union in interface mode.
+*/
+func (s *unionField) branchType(item Field) string {
+	return s.FieldType() + item.FieldType()
+}
+
+func (s *unionField) markerMethod() string {
+	return "is" + s.FieldType()
+}
+
+func (s *unionField) unionInterfaceDef() string {
+	branches := make([]string, 0, len(s.itemType))
+	nullable := ""
+	for _, item := range s.itemType {
+		if _, isNull := item.(*nullField); isNull {
+			nullable = ", or nil for null"
+			continue
+		}
+		branches = append(branches, s.branchType(item))
+	}
+	return fmt.Sprintf("// %v holds one of %v%v\ntype %v interface {\n%v()\n}\n", s.FieldType(), strings.Join(branches, ", "), nullable, s.FieldType(), s.markerMethod())
+}
+
+func (s *unionField) unionInterfaceSerializer() string {
+	switchCase := ""
+	for i, t := range s.itemType {
+		if _, isNull := t.(*nullField); isNull {
+			switchCase += fmt.Sprintf("case nil:\nreturn writeLong(%v, w)\n", i)
+			continue
+		}
+		switchCase += fmt.Sprintf("case %v:\nif err := writeLong(%v, w); err != nil {\nreturn err\n}\nreturn %v(branch.Value, w)\n", s.branchType(t), i, t.SerializerMethod())
+	}
+	return fmt.Sprintf(unionInterfaceSerializerTemplate, s.SerializerMethod(), s.GoType(), s.branchSwitch("r"), switchCase, s.GoType())
+}
+
+func (s *unionField) unionInterfaceDeserializer() string {
+	return s.unionInterfaceDeserializerWith(s.DeserializerMethod(), Field.DeserializerMethod)
+}
+
+func (s *unionField) unionInterfaceDeserializerWith(name string, reader func(Field) string) string {
+	switchCase := ""
+	for i, t := range s.itemType {
+		if _, isNull := t.(*nullField); isNull {
+			switchCase += fmt.Sprintf("case %v:\nreturn nil, nil\n", i)
+			continue
+		}
+		switchCase += fmt.Sprintf("case %v:\nval, err := %v(r)\nif err != nil {\nreturn nil, err\n}\nreturn %v, nil\n", i, reader(t), s.newBranch(t, "val"))
+	}
+	return fmt.Sprintf(unionInterfaceDeserializerTemplate, name, s.GoType(), switchCase, s.GoType())
+}
+
+/*
+  The statement opening a switch over the branch held by the union value expr.
+  Cases are generated with branchCase, and the value of the branch is read with branchValue.
+*/
+func (s *unionField) branchSwitch(expr string) string {
+	if s.mode == UnionModeInterface {
+		return fmt.Sprintf("switch branch := %v.(type) {", s.branchOf(expr))
+	}
+	return fmt.Sprintf("switch %v.UnionType {", expr)
+}
+
+/*
+  The switch over the branches of the union expr in interface mode, for cases which don't use the branch's value.
+*/
+func (s *unionField) branchTypeSwitch(expr string) string {
+	return fmt.Sprintf("switch %v.(type) {", s.branchOf(expr))
+}
+
+func (s *unionField) derefMethod() string {
+	return "deref" + s.FieldType()
+}
+
+/*
+  The union expr in interface mode, with a branch held by pointer replaced by its value. The pointer types
+  also have the marker method, so they satisfy the interface.
+*/
+func (s *unionField) branchOf(expr string) string {
+	return fmt.Sprintf("%v(%v)", s.derefMethod(), expr)
+}
+
+func (s *unionField) addBranchDeref(p *generator.Package) {
+	cases := ""
+	for _, item := range s.itemType {
+		if _, isNull := item.(*nullField); !isNull {
+			cases += fmt.Sprintf("case *%v:\nif branch != nil {\nreturn *branch\n}\n", s.branchType(item))
+		}
+	}
+	p.AddFunction(s.filename(), "", s.derefMethod(), fmt.Sprintf(unionDerefTemplate, s.derefMethod(), s.derefMethod(), s.GoType(), s.GoType(), cases))
+}
+
+func (s *unionField) branchCase(item Field) string {
+	if s.mode == UnionModeInterface {
+		if _, isNull := item.(*nullField); isNull {
+			return "case nil:"
+		}
+		return fmt.Sprintf("case %v:", s.branchType(item))
+	}
+	return fmt.Sprintf("case %v:", s.unionEnumType()+item.FieldType())
+}
+
+func (s *unionField) branchValue(expr string, item Field) string {
+	if s.mode == UnionModeInterface {
+		return "branch.Value"
+	}
+	return fmt.Sprintf("%v.%v", expr, item.FieldType())
+}
+
+/*
+  An expression for a union value holding the branch item, set to value.
+*/
+func (s *unionField) newBranch(item Field, value string) string {
+	if s.mode == UnionModeInterface {
+		if _, isNull := item.(*nullField); isNull {
+			return "nil"
+		}
+		return fmt.Sprintf("%v{Value: %v}", s.branchType(item), value)
+	}
+	if _, isNull := item.(*nullField); isNull {
+		return fmt.Sprintf("%v{UnionType: %v}", s.GoType(), s.unionEnumType()+item.FieldType())
+	}
+	return fmt.Sprintf("%v{%v: %v, UnionType: %v}", s.GoType(), item.FieldType(), value, s.unionEnumType()+item.FieldType())
 }
 
 func (s *unionField) filename() string {
@@ -113,7 +381,11 @@ func (s *unionField) filename() string {
 }
 
 func (s *unionField) SerializerMethod() string {
-	return fmt.Sprintf("write%v", s.FieldType())
+	name := "writeUnion"
+	for _, item := range s.itemType {
+		name += serializerSuffix(item)
+	}
+	return name
 }
 
 func (s *unionField) DeserializerMethod() string {
@@ -121,8 +393,24 @@ func (s *unionField) DeserializerMethod() string {
 }
 
 func (s *unionField) AddStruct(p *generator.Package) {
-	p.AddStruct(s.filename(), s.unionEnumType(), s.unionEnumDef())
-	p.AddStruct(s.filename(), s.FieldType(), s.unionTypeDef())
+	if s.optional {
+		// The pointer type needs no definition
+	} else if s.mode == UnionModeInterface {
+		p.AddStruct(s.filename(), s.FieldType(), s.unionInterfaceDef())
+		for _, item := range s.itemType {
+			if _, isNull := item.(*nullField); isNull {
+				continue
+			}
+			p.AddStruct(s.filename(), s.branchType(item), fmt.Sprintf("type %v struct {\nValue %v\n}\n", s.branchType(item), item.GoType()))
+			p.AddFunction(s.filename(), s.branchType(item), s.markerMethod(), fmt.Sprintf("func (%v) %v() {}\n", s.branchType(item), s.markerMethod()))
+		}
+		s.addBranchDeref(p)
+	} else {
+		p.AddStruct(s.filename(), s.unionEnumType(), s.unionEnumDef())
+		p.AddStruct(s.filename(), s.FieldType(), s.unionTypeDef())
+		s.addStructHelpers(p)
+		s.addEqualityMethods(p)
+	}
 	for _, f := range s.itemType {
 		f.AddStruct(p)
 	}
@@ -130,7 +418,13 @@ func (s *unionField) AddStruct(p *generator.Package) {
 
 func (s *unionField) AddSerializer(p *generator.Package) {
 	p.AddImport(UTIL_FILE, "fmt")
-	p.AddFunction(UTIL_FILE, "", s.SerializerMethod(), s.unionSerializer())
+	if s.optional {
+		p.AddFunction(UTIL_FILE, "", s.SerializerMethod(), s.optionalSerializer())
+	} else if s.mode == UnionModeInterface {
+		p.AddFunction(UTIL_FILE, "", s.SerializerMethod(), s.unionInterfaceSerializer())
+	} else {
+		p.AddFunction(UTIL_FILE, "", s.SerializerMethod(), s.unionSerializer())
+	}
 	p.AddStruct(UTIL_FILE, "ByteWriter", byteWriterInterface)
 	p.AddFunction(UTIL_FILE, "", "writeLong", writeLongMethod)
 	p.AddFunction(UTIL_FILE, "", "encodeInt", encodeIntMethod)
@@ -142,8 +436,16 @@ func (s *unionField) AddSerializer(p *generator.Package) {
 
 func (s *unionField) AddDeserializer(p *generator.Package) {
 	p.AddImport(UTIL_FILE, "fmt")
-	p.AddFunction(UTIL_FILE, "", s.DeserializerMethod(), s.unionDeserializer())
+	if s.optional {
+		p.AddFunction(UTIL_FILE, "", s.DeserializerMethod(), s.optionalDeserializer())
+	} else if s.mode == UnionModeInterface {
+		p.AddFunction(UTIL_FILE, "", s.DeserializerMethod(), s.unionInterfaceDeserializer())
+	} else {
+		p.AddFunction(UTIL_FILE, "", s.DeserializerMethod(), s.unionDeserializer())
+	}
 	p.AddFunction(UTIL_FILE, "", "readLong", readLongMethod)
+	p.AddImport(UTIL_FILE, "fmt")
+	addDecoder(p)
 	p.AddImport(UTIL_FILE, "io")
 	for _, f := range s.itemType {
 		f.AddDeserializer(p)
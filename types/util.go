@@ -1,5 +1,10 @@
 package types
 
+import (
+	"fmt"
+	"strings"
+)
+
 func interfaceSliceToStringSlice(iSlice []interface{}) ([]string, bool) {
 	var ok bool
 	stringSlice := make([]string, len(iSlice))
@@ -21,3 +26,12 @@ func mergeMaps(m1, m2 map[string]interface{}) map[string]interface{} {
 	}
 	return m1
 }
+
+/* Format a byte slice as the comma-separated elements of a Go array literal */
+func byteLiteralList(b []byte) string {
+	elems := make([]string, len(b))
+	for i, v := range b {
+		elems[i] = fmt.Sprintf("%#02x", v)
+	}
+	return strings.Join(elems, ", ")
+}
@@ -0,0 +1,275 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/alanctgardner/gogen-avro/generator"
+)
+
+const validationErrorDef = `
+// ValidationError describes a value which can't be serialized, and the path of the field which holds it
+type ValidationError struct {
+	Path    string
+	Message string
+}
+`
+
+const validationErrorMethodDef = `
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+`
+
+const validationErrorsDef = `
+// ValidationErrors holds every value in a record which can't be serialized
+type ValidationErrors []*ValidationError
+`
+
+const validationErrorsMethodDef = `
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+`
+
+const validationPathDef = `
+func validationPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+`
+
+const validateRecordTemplate = `
+func %v(r %v, path string, errs ValidationErrors) ValidationErrors {
+	if r == nil {
+		return append(errs, &ValidationError{Path: path, Message: "Nil value for record %v"})
+	}
+%v	return errs
+}
+`
+
+const validateImportedRecordTemplate = `	if invalid, ok := r.ValidateAll().(%v.ValidationErrors); ok {
+		for _, e := range invalid {
+			errs = append(errs, &ValidationError{Path: validationPath(path, e.Path), Message: e.Message})
+		}
+	}
+`
+
+const validateEnumTemplate = `
+func %v(r %v, path string, errs ValidationErrors) ValidationErrors {
+	if r < 0 || r >= %v {
+		return append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Invalid value %%d for enum %v", int32(r))})
+	}
+	return errs
+}
+`
+
+const validateUnionTemplate = `
+func %v(r %v, path string, errs ValidationErrors) ValidationErrors {
+	%v
+%v
+	}
+	return append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("Invalid %v for %v", %v)})
+}
+`
+
+const validateOptionalTemplate = `
+func %v(r %v, path string, errs ValidationErrors) ValidationErrors {
+	if r == nil {
+		return errs
+	}
+	return %v(%v, path, errs)
+}
+`
+
+const validateArrayTemplate = `
+func %v(r %v, path string, errs ValidationErrors) ValidationErrors {
+	for i, v := range r {
+		errs = %v(v, fmt.Sprintf("%%v[%%v]", path, i), errs)
+	}
+	return errs
+}
+`
+
+const validateMapTemplate = `
+func %v(r %v, path string, errs ValidationErrors) ValidationErrors {
+	keys := make([]string, 0, len(r))
+	for k := range r {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		errs = %v(r[k], fmt.Sprintf("%%v[%%q]", path, k), errs)
+	}
+	return errs
+}
+`
+
+const recordValidateTemplate = `
+// Validate returns the first value in the record which can't be serialized, as a *ValidationError
+func (r %v) Validate() error {
+	if errs := %v(r, "", nil); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+`
+
+const recordValidateAllTemplate = `
+// ValidateAll returns every value in the record which can't be serialized, as ValidationErrors
+func (r %v) ValidateAll() error {
+	if errs := %v(r, "", nil); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+`
+
+func validateMethod(f Field) string {
+	return "validate" + f.FieldType()
+}
+
+func definitionValidateMethod(d Definition) string {
+	return "validate" + d.FieldType()
+}
+
+/*
+  Whether a value of the field's type can be invalid. Primitives and fixed values are always valid.
+*/
+func needsValidation(f Field) bool {
+	switch t := f.(type) {
+	case *arrayField:
+		return needsValidation(t.itemType)
+	case *mapField:
+		return needsValidation(t.itemType)
+	case *unionField:
+		if t.optional {
+			return needsValidation(t.optionalItem())
+		}
+		return true
+	case *Reference:
+		switch t.def.(type) {
+		case *RecordDefinition, *EnumDefinition:
+			return true
+		}
+	}
+	return false
+}
+
+func addValidateFunction(p *generator.Package, name, def string, imports ...string) {
+	for _, i := range imports {
+		p.AddImport(UTIL_FILE, i)
+	}
+	p.AddFunction(UTIL_FILE, "", name, def)
+}
+
+/*
+  Add the validator for the field, and for every type beneath it which needs one.
+*/
+func addValidateField(p *generator.Package, f Field) {
+	if !needsValidation(f) || p.HasFunction(UTIL_FILE, "", validateMethod(f)) {
+		return
+	}
+	switch t := f.(type) {
+	case *arrayField:
+		addValidateFunction(p, validateMethod(t), fmt.Sprintf(validateArrayTemplate, validateMethod(t), t.GoType(), validateMethod(t.itemType)), "fmt")
+		addValidateField(p, t.itemType)
+	case *mapField:
+		addValidateFunction(p, validateMethod(t), fmt.Sprintf(validateMapTemplate, validateMethod(t), t.GoType(), validateMethod(t.itemType)), "fmt", "sort")
+		addValidateField(p, t.itemType)
+	case *unionField:
+		addValidateUnion(p, t)
+	case *Reference:
+		addValidateDefinition(p, t.def)
+	}
+}
+
+func addValidateUnion(p *generator.Package, u *unionField) {
+	if u.optional {
+		item := u.optionalItem()
+		addValidateFunction(p, validateMethod(u), fmt.Sprintf(validateOptionalTemplate, validateMethod(u), u.GoType(), validateMethod(item), u.optionalValue("r")))
+		addValidateField(p, item)
+		return
+	}
+
+	cases := ""
+	usesBranch := false
+	for _, item := range u.itemType {
+		if needsValidation(item) {
+			cases += fmt.Sprintf("%v\nreturn %v(%v, path, errs)\n", u.branchCase(item), validateMethod(item), u.branchValue("r", item))
+			usesBranch = true
+		} else {
+			cases += fmt.Sprintf("%v\nreturn errs\n", u.branchCase(item))
+		}
+	}
+
+	var def string
+	if u.mode == UnionModeInterface {
+		switchStmt := u.branchTypeSwitch("r")
+		if usesBranch {
+			switchStmt = u.branchSwitch("r")
+		}
+		def = fmt.Sprintf(validateUnionTemplate, validateMethod(u), u.GoType(), switchStmt, cases, "value %T", u.GoType(), "r")
+	} else {
+		def = fmt.Sprintf(validateUnionTemplate, validateMethod(u), u.GoType(), u.branchSwitch("r"), cases, "UnionType %v", u.GoType(), "r.UnionType")
+	}
+	addValidateFunction(p, validateMethod(u), def, "fmt")
+	for _, item := range u.itemType {
+		addValidateField(p, item)
+	}
+}
+
+func addValidateDefinition(p *generator.Package, d Definition) {
+	if p.HasFunction(UTIL_FILE, "", definitionValidateMethod(d)) {
+		return
+	}
+	switch t := d.(type) {
+	case *EnumDefinition:
+		addValidateFunction(p, definitionValidateMethod(t), fmt.Sprintf(validateEnumTemplate, definitionValidateMethod(t), t.GoType(), len(t.symbols), t.name), "fmt")
+	case *RecordDefinition:
+		if t.pkg != "" {
+			// The errors of a record imported from another package are converted, with the record's path in front
+			addValidateFunction(p, definitionValidateMethod(t), fmt.Sprintf(validateRecordTemplate, definitionValidateMethod(t), t.GoType(), t.name, fmt.Sprintf(validateImportedRecordTemplate, t.pkg)))
+			return
+		}
+		fieldValidators := ""
+		for _, f := range t.fields {
+			if needsValidation(f) {
+				fieldValidators += fmt.Sprintf("\terrs = %v(r.%v, validationPath(path, %q), errs)\n", validateMethod(f), f.GoName(), f.AvroName())
+			}
+		}
+		addValidateFunction(p, definitionValidateMethod(t), fmt.Sprintf(validateRecordTemplate, definitionValidateMethod(t), t.GoType(), t.name, fieldValidators))
+		for _, f := range t.fields {
+			addValidateField(p, f)
+		}
+	}
+}
+
+/*
+  Add the Validate and ValidateAll methods, which check the record for values which can't be serialized:
+  nil records, enum values without a symbol and unions which don't hold a valid branch.
+*/
+func (r *RecordDefinition) AddValidate(p *generator.Package) {
+	// Import guard, to avoid circular dependencies
+	if p.HasFunction(r.filename(), r.GoType(), "Validate") {
+		return
+	}
+	p.AddStruct(UTIL_FILE, "ValidationError", validationErrorDef)
+	p.AddFunction(UTIL_FILE, "*ValidationError", "Error", validationErrorMethodDef)
+	p.AddStruct(UTIL_FILE, "ValidationErrors", validationErrorsDef)
+	p.AddImport(UTIL_FILE, "strings")
+	p.AddFunction(UTIL_FILE, "ValidationErrors", "Error", validationErrorsMethodDef)
+	addValidateFunction(p, "validationPath", validationPathDef)
+	addValidateDefinition(p, r)
+
+	p.AddFunction(r.filename(), r.GoType(), "Validate", fmt.Sprintf(recordValidateTemplate, r.GoType(), definitionValidateMethod(r)))
+	p.AddFunction(r.filename(), r.GoType(), "ValidateAll", fmt.Sprintf(recordValidateAllTemplate, r.GoType(), definitionValidateMethod(r)))
+}